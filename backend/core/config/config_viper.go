@@ -107,6 +107,24 @@ func setDefaultValue(v *viper.Viper) {
 	v.SetDefault("RESUME_PIPELINES", true)
 	// v.SetDefault("CORS_ALLOW_ORIGIN", "*")
 	v.SetDefault("CONSUME_PIPELINES", true)
+	v.SetDefault("WORKER_LEASE_DURATION", "60s")
+	v.SetDefault("WORKER_HEARTBEAT_INTERVAL", "15s")
+	v.SetDefault("SUBTASK_MAX_PARALLEL", 1)
+	v.SetDefault("DAG_PIPELINE_PLANS", false)
+	v.SetDefault("DAG_TASK_MAX_PARALLEL", 4)
+	v.SetDefault("TASK_TIMEOUT", "0s")
+	v.SetDefault("TASK_HANG_TIMEOUT", "0s")
+	v.SetDefault("TRACING_EXPORTER", "")
+	v.SetDefault("TRACING_FILE_PATH", "logs/traces.json")
+	v.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	v.SetDefault("RAW_DATA_COMPRESSION", "")
+	v.SetDefault("RAW_DATA_RETENTION", "")
+	v.SetDefault("RAW_DATA_MAINTENANCE_CRON", "0 3 * * *")
+	v.SetDefault("RAW_DATA_ARCHIVE_PATH", "")
+	v.SetDefault("API_CASSETTE_MODE", "")
+	v.SetDefault("API_CASSETTE_DIR", "")
+	v.SetDefault("API_CONDITIONAL_REQUESTS", false)
+	v.SetDefault("API_ADAPTIVE_RATE_LIMIT", true)
 }
 
 func init() {
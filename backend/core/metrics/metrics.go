@@ -0,0 +1,116 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "devlake"
+
+var (
+	// SubtaskDuration observes how long subtasks take, by plugin, subtask and result
+	SubtaskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "subtask_duration_seconds",
+		Help:      "Duration of subtasks by plugin, subtask and result.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400},
+	}, []string{"plugin", "subtask", "result"})
+
+	// BatchSaveRows counts rows written to the database by BatchSave
+	BatchSaveRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "batch_save_rows_total",
+		Help:      "Rows written to the database by BatchSave, by table.",
+	}, []string{"table"})
+
+	// ApiRequests counts http requests sent by ApiClient, by connection and response status code
+	ApiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Http requests sent to the data sources, by connection and status code (\"error\" if no response was received).",
+	}, []string{"connection", "code"})
+
+	// ApiRequestDuration observes the latency of http requests sent by ApiClient
+	ApiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of http requests sent to the data sources, by connection.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"connection"})
+
+	// ApiRetries counts the http requests retried by ApiAsyncClient
+	ApiRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_retries_total",
+		Help:      "Http requests retried by the async api client, by connection.",
+	}, []string{"connection"})
+
+	// ApiRateLimited counts the http requests rejected with 429 Too Many Requests
+	ApiRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_rate_limited_total",
+		Help:      "Http requests rejected by the data sources with 429 Too Many Requests, by connection.",
+	}, []string{"connection"})
+
+	// ApiRateLimitRemaining is the number of requests left in the current rate limit window as reported by the data source
+	ApiRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "api_rate_limit_remaining",
+		Help:      "Requests left in the current rate limit window as reported by the data sources, by connection.",
+	}, []string{"connection"})
+
+	// ApiRateLimitResetSeconds is the time left until the current rate limit window resets
+	ApiRateLimitResetSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "api_rate_limit_reset_seconds",
+		Help:      "Seconds until the current rate limit window of the data sources resets, by connection.",
+	}, []string{"connection"})
+)
+
+// Registry holds all DevLake metrics along with the go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SubtaskDuration,
+		BatchSaveRows,
+		ApiRequests,
+		ApiRequestDuration,
+		ApiRetries,
+		ApiRateLimited,
+		ApiRateLimitRemaining,
+		ApiRateLimitResetSeconds,
+	)
+}
+
+// Register registers extra collectors, i.e. the ones reading their values from the database on scraping
+func Register(collectors ...prometheus.Collector) {
+	Registry.MustRegister(collectors...)
+}
+
+// Handler serves the metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
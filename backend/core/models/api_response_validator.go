@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// ApiResponseValidator holds the validators of the last response to a GET request of a connection, so the request
+// could be sent conditionally with `If-None-Match` and `If-Modified-Since` to skip unchanged responses
+type ApiResponseValidator struct {
+	// Connection identifies the connection, i.e. `github#1`
+	Connection string `gorm:"primaryKey;type:varchar(100)" json:"connection"`
+	// UrlHash is the sha256 of the url, which might carry credentials in its query
+	UrlHash      string `gorm:"primaryKey;type:varchar(64)" json:"urlHash"`
+	ETag         string `gorm:"column:etag;type:varchar(255)" json:"etag"`
+	LastModified string `gorm:"type:varchar(100)" json:"lastModified"`
+	// RawCreatedAt and RawRows locate the raw rows the last response was saved as, which are reused on 304
+	RawCreatedAt *time.Time `json:"rawCreatedAt"`
+	RawRows      int        `json:"rawRows"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"index" json:"updatedAt"`
+}
+
+func (ApiResponseValidator) TableName() string {
+	return "_devlake_api_response_validators"
+}
@@ -91,5 +91,9 @@ type TriggerSyncPolicy struct {
 type SyncPolicy struct {
 	SkipOnFail bool       `json:"skipOnFail"`
 	TimeAfter  *time.Time `json:"timeAfter"`
+	// TaskTimeoutSeconds limits how long a task may run, 0 means using TASK_TIMEOUT
+	TaskTimeoutSeconds int `json:"taskTimeoutSeconds"`
+	// HangTimeoutSeconds limits how long a task may run without any progress, 0 means using TASK_HANG_TIMEOUT
+	HangTimeoutSeconds int `json:"hangTimeoutSeconds"`
 	TriggerSyncPolicy
 }
@@ -0,0 +1,65 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// CollectorCheckpoint records where a collector is with one of its inputs within a task, so the collection could
+// continue from the last committed page when the task gets resumed
+type CollectorCheckpoint struct {
+	RawTable string `gorm:"primaryKey;type:varchar(255)" json:"rawTable"`
+	// Params is a json string to identitfy rows of a specific scope (jira board, github repo)
+	Params string `gorm:"primaryKey;type:varchar(255)" json:"params"`
+	// Collector distinguishes collectors sharing the same raw table within a subtask
+	Collector string `gorm:"primaryKey;type:varchar(50)" json:"collector"`
+	// InputHash is the sha256 of the input json, empty input is hashed as well
+	InputHash  string `gorm:"primaryKey;type:varchar(64)" json:"inputHash"`
+	TaskId     uint64 `gorm:"index" json:"taskId"`
+	TotalPages int    `json:"totalPages"`
+	// NextPage, NextSkip and NextCursor locate the next page for collectors fetching pages one by one
+	NextPage   int       `json:"nextPage"`
+	NextSkip   int       `json:"nextSkip"`
+	NextCursor *string   `json:"nextCursor"`
+	Done       bool      `json:"done"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func (CollectorCheckpoint) TableName() string {
+	return "_devlake_collector_checkpoints"
+}
+
+// CollectorCheckpointPage is a page committed by a collector for one of its inputs within a task, it is saved along
+// with the raw rows of the page
+type CollectorCheckpointPage struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)" json:"rawTable"`
+	Params    string `gorm:"primaryKey;type:varchar(255)" json:"params"`
+	Collector string `gorm:"primaryKey;type:varchar(50)" json:"collector"`
+	InputHash string `gorm:"primaryKey;type:varchar(64)" json:"inputHash"`
+	Page      int    `gorm:"primaryKey;autoIncrement:false" json:"page"`
+	TaskId    uint64 `gorm:"index" json:"taskId"`
+	// HasMore tells whether the collection should continue after the page
+	HasMore   bool      `json:"hasMore"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (CollectorCheckpointPage) TableName() string {
+	return "_devlake_collector_checkpoint_pages"
+}
@@ -0,0 +1,47 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codequality
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+)
+
+// CqCommitCoverage holds the coverage totals of a commit of a repo
+type CqCommitCoverage struct {
+	domainlayer.DomainEntity
+	ProjectKey      string `gorm:"index;type:varchar(255)"` //domain project key
+	RepoId          string `gorm:"index;type:varchar(255)"`
+	RepoUrl         string
+	CommitSha       string `gorm:"index;type:varchar(40)"`
+	Format          string `gorm:"type:varchar(20)"`
+	FileCount       int
+	LinesToCover    int
+	CoveredLines    int
+	BranchesToCover int
+	CoveredBranches int
+	LineCoverage    float64
+	BranchCoverage  float64
+	Coverage        float64
+	CreatedDate     time.Time
+}
+
+func (CqCommitCoverage) TableName() string {
+	return "cq_commit_coverages"
+}
@@ -41,6 +41,10 @@ type CqFileMetrics struct {
 	UncoveredLines                      int
 	Coverage                            float64
 	LinesToCover                        int
+	BranchesToCover                     int
+	UncoveredBranches                   int
+	LineCoverage                        float64
+	BranchCoverage                      float64
 	DuplicatedLinesDensity              float64
 	DuplicatedBlocks                    int
 	DuplicatedFiles                     int
@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+const (
+	CHAT_CHANNEL_PUBLIC  = "PUBLIC"
+	CHAT_CHANNEL_PRIVATE = "PRIVATE"
+	CHAT_CHANNEL_DIRECT  = "DIRECT"
+)
+
+var _ plugin.Scope = (*ChatChannel)(nil)
+
+// ChatChannel is a channel/group of an instant messaging tool, e.g. slack or feishu
+type ChatChannel struct {
+	domainlayer.DomainEntity
+	Name        string `gorm:"type:varchar(255)"`
+	Type        string `gorm:"type:varchar(100)"`
+	Description string
+	CreatorId   string `gorm:"type:varchar(255)"`
+	CreatedDate *time.Time
+}
+
+func (ChatChannel) TableName() string {
+	return "chat_channels"
+}
+
+func (c *ChatChannel) ScopeId() string {
+	return c.Id
+}
+
+func (c *ChatChannel) ScopeName() string {
+	return c.Name
+}
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+)
+
+// ChatMessage is a message posted in a ChatChannel, replies point to the first message of the thread
+type ChatMessage struct {
+	domainlayer.DomainEntity
+	ChannelId       string `gorm:"index;type:varchar(255)"`
+	ParentMessageId string `gorm:"index;type:varchar(255)"`
+	SenderId        string `gorm:"type:varchar(255)"`
+	Type            string `gorm:"type:varchar(100)"`
+	Content         string
+	ReplyCount      int
+	CreatedDate     time.Time `gorm:"index"`
+}
+
+func (ChatMessage) TableName() string {
+	return "chat_messages"
+}
+
+// ChatMessagePullRequest links a message to the pull request it mentions
+type ChatMessagePullRequest struct {
+	MessageId     string `gorm:"primaryKey;type:varchar(255)"`
+	PullRequestId string `gorm:"primaryKey;type:varchar(255)"`
+	common.NoPKModel
+}
+
+func (ChatMessagePullRequest) TableName() string {
+	return "chat_message_pull_requests"
+}
+
+// ChatMessageIssue links a message to the issue it mentions
+type ChatMessageIssue struct {
+	MessageId string `gorm:"primaryKey;type:varchar(255)"`
+	IssueId   string `gorm:"primaryKey;type:varchar(255)"`
+	common.NoPKModel
+}
+
+func (ChatMessageIssue) TableName() string {
+	return "chat_message_issues"
+}
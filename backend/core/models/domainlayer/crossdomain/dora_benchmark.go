@@ -0,0 +1,38 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// DoraBenchmark describes what the low/medium/high/elite levels of a metric mean in a specific DORA report,
+// the rows are maintained by the migrations of the dora plugin
+type DoraBenchmark struct {
+	common.Model
+	Metric     string `json:"metric" gorm:"type:varchar(255)"`
+	Low        string `json:"low" gorm:"type:varchar(255)"`
+	Medium     string `json:"medium" gorm:"type:varchar(255)"`
+	High       string `json:"high" gorm:"type:varchar(255)"`
+	Elite      string `json:"elite" gorm:"type:varchar(255)"`
+	DoraReport string `json:"doraReport" gorm:"type:varchar(20)"`
+}
+
+func (DoraBenchmark) TableName() string {
+	return "dora_benchmarks"
+}
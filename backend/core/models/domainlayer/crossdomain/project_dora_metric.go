@@ -0,0 +1,67 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+const (
+	DORA_PERIOD_WEEK  = "WEEK"
+	DORA_PERIOD_MONTH = "MONTH"
+)
+
+const (
+	DORA_LEVEL_ELITE  = "elite"
+	DORA_LEVEL_HIGH   = "high"
+	DORA_LEVEL_MEDIUM = "medium"
+	DORA_LEVEL_LOW    = "low"
+)
+
+// ProjectDoraMetric holds the four key DORA metrics of a project for a calendar week or month,
+// graded against the benchmarks of the selected DORA report
+type ProjectDoraMetric struct {
+	ProjectName string    `json:"projectName" gorm:"primaryKey;type:varchar(100)"`
+	PeriodType  string    `json:"periodType" gorm:"primaryKey;type:varchar(20)"`
+	PeriodStart time.Time `json:"periodStart" gorm:"primaryKey"`
+	// PeriodEnd is exclusive, it equals to the PeriodStart of the next period
+	PeriodEnd  time.Time `json:"periodEnd"`
+	DoraReport string    `json:"doraReport" gorm:"type:varchar(20)"`
+
+	DeploymentCount          int    `json:"deploymentCount"`
+	DeploymentDays           int    `json:"deploymentDays"`
+	DeploymentFrequencyLevel string `json:"deploymentFrequencyLevel" gorm:"type:varchar(20)"`
+
+	MedianLeadTimeMinutes *int64 `json:"medianLeadTimeMinutes"`
+	LeadTimeLevel         string `json:"leadTimeLevel" gorm:"type:varchar(20)"`
+
+	FailedDeploymentCount  int      `json:"failedDeploymentCount"`
+	ChangeFailureRate      *float64 `json:"changeFailureRate"`
+	ChangeFailureRateLevel string   `json:"changeFailureRateLevel" gorm:"type:varchar(20)"`
+
+	MedianRecoveryTimeMinutes *int64 `json:"medianRecoveryTimeMinutes"`
+	RecoveryTimeLevel         string `json:"recoveryTimeLevel" gorm:"type:varchar(20)"`
+
+	common.NoPKModel
+}
+
+func (ProjectDoraMetric) TableName() string {
+	return "project_dora_metrics"
+}
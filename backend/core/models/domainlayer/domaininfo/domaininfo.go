@@ -51,6 +51,7 @@ func GetDomainTablesInfo() []dal.Tabler {
 		&code.RepoLanguage{},
 		&code.RepoSnapshot{},
 		// codequality
+		&codequality.CqCommitCoverage{},
 		&codequality.CqFileMetrics{},
 		&codequality.CqIssueCodeBlock{},
 		&codequality.CqIssue{},
@@ -59,10 +60,16 @@ func GetDomainTablesInfo() []dal.Tabler {
 		// crossdomain
 		&crossdomain.Account{},
 		&crossdomain.BoardRepo{},
+		&crossdomain.ChatChannel{},
+		&crossdomain.ChatMessage{},
+		&crossdomain.ChatMessageIssue{},
+		&crossdomain.ChatMessagePullRequest{},
+		&crossdomain.DoraBenchmark{},
 		&crossdomain.IssueCommit{},
 		&crossdomain.IssueRepoCommit{},
 		&crossdomain.ProjectMapping{},
 		&crossdomain.ProjectIncidentDeploymentRelationship{},
+		&crossdomain.ProjectDoraMetric{},
 		&crossdomain.ProjectPrMetric{},
 		&crossdomain.PullRequestIssue{},
 		&crossdomain.RefsIssuesDiffs{},
@@ -32,6 +32,7 @@ type QaTestCase struct {
 	Type        string    `gorm:"type:varchar(255);comment:Test case type | functional | api"`                // enum in image, using string
 	QaApiId     string    `gorm:"type:varchar(255);comment:Valid only when type = api, represents qa_api_id"` // nullable in image, using string
 	QaProjectId string    `gorm:"type:varchar(255);index;comment:Project ID"`
+	IsFlaky     bool      `gorm:"comment:Whether the test case both passed and failed on the same code"`
 }
 
 func (qaTestCase *QaTestCase) TableName() string {
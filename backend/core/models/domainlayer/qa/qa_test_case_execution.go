@@ -33,6 +33,9 @@ type QaTestCaseExecution struct {
 	FinishTime   time.Time `gorm:"comment:Test finish time"`
 	CreatorId    string    `gorm:"type:varchar(255);comment:Executor ID"`
 	Status       string    `gorm:"type:varchar(255);comment:Test execution status | PENDING | IN_PROGRESS | SUCCESS | FAILED"` // enum, using string
+	// CicdPipelineId and CommitSha link executions imported from CI test reports to the pipeline that ran them
+	CicdPipelineId string `gorm:"type:varchar(255);index;comment:CI/CD pipeline ID"`
+	CommitSha      string `gorm:"type:varchar(40);comment:Commit SHA under test"`
 }
 
 func (QaTestCaseExecution) TableName() string {
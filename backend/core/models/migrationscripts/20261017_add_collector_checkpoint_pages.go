@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addCollectorCheckpointPages)(nil)
+
+type addCollectorCheckpointPages struct{}
+
+func (*addCollectorCheckpointPages) Up(basicRes context.BasicRes) errors.Error {
+	db := basicRes.GetDal()
+	// committed pages are kept as rows from now on, checkpoints of the former json pages can't be resumed
+	err := db.Delete(&archived.CollectorCheckpoint{}, dal.Where("1 = 1"))
+	if err != nil {
+		return err
+	}
+	err = db.DropColumns("_devlake_collector_checkpoints", "pages")
+	if err != nil {
+		return err
+	}
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&archived.CollectorCheckpointPage{},
+	)
+}
+
+func (*addCollectorCheckpointPages) Version() uint64 {
+	return 20261017210000
+}
+
+func (*addCollectorCheckpointPages) Name() string {
+	return "add _devlake_collector_checkpoint_pages"
+}
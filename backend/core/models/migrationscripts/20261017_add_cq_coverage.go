@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addCqCoverage)(nil)
+
+type cqFileMetrics20261017 struct {
+	BranchesToCover   int
+	UncoveredBranches int
+	LineCoverage      float64
+	BranchCoverage    float64
+}
+
+func (cqFileMetrics20261017) TableName() string {
+	return "cq_file_metrics"
+}
+
+type addCqCoverage struct{}
+
+func (*addCqCoverage) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&cqFileMetrics20261017{},
+		&archived.CqCommitCoverage{},
+	)
+}
+
+func (*addCqCoverage) Version() uint64 {
+	return 20261017150000
+}
+
+func (*addCqCoverage) Name() string {
+	return "add branch coverage to cq_file_metrics and cq_commit_coverages"
+}
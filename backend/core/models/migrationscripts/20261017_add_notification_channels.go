@@ -0,0 +1,66 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+var _ plugin.MigrationScript = (*addNotificationChannels)(nil)
+
+type notification20261017Channel struct {
+	Channel string `gorm:"type:varchar(20)"`
+}
+
+func (notification20261017Channel) TableName() string {
+	return "_devlake_notifications"
+}
+
+type notificationSubscription20261017Channel struct {
+	Channel string `gorm:"type:varchar(20)"`
+}
+
+func (notificationSubscription20261017Channel) TableName() string {
+	return "_devlake_notification_subscriptions"
+}
+
+type addNotificationChannels struct{}
+
+func (*addNotificationChannels) Up(basicRes context.BasicRes) errors.Error {
+	db := basicRes.GetDal()
+	if err := db.AutoMigrate(&notification20261017Channel{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&notificationSubscription20261017Channel{}); err != nil {
+		return err
+	}
+	if err := db.Exec("UPDATE _devlake_notifications SET channel = ?", "webhook"); err != nil {
+		return err
+	}
+	return db.Exec("UPDATE _devlake_notification_subscriptions SET channel = ?", "webhook")
+}
+
+func (*addNotificationChannels) Version() uint64 {
+	return 20261017120000
+}
+
+func (*addNotificationChannels) Name() string {
+	return "add channel to notifications and subscriptions"
+}
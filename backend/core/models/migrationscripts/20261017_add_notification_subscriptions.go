@@ -0,0 +1,69 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addNotificationSubscriptions)(nil)
+
+type notification20261017 struct {
+	SubscriptionId uint64 `gorm:"index"`
+	Status         string `gorm:"type:varchar(20);index"`
+	Attempts       int
+	NextAttemptAt  *time.Time
+	DeliveredAt    *time.Time
+}
+
+func (notification20261017) TableName() string {
+	return "_devlake_notifications"
+}
+
+type addNotificationSubscriptions struct{}
+
+func (*addNotificationSubscriptions) Up(basicRes context.BasicRes) errors.Error {
+	db := basicRes.GetDal()
+	if err := db.AutoMigrate(&notification20261017{}); err != nil {
+		return err
+	}
+	// notifications sent before were delivered only once, whatever the response was
+	err := db.Exec("UPDATE _devlake_notifications SET status = ?, attempts = 1 WHERE status IS NULL OR status = ''", "DELIVERED")
+	if err != nil {
+		return err
+	}
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&archived.NotificationAttempt{},
+		&archived.NotificationSubscription{},
+	)
+}
+
+func (*addNotificationSubscriptions) Version() uint64 {
+	return 20261017110000
+}
+
+func (*addNotificationSubscriptions) Name() string {
+	return "add notification subscriptions and delivery attempts"
+}
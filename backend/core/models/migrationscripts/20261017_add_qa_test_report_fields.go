@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addQaTestReportFields)(nil)
+
+type qaTestCase20261017 struct {
+	IsFlaky bool
+}
+
+func (qaTestCase20261017) TableName() string {
+	return "qa_test_cases"
+}
+
+type qaTestCaseExecution20261017 struct {
+	CicdPipelineId string `gorm:"type:varchar(255);index"`
+	CommitSha      string `gorm:"type:varchar(40)"`
+}
+
+func (qaTestCaseExecution20261017) TableName() string {
+	return "qa_test_case_executions"
+}
+
+type addQaTestReportFields struct{}
+
+func (*addQaTestReportFields) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&qaTestCase20261017{},
+		&qaTestCaseExecution20261017{},
+	)
+}
+
+func (*addQaTestReportFields) Version() uint64 {
+	return 20261017140000
+}
+
+func (*addQaTestReportFields) Name() string {
+	return "add flaky flag and cicd pipeline link to qa tables"
+}
@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addTaskTimeouts)(nil)
+
+type task20261017 struct {
+	TimeoutSeconds int
+}
+
+func (task20261017) TableName() string {
+	return "_devlake_tasks"
+}
+
+type pipelineTimeout20261017 struct {
+	TaskTimeoutSeconds int
+	HangTimeoutSeconds int
+}
+
+func (pipelineTimeout20261017) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type blueprintTimeout20261017 struct {
+	TaskTimeoutSeconds int
+	HangTimeoutSeconds int
+}
+
+func (blueprintTimeout20261017) TableName() string {
+	return "_devlake_blueprints"
+}
+
+type addTaskTimeouts struct{}
+
+func (*addTaskTimeouts) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&task20261017{},
+		&pipelineTimeout20261017{},
+		&blueprintTimeout20261017{},
+	)
+}
+
+func (*addTaskTimeouts) Version() uint64 {
+	return 20261017180000
+}
+
+func (*addTaskTimeouts) Name() string {
+	return "add timeouts to _devlake_tasks, _devlake_pipelines and _devlake_blueprints"
+}
@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archived
+
+import "time"
+
+type CollectorCheckpoint struct {
+	RawTable   string       `gorm:"primaryKey;type:varchar(255)"`
+	Params     string       `gorm:"primaryKey;type:varchar(255)"`
+	Collector  string       `gorm:"primaryKey;type:varchar(50)"`
+	InputHash  string       `gorm:"primaryKey;type:varchar(64)"`
+	TaskId     uint64       `gorm:"index"`
+	Pages      map[int]bool `gorm:"type:json;serializer:json"`
+	TotalPages int
+	NextPage   int
+	NextSkip   int
+	NextCursor *string
+	Done       bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (CollectorCheckpoint) TableName() string {
+	return "_devlake_collector_checkpoints"
+}
+
+type CollectorCheckpointPage struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)"`
+	Params    string `gorm:"primaryKey;type:varchar(255)"`
+	Collector string `gorm:"primaryKey;type:varchar(50)"`
+	InputHash string `gorm:"primaryKey;type:varchar(64)"`
+	Page      int    `gorm:"primaryKey;autoIncrement:false"`
+	TaskId    uint64 `gorm:"index"`
+	HasMore   bool
+	CreatedAt time.Time
+}
+
+func (CollectorCheckpointPage) TableName() string {
+	return "_devlake_collector_checkpoint_pages"
+}
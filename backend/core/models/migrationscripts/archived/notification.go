@@ -33,3 +33,32 @@ type Notification struct {
 func (Notification) TableName() string {
 	return "_devlake_notifications"
 }
+
+type NotificationAttempt struct {
+	Model
+	NotificationId uint64 `gorm:"index"`
+	Attempt        int
+	ResponseCode   int
+	Response       string
+	Error          string
+	DurationMs     int64
+}
+
+func (NotificationAttempt) TableName() string {
+	return "_devlake_notification_attempts"
+}
+
+type NotificationSubscription struct {
+	Model
+	Name        string `gorm:"type:varchar(255)"`
+	Endpoint    string
+	Secret      string `gorm:"serializer:encdec"`
+	ProjectName string `gorm:"type:varchar(255);index"`
+	BlueprintId uint64
+	Statuses    []string `gorm:"type:json;serializer:json"`
+	Enabled     bool
+}
+
+func (NotificationSubscription) TableName() string {
+	return "_devlake_notification_subscriptions"
+}
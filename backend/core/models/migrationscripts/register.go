@@ -142,5 +142,17 @@ func All() []plugin.MigrationScript {
 		new(addPipelinePriority),
 		new(fixNullPriority),
 		new(modifyCicdDeploymentsToText),
+		new(addProjectDoraMetrics),
+		new(addNotificationSubscriptions),
+		new(addNotificationChannels),
+		new(addChatTables),
+		new(addQaTestReportFields),
+		new(addCqCoverage),
+		new(addPipelineLeases),
+		new(addCollectorCheckpoints),
+		new(addTaskTimeouts),
+		new(addApiResponseValidators),
+		new(addRawRowsToApiResponseValidators),
+		new(addCollectorCheckpointPages),
 	}
 }
@@ -18,6 +18,8 @@ limitations under the License.
 package models
 
 import (
+	"time"
+
 	"github.com/apache/incubator-devlake/core/models/common"
 )
 
@@ -27,17 +29,106 @@ const (
 	NotificationPipelineStatusChanged NotificationType = "PipelineStatusChanged"
 )
 
+const (
+	NOTIFICATION_PENDING   = "PENDING"
+	NOTIFICATION_RETRYING  = "RETRYING"
+	NOTIFICATION_DELIVERED = "DELIVERED"
+	NOTIFICATION_FAILED    = "FAILED"
+)
+
+const (
+	NOTIFICATION_CHANNEL_WEBHOOK = "webhook" // generic signed payload
+	NOTIFICATION_CHANNEL_SLACK   = "slack"   // slack incoming webhook
+	NOTIFICATION_CHANNEL_FEISHU  = "feishu"  // feishu custom bot webhook
+)
+
 // Notification records notifications sent by lake
 type Notification struct {
 	common.Model
-	Type         NotificationType
-	Endpoint     string
-	Nonce        string
-	ResponseCode int
-	Response     string
-	Data         string
+	SubscriptionId uint64           `json:"subscriptionId" gorm:"index"`
+	Channel        string           `json:"channel" gorm:"type:varchar(20)"`
+	Type           NotificationType `json:"type"`
+	Endpoint       string           `json:"endpoint"`
+	Nonce          string           `json:"-"`
+	ResponseCode   int              `json:"responseCode"`
+	Response       string           `json:"response"`
+	Data           string           `json:"data"`
+	Status         string           `json:"status" gorm:"type:varchar(20);index"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  *time.Time       `json:"nextAttemptAt"`
+	DeliveredAt    *time.Time       `json:"deliveredAt"`
 }
 
 func (Notification) TableName() string {
 	return "_devlake_notifications"
 }
+
+// NotificationAttempt records the outcome of every delivery attempt of a notification
+type NotificationAttempt struct {
+	common.Model
+	NotificationId uint64 `json:"notificationId" gorm:"index"`
+	Attempt        int    `json:"attempt"`
+	ResponseCode   int    `json:"responseCode"`
+	Response       string `json:"response"`
+	Error          string `json:"error"`
+	DurationMs     int64  `json:"durationMs"`
+}
+
+func (NotificationAttempt) TableName() string {
+	return "_devlake_notification_attempts"
+}
+
+// NotificationSubscription is an endpoint interested in pipeline notifications,
+// empty filters match everything except that chat channels only receive finished pipelines by default
+type NotificationSubscription struct {
+	common.Model
+	Name        string   `json:"name" gorm:"type:varchar(255)"`
+	Channel     string   `json:"channel" gorm:"type:varchar(20)"`
+	Endpoint    string   `json:"endpoint"`
+	Secret      string   `json:"secret,omitempty" gorm:"serializer:encdec"`
+	ProjectName string   `json:"projectName" gorm:"type:varchar(255);index"`
+	BlueprintId uint64   `json:"blueprintId"`
+	Statuses    []string `json:"statuses" gorm:"type:json;serializer:json"`
+	Enabled     bool     `json:"enabled"`
+}
+
+func (NotificationSubscription) TableName() string {
+	return "_devlake_notification_subscriptions"
+}
+
+// Matches tells whether the subscription is interested in the pipeline
+func (s *NotificationSubscription) Matches(projectName string, blueprintId uint64, status string) bool {
+	if !s.Enabled {
+		return false
+	}
+	if s.ProjectName != "" && s.ProjectName != projectName {
+		return false
+	}
+	if s.BlueprintId != 0 && s.BlueprintId != blueprintId {
+		return false
+	}
+	statuses := s.Statuses
+	if len(statuses) == 0 {
+		if s.Channel == "" || s.Channel == NOTIFICATION_CHANNEL_WEBHOOK {
+			return true
+		}
+		statuses = FinishedTaskStatus
+	}
+	for _, st := range statuses {
+		if st == status {
+			return true
+		}
+	}
+	return false
+}
+
+type ApiInputNotificationSubscription struct {
+	Name        string   `json:"name" validate:"required,max=255"`
+	Channel     string   `json:"channel" validate:"omitempty,oneof=webhook slack feishu"`
+	Endpoint    string   `json:"endpoint" validate:"required,url"`
+	Secret      string   `json:"secret"`
+	ProjectName string   `json:"projectName" validate:"max=255"`
+	BlueprintId uint64   `json:"blueprintId"`
+	Statuses    []string `json:"statuses"`
+	Enabled     *bool    `json:"enabled"`
+}
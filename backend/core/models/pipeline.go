@@ -27,6 +27,11 @@ type GenericPipelineTask[T any] struct {
 	Plugin   string   `json:"plugin" binding:"required"`
 	Subtasks []string `json:"subtasks"`
 	Options  T        `json:"options"`
+	// Id and DependsOn are only used by DAG-based plans, check PipelinePlan.IsDag for details
+	Id        string   `json:"id,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// TimeoutSeconds overrides the task timeout of the blueprint
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // PipelineTask represents a smallest unit of execution inside a PipelinePlan
@@ -67,7 +72,10 @@ type Pipeline struct {
 	Stage         int          `json:"stage"`
 	Labels        []string     `json:"labels" gorm:"-"`
 	Priority      int          `json:"priority"` // greater is higher
-	SyncPolicy    `gorm:"embedded"`
+	// WorkerId is the devlake instance running the pipeline, it must renew LeaseExpiresAt in cluster mode
+	WorkerId       string     `json:"workerId" gorm:"type:varchar(255);index"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt"`
+	SyncPolicy     `gorm:"embedded"`
 }
 
 // We use a 2D array because the request body must be an array of a set of tasks
@@ -0,0 +1,204 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// PipelineTaskPosition locates a task inside a PipelinePlan, Row and Col start from 1 like Task.PipelineRow and Task.PipelineCol
+type PipelineTaskPosition struct {
+	Row int
+	Col int
+}
+
+// IsDag checks if tasks of the PipelinePlan declare their own dependencies by `id` and `dependsOn`, in which case
+// each task would be executed as soon as all tasks it depends on are finished instead of waiting for the whole
+// previous stage. Stages of a DAG-based plan are kept for displaying only.
+func (plan PipelinePlan) IsDag() bool {
+	for _, stage := range plan {
+		for _, task := range stage {
+			if task != nil && (task.Id != "" || len(task.DependsOn) > 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON accepts both the legacy stage format `[[task, ...], ...]` and the DAG format `[task, ...]`,
+// the latter would be converted to stages so it can be stored and displayed the same way
+func (plan *PipelinePlan) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*plan = nil
+		return nil
+	}
+	var stages []PipelineStage
+	if err := json.Unmarshal(data, &stages); err == nil {
+		*plan = stages
+		return nil
+	}
+	var tasks []*PipelineTask
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return err
+	}
+	dag, err := NewDagPipelinePlan(tasks)
+	if err != nil {
+		return err
+	}
+	*plan = dag
+	return nil
+}
+
+// NewDagPipelinePlan arranges tasks into stages by the length of their dependency chains, so every task is placed
+// in a later stage than all the tasks it depends on
+func NewDagPipelinePlan(tasks []*PipelineTask) (PipelinePlan, errors.Error) {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if task == nil || task.Id == "" {
+			return nil, errors.BadInput.New(fmt.Sprintf("task #%d of the DAG plan has no id", i))
+		}
+		if _, ok := index[task.Id]; ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("duplicated task id %s in the DAG plan", task.Id))
+		}
+		index[task.Id] = i
+	}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, errors.BadInput.New(fmt.Sprintf("task %s depends on unknown task %s", task.Id, dep))
+			}
+		}
+	}
+	// compute the stage of each task with Kahn's algorithm, which also detects cycles
+	levels := make([]int, len(tasks))
+	pending := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	queue := make([]int, 0, len(tasks))
+	for i, task := range tasks {
+		pending[i] = len(task.DependsOn)
+		for _, dep := range task.DependsOn {
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+		if pending[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	resolved := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		resolved++
+		for _, j := range dependents[i] {
+			if levels[i]+1 > levels[j] {
+				levels[j] = levels[i] + 1
+			}
+			pending[j]--
+			if pending[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+	if resolved < len(tasks) {
+		return nil, errors.BadInput.New("circular dependencies found in the DAG plan")
+	}
+	plan := make(PipelinePlan, 0)
+	for i, task := range tasks {
+		for len(plan) <= levels[i] {
+			plan = append(plan, PipelineStage{})
+		}
+		plan[levels[i]] = append(plan[levels[i]], task)
+	}
+	return plan, nil
+}
+
+// ValidateDag makes sure every task of a DAG-based plan has a unique id and only depends on tasks of earlier stages
+func (plan PipelinePlan) ValidateDag() errors.Error {
+	if !plan.IsDag() {
+		return nil
+	}
+	rows := make(map[string]int)
+	for i, stage := range plan {
+		for j, task := range stage {
+			if task.Id == "" {
+				return errors.BadInput.New(fmt.Sprintf("task plan[%d][%d] of the DAG plan has no id", i, j))
+			}
+			if _, ok := rows[task.Id]; ok {
+				return errors.BadInput.New(fmt.Sprintf("duplicated task id %s in the DAG plan", task.Id))
+			}
+			rows[task.Id] = i
+		}
+	}
+	for i, stage := range plan {
+		for _, task := range stage {
+			for _, dep := range task.DependsOn {
+				row, ok := rows[dep]
+				if !ok {
+					return errors.BadInput.New(fmt.Sprintf("task %s depends on unknown task %s", task.Id, dep))
+				}
+				if row >= i {
+					return errors.BadInput.New(fmt.Sprintf("task %s must be placed in a later stage than task %s it depends on", task.Id, dep))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TaskDependencies returns the positions of the tasks each task has to wait for. Tasks of a legacy plan wait for
+// the whole previous non-empty stage, while tasks of a DAG-based plan only wait for the ones in their `dependsOn`.
+func (plan PipelinePlan) TaskDependencies() map[PipelineTaskPosition][]PipelineTaskPosition {
+	deps := make(map[PipelineTaskPosition][]PipelineTaskPosition)
+	if plan.IsDag() {
+		positions := make(map[string]PipelineTaskPosition)
+		for i, stage := range plan {
+			for j, task := range stage {
+				positions[task.Id] = PipelineTaskPosition{Row: i + 1, Col: j + 1}
+			}
+		}
+		for i, stage := range plan {
+			for j, task := range stage {
+				position := PipelineTaskPosition{Row: i + 1, Col: j + 1}
+				deps[position] = []PipelineTaskPosition{}
+				for _, dep := range task.DependsOn {
+					if depPosition, ok := positions[dep]; ok {
+						deps[position] = append(deps[position], depPosition)
+					}
+				}
+			}
+		}
+		return deps
+	}
+	previous := []PipelineTaskPosition{}
+	for i, stage := range plan {
+		current := make([]PipelineTaskPosition, 0, len(stage))
+		for j := range stage {
+			position := PipelineTaskPosition{Row: i + 1, Col: j + 1}
+			deps[position] = previous
+			current = append(current, position)
+		}
+		if len(current) > 0 {
+			previous = current
+		}
+	}
+	return deps
+}
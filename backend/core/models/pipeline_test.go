@@ -18,6 +18,7 @@ limitations under the License.
 package models
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,3 +53,37 @@ func TestPipelinePlan_IsEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelinePlan_UnmarshalDag(t *testing.T) {
+	var plan PipelinePlan
+	err := json.Unmarshal([]byte(`[
+		{"id": "dora", "plugin": "dora", "dependsOn": ["github", "gitextractor"]},
+		{"id": "github", "plugin": "github"},
+		{"id": "jira", "plugin": "jira"},
+		{"id": "gitextractor", "plugin": "gitextractor", "dependsOn": ["github"]}
+	]`), &plan)
+	assert.Nil(t, err)
+	assert.True(t, plan.IsDag())
+	assert.Len(t, plan, 3)
+	assert.Equal(t, "github", plan[0][0].Id)
+	assert.Equal(t, "jira", plan[0][1].Id)
+	assert.Equal(t, "gitextractor", plan[1][0].Id)
+	assert.Equal(t, "dora", plan[2][0].Id)
+	assert.Nil(t, plan.ValidateDag())
+	// dora waits for github and gitextractor only, not jira
+	assert.ElementsMatch(t,
+		[]PipelineTaskPosition{{Row: 1, Col: 1}, {Row: 2, Col: 1}},
+		plan.TaskDependencies()[PipelineTaskPosition{Row: 3, Col: 1}],
+	)
+
+	err = json.Unmarshal([]byte(`[{"id": "a", "plugin": "a", "dependsOn": ["b"]}, {"id": "b", "plugin": "b", "dependsOn": ["a"]}]`), &plan)
+	assert.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`[[{"plugin": "github"}], [{"plugin": "gitextractor"}]]`), &plan)
+	assert.Nil(t, err)
+	assert.False(t, plan.IsDag())
+	assert.Equal(t,
+		[]PipelineTaskPosition{{Row: 1, Col: 1}},
+		plan.TaskDependencies()[PipelineTaskPosition{Row: 2, Col: 1}],
+	)
+}
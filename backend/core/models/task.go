@@ -32,11 +32,12 @@ const (
 	TASK_FAILED    = "TASK_FAILED"
 	TASK_CANCELLED = "TASK_CANCELLED"
 	TASK_PARTIAL   = "TASK_PARTIAL"
+	TASK_TIMEOUT   = "TASK_TIMEOUT"
 )
 
 var (
 	PendingTaskStatus  = []string{TASK_CREATED, TASK_RERUN, TASK_RUNNING}
-	FinishedTaskStatus = []string{TASK_PARTIAL, TASK_CANCELLED, TASK_FAILED, TASK_COMPLETED}
+	FinishedTaskStatus = []string{TASK_PARTIAL, TASK_CANCELLED, TASK_FAILED, TASK_TIMEOUT, TASK_COMPLETED}
 )
 
 type TaskProgressDetail struct {
@@ -48,6 +49,14 @@ type TaskProgressDetail struct {
 	SubTaskNumber        int    `json:"subTaskNumber"`
 	CollectSubtaskNumber int    `json:"collectSubtaskNumber"`
 	OtherSubtaskNumber   int    `json:"otherSubtaskNumber"`
+	// ParallelSubTasks keeps the records progress of subtasks running alongside the current one
+	ParallelSubTasks map[string]*SubTaskRecords `json:"-"`
+}
+
+// SubTaskRecords is the records progress of a single subtask
+type SubTaskRecords struct {
+	TotalRecords    int
+	FinishedRecords int
 }
 
 type NewTask struct {
@@ -77,6 +86,8 @@ type Task struct {
 	BeganAt       *time.Time `json:"beganAt"`
 	FinishedAt    *time.Time `json:"finishedAt" gorm:"index"`
 	SpentSeconds  int        `json:"spentSeconds"`
+	// TimeoutSeconds limits how long the task may run, 0 means using the one of the pipeline
+	TimeoutSeconds int `json:"timeoutSeconds"`
 }
 
 func (Task) TableName() string {
@@ -19,6 +19,7 @@ package plugin
 
 import (
 	"context"
+	"time"
 
 	corecontext "github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
@@ -33,6 +34,8 @@ const (
 	SubTaskSetProgress
 	SubTaskIncProgress
 	SetCurrentSubTask
+	// SubTaskFinished is sent once a subtask ended, no matter it succeeded or not
+	SubTaskFinished
 )
 
 type RunningProgress struct {
@@ -75,6 +78,22 @@ type SubTask interface {
 	Execute() errors.Error
 } // nolint
 
+type taskIdContextKey struct{}
+
+// ContextWithTaskId attaches the id of the running task to ctx, so helpers could tell which task they work for
+func ContextWithTaskId(ctx context.Context, taskId uint64) context.Context {
+	return context.WithValue(ctx, taskIdContextKey{}, taskId)
+}
+
+// TaskIdFromContext returns the id of the running task attached by ContextWithTaskId, or 0 if there is none
+func TaskIdFromContext(ctx context.Context) uint64 {
+	if ctx == nil {
+		return 0
+	}
+	taskId, _ := ctx.Value(taskIdContextKey{}).(uint64)
+	return taskId
+}
+
 // SubTaskEntryPoint All subtasks from plugins should comply to this prototype, so they could be orchestrated by framework
 type SubTaskEntryPoint func(c SubTaskContext) errors.Error
 
@@ -107,6 +126,8 @@ type SubTaskMeta struct {
 	DependencyTables []string
 	ProductTables    []string
 	ForceRunOnResume bool // Should a subtask be ran dispite it was finished before
+	// Timeout limits how long the subtask may run, the whole task would be marked as TASK_TIMEOUT once exceeded
+	Timeout time.Duration
 }
 
 // PluginTask Implement this interface to let framework run tasks for you
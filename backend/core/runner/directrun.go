@@ -31,6 +31,7 @@ import (
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/models/migrationscripts"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -51,6 +52,13 @@ func RunCmd(cmd *cobra.Command) {
 // options: plugin config
 func DirectRun(cmd *cobra.Command, args []string, pluginTask plugin.PluginTask, options map[string]interface{}, timeAfter string) {
 	basicRes := CreateAppBasicRes()
+	shutdownTracing, tracingErr := tracing.Init(basicRes.GetConfigReader())
+	if tracingErr != nil {
+		panic(tracingErr)
+	}
+	defer func() {
+		_ = shutdownTracing(context.Background())
+	}()
 	tasks, err := cmd.Flags().GetStringSlice("subtasks")
 	if err != nil {
 		panic(err)
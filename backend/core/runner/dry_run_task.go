@@ -0,0 +1,123 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"fmt"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+)
+
+// SubtasksFlag returns whether each subtask of the plugin would be executed for the task, based on the subtasks
+// enabled by default, the ones specified by the task, and the sync policy
+func SubtasksFlag(task *models.Task, subtaskMetas []plugin.SubTaskMeta, syncPolicy *models.SyncPolicy) (map[string]bool, errors.Error) {
+	subtasksFlag := make(map[string]bool)
+	for _, subtaskMeta := range subtaskMetas {
+		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
+	}
+	/* subtasksFlag example
+	subtasksFlag := map[string]bool{
+		"collectProject": true,
+		"convertCommits": true,
+		...
+	}
+	*/
+
+	// user specifies what subtasks to run
+	if len(task.Subtasks) != 0 {
+		// decode user specified subtasks
+		var specifiedTasks []string
+		err := api.Decode(task.Subtasks, &specifiedTasks, nil)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "subtasks could not be decoded")
+		}
+		if len(specifiedTasks) > 0 {
+			// first, disable all subtasks
+			for task := range subtasksFlag {
+				subtasksFlag[task] = false
+			}
+			// second, check specified subtasks is valid and enable them if so
+			for _, task := range specifiedTasks {
+				if _, ok := subtasksFlag[task]; ok {
+					subtasksFlag[task] = true
+				} else {
+					return nil, errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
+				}
+			}
+		}
+	}
+
+	// 1. make sure `Collect` subtasks skip if `SkipCollectors` is true
+	// 2. make sure `Required` subtasks are always enabled
+	for _, subtaskMeta := range subtaskMetas {
+		if syncPolicy != nil && syncPolicy.SkipCollectors && strings.Contains(strings.ToLower(subtaskMeta.Name), "collect") {
+			subtasksFlag[subtaskMeta.Name] = false
+		}
+		if subtaskMeta.Required {
+			subtasksFlag[subtaskMeta.Name] = true
+		}
+	}
+	return subtasksFlag, nil
+}
+
+// DryRunPluginTask validates the options of the task the same way RunPluginTask does, by preparing the task data,
+// and returns the subtasks which would be executed, without executing any of them.
+// Note that PrepareTaskData is called as it is: plugins may call their data sources and save what they learn, e.g.
+// github and gitlab fetch the repo or project from the api and save it as a scope when it is missing
+func DryRunPluginTask(
+	ctx gocontext.Context,
+	basicRes context.BasicRes,
+	task *models.Task,
+	syncPolicy *models.SyncPolicy,
+) ([]plugin.SubTaskMeta, errors.Error) {
+	pluginMeta, err := plugin.GetPlugin(task.Plugin)
+	if err != nil {
+		return nil, errors.Default.WrapRaw(err)
+	}
+	pluginTask, ok := pluginMeta.(plugin.PluginTask)
+	if !ok {
+		return nil, errors.Default.New(fmt.Sprintf("plugin %s doesn't support PluginTask interface", task.Plugin))
+	}
+	subtaskMetas := pluginTask.SubTaskMetas()
+	subtasksFlag, err := SubtasksFlag(task, subtaskMetas, syncPolicy)
+	if err != nil {
+		return nil, err
+	}
+	taskCtx := contextimpl.NewDefaultTaskContext(ctx, basicRes, task.Plugin, subtasksFlag, nil)
+	if closeablePlugin, ok := pluginTask.(plugin.CloseablePluginTask); ok {
+		defer closeablePlugin.Close(taskCtx)
+	}
+	_, err = pluginTask.PrepareTaskData(taskCtx, task.Options)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error preparing task data for %s", task.Plugin))
+	}
+	enabledMetas := make([]plugin.SubTaskMeta, 0, len(subtaskMetas))
+	for _, subtaskMeta := range subtaskMetas {
+		if subtasksFlag[subtaskMeta.Name] {
+			enabledMetas = append(enabledMetas, subtaskMeta)
+		}
+	}
+	return enabledMetas, nil
+}
@@ -0,0 +1,58 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtasksFlag(t *testing.T) {
+	subtaskMetas := []plugin.SubTaskMeta{
+		{Name: "collectIssues", EnabledByDefault: true},
+		{Name: "extractIssues", EnabledByDefault: true},
+		{Name: "convertIssues", EnabledByDefault: false},
+		{Name: "collectAccounts", Required: true},
+	}
+
+	flags, err := SubtasksFlag(&models.Task{}, subtaskMetas, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"collectIssues":   true,
+		"extractIssues":   true,
+		"convertIssues":   false,
+		"collectAccounts": true,
+	}, flags)
+
+	flags, err = SubtasksFlag(&models.Task{Subtasks: []string{"collectIssues", "convertIssues"}}, subtaskMetas, &models.SyncPolicy{
+		TriggerSyncPolicy: models.TriggerSyncPolicy{SkipCollectors: true},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"collectIssues":   false,
+		"extractIssues":   false,
+		"convertIssues":   true,
+		"collectAccounts": true,
+	}, flags)
+
+	_, err = SubtasksFlag(&models.Task{Subtasks: []string{"collectPrs"}}, subtaskMetas, nil)
+	assert.NotNil(t, err)
+}
@@ -19,6 +19,8 @@ package runner
 
 import (
 	gocontext "context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/apache/incubator-devlake/core/context"
@@ -44,6 +46,14 @@ func RunPipeline(
 	if err != nil {
 		return err
 	}
+	dbPipeline := &models.Pipeline{}
+	err = db.First(dbPipeline, dal.Where("id = ?", pipelineId))
+	if err != nil {
+		return err
+	}
+	if dbPipeline.Plan.IsDag() {
+		return runPipelineDag(basicRes, dbPipeline, tasks, runTasks)
+	}
 	taskIds := make([][]uint64, 0)
 	for _, task := range tasks {
 		for len(taskIds) < task.PipelineRow {
@@ -103,3 +113,112 @@ func runPipelineTasks(
 	}
 	return err
 }
+
+// runPipelineDag executes each task as soon as all the tasks it depends on are finished, with up to
+// DAG_TASK_MAX_PARALLEL tasks running at the same time. The tasks which are not pending (finished by a previous run,
+// for example) are considered done already
+func runPipelineDag(
+	basicRes context.BasicRes,
+	dbPipeline *models.Pipeline,
+	tasks []models.Task,
+	runTasks func([]uint64) errors.Error,
+) errors.Error {
+	db := basicRes.GetDal()
+	log := basicRes.GetLogger()
+	// if pipeline has been cancelled, just return.
+	if dbPipeline.Status == models.TASK_CANCELLED {
+		return nil
+	}
+	taskDeps := dbPipeline.Plan.TaskDependencies()
+	positions := make([]models.PipelineTaskPosition, len(tasks))
+	byPosition := make(map[models.PipelineTaskPosition][]int)
+	for i, task := range tasks {
+		positions[i] = models.PipelineTaskPosition{Row: task.PipelineRow, Col: task.PipelineCol}
+		byPosition[positions[i]] = append(byPosition[positions[i]], i)
+	}
+	pending := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	ready := make([]int, 0, len(tasks))
+	for i := range tasks {
+		for _, dep := range taskDeps[positions[i]] {
+			for _, j := range byPosition[dep] {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	maxParallel := basicRes.GetConfigReader().GetInt("DAG_TASK_MAX_PARALLEL")
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	type result struct {
+		index int
+		err   errors.Error
+	}
+	results := make(chan result, len(tasks))
+	running := 0
+	stage := 0
+	var err errors.Error
+	for {
+		for err == nil && running < maxParallel && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			// stage keeps the furthest row reached for displaying
+			if tasks[i].PipelineRow > stage {
+				stage = tasks[i].PipelineRow
+				e := db.UpdateColumns(dbPipeline, []dal.DalSet{
+					{ColumnName: "status", Value: models.TASK_RUNNING},
+					{ColumnName: "stage", Value: stage},
+				})
+				if e != nil {
+					log.Error(e, "update pipeline state failed")
+				}
+			}
+			running++
+			go func(i int) {
+				var e errors.Error
+				defer func() {
+					if r := recover(); r != nil {
+						e = errors.Default.New(fmt.Sprintf("task %d panicked: %v", tasks[i].ID, r))
+					}
+					results <- result{index: i, err: e}
+				}()
+				e = runTasks([]uint64{tasks[i].ID})
+			}(i)
+		}
+		if running == 0 {
+			break
+		}
+		r := <-results
+		running--
+		if r.err != nil {
+			log.Error(r.err, "run task failed")
+			if errors.Is(r.err, gocontext.Canceled) || !dbPipeline.SkipOnFail {
+				// wait for the running tasks and stop scheduling new ones
+				if err == nil {
+					err = r.err
+				}
+				continue
+			}
+		}
+		for _, j := range dependents[r.index] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+		// keep the order of the plan among tasks that are ready at the same time
+		sort.Ints(ready)
+	}
+	if dbPipeline.BeganAt != nil {
+		log.Info("pipeline finished in %d ms: %v", time.Now().UnixMilli()-dbPipeline.BeganAt.UnixMilli(), err)
+	} else {
+		log.Info("pipeline finished at %d ms: %v", time.Now().UnixMilli(), err)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	mockcontext "github.com/apache/incubator-devlake/mocks/core/context"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// wideDag returns a DAG-based pipeline of independent tasks along with its tasks
+func wideDag(width int, skipOnFail bool) (*models.Pipeline, []models.Task) {
+	stage := models.PipelineStage{}
+	tasks := make([]models.Task, 0, width)
+	for i := 0; i < width; i++ {
+		stage = append(stage, &models.PipelineTask{Plugin: "test", Id: string(rune('a' + i))})
+		task := models.Task{PipelineRow: 1, PipelineCol: i + 1}
+		task.ID = uint64(i + 1)
+		tasks = append(tasks, task)
+	}
+	pipeline := &models.Pipeline{Plan: models.PipelinePlan{stage}}
+	pipeline.SkipOnFail = skipOnFail
+	return pipeline, tasks
+}
+
+func dagBasicRes(maxParallel int) *mockcontext.BasicRes {
+	v := viper.New()
+	v.Set("DAG_TASK_MAX_PARALLEL", maxParallel)
+	res := unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {
+		mockDal.On("UpdateColumns", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	})
+	res.On("GetConfigReader").Return(v)
+	return res
+}
+
+func TestRunPipelineDagLimitsParallelTasks(t *testing.T) {
+	pipeline, tasks := wideDag(6, false)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var ran []uint64
+	err := runPipelineDag(dagBasicRes(2), pipeline, tasks, func(taskIds []uint64) errors.Error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		ran = append(ran, taskIds...)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, peak)
+	assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 5, 6}, ran)
+}
+
+func TestRunPipelineDagStopsAfterFailure(t *testing.T) {
+	var mu sync.Mutex
+	var ran []uint64
+	runTasks := func(taskIds []uint64) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, taskIds...)
+		if taskIds[0] == 1 {
+			return errors.Default.New("failed")
+		}
+		return nil
+	}
+
+	pipeline, tasks := wideDag(3, false)
+	err := runPipelineDag(dagBasicRes(1), pipeline, tasks, runTasks)
+	assert.NotNil(t, err)
+	assert.Equal(t, []uint64{1}, ran)
+
+	// tasks keep going when the pipeline skips failed tasks
+	ran = nil
+	pipeline, tasks = wideDag(3, true)
+	err = runPipelineDag(dagBasicRes(1), pipeline, tasks, runTasks)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, ran)
+}
+
+func TestRunPipelineDagRecoversPanics(t *testing.T) {
+	pipeline, tasks := wideDag(2, false)
+	err := runPipelineDag(dagBasicRes(2), pipeline, tasks, func(taskIds []uint64) errors.Error {
+		if taskIds[0] == 2 {
+			panic("boom")
+		}
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "task 2 panicked")
+}
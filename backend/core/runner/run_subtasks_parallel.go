@@ -0,0 +1,143 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"fmt"
+	"sort"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// subtaskDependencies returns, for each subtask, the indexes of the preceding subtasks it must wait for.
+// A subtask depends on an earlier one when it is listed in Dependencies, when it reads a table the earlier
+// one produces, when it produces a table the earlier one reads or produces, or when either of them
+// declares no tables at all, in which case it is treated as a barrier to keep the original ordering safe.
+func subtaskDependencies(metas []plugin.SubTaskMeta) [][]int {
+	deps := make([][]int, len(metas))
+	for j := range metas {
+		for i := 0; i < j; i++ {
+			if subtaskDependsOn(&metas[j], &metas[i]) {
+				deps[j] = append(deps[j], i)
+			}
+		}
+	}
+	return deps
+}
+
+func subtaskDependsOn(later, earlier *plugin.SubTaskMeta) bool {
+	for _, dep := range later.Dependencies {
+		if dep != nil && dep.Name == earlier.Name {
+			return true
+		}
+	}
+	if !declaresTables(later) || !declaresTables(earlier) {
+		return true
+	}
+	return tablesIntersect(later.DependencyTables, earlier.ProductTables) ||
+		tablesIntersect(later.ProductTables, earlier.DependencyTables) ||
+		tablesIntersect(later.ProductTables, earlier.ProductTables)
+}
+
+func declaresTables(meta *plugin.SubTaskMeta) bool {
+	return len(meta.ProductTables) > 0 || len(meta.DependencyTables) > 0
+}
+
+func tablesIntersect(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runSubtasksInParallel executes subtasks as soon as all of their dependencies are finished, with at most
+// maxParallel of them running at the same time. No more subtasks would be started after the first failure,
+// and the first error is returned once the running ones have ended.
+func runSubtasksInParallel(
+	ctx gocontext.Context,
+	metas []plugin.SubTaskMeta,
+	maxParallel int,
+	execute func(index int) errors.Error,
+) errors.Error {
+	type result struct {
+		index int
+		err   errors.Error
+	}
+	deps := subtaskDependencies(metas)
+	pending := make([]int, len(metas))
+	dependents := make([][]int, len(metas))
+	ready := make([]int, 0, len(metas))
+	for j, d := range deps {
+		pending[j] = len(d)
+		for _, i := range d {
+			dependents[i] = append(dependents[i], j)
+		}
+		if len(d) == 0 {
+			ready = append(ready, j)
+		}
+	}
+	results := make(chan result, len(metas))
+	running := 0
+	var firstErr errors.Error
+	for {
+		for firstErr == nil && running < maxParallel && len(ready) > 0 {
+			if ctx.Err() != nil {
+				firstErr = errors.Convert(ctx.Err())
+				break
+			}
+			index := ready[0]
+			ready = ready[1:]
+			running++
+			go func() {
+				var err errors.Error
+				defer func() {
+					if r := recover(); r != nil {
+						err = errors.Default.New(fmt.Sprintf("subtask %s panicked: %v", metas[index].Name, r))
+					}
+					results <- result{index: index, err: err}
+				}()
+				err = execute(index)
+			}()
+		}
+		if running == 0 {
+			return firstErr
+		}
+		r := <-results
+		running--
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		for _, j := range dependents[r.index] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+		// keep the declared order among subtasks that are ready at the same time
+		sort.Ints(ready)
+	}
+}
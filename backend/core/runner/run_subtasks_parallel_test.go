@@ -0,0 +1,85 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"sync"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtaskDependencies(t *testing.T) {
+	collectIssues := plugin.SubTaskMeta{Name: "collectIssues", DependencyTables: []string{}, ProductTables: []string{"raw_issues"}}
+	collectPrs := plugin.SubTaskMeta{Name: "collectPrs", DependencyTables: []string{}, ProductTables: []string{"raw_prs"}}
+	extractIssues := plugin.SubTaskMeta{Name: "extractIssues", DependencyTables: []string{"raw_issues"}, ProductTables: []string{"tool_issues"}}
+	extractPrs := plugin.SubTaskMeta{Name: "extractPrs", DependencyTables: []string{"raw_prs"}, ProductTables: []string{"tool_prs"}}
+	enrich := plugin.SubTaskMeta{Name: "enrich", Dependencies: []*plugin.SubTaskMeta{&extractPrs}}
+	convert := plugin.SubTaskMeta{Name: "convert", DependencyTables: []string{"tool_issues"}, ProductTables: []string{"issues"}}
+
+	deps := subtaskDependencies([]plugin.SubTaskMeta{collectIssues, collectPrs, extractIssues, extractPrs, enrich, convert})
+	assert.Empty(t, deps[0])
+	assert.Empty(t, deps[1])
+	assert.Equal(t, []int{0}, deps[2])
+	assert.Equal(t, []int{1}, deps[3])
+	// enrich declares no tables, so it waits for everything before it and blocks everything after it
+	assert.Equal(t, []int{0, 1, 2, 3}, deps[4])
+	assert.Equal(t, []int{2, 4}, deps[5])
+}
+
+func TestRunSubtasksInParallel(t *testing.T) {
+	metas := []plugin.SubTaskMeta{
+		{Name: "a", ProductTables: []string{"raw_a"}},
+		{Name: "b", ProductTables: []string{"raw_b"}},
+		{Name: "c", DependencyTables: []string{"raw_a"}, ProductTables: []string{"tool_a"}},
+		{Name: "d", DependencyTables: []string{"raw_b"}, ProductTables: []string{"tool_b"}},
+	}
+	var mu sync.Mutex
+	finished := map[string]bool{}
+	err := runSubtasksInParallel(gocontext.Background(), metas, 2, func(i int) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		switch metas[i].Name {
+		case "c":
+			assert.True(t, finished["a"])
+		case "d":
+			assert.True(t, finished["b"])
+		}
+		finished[metas[i].Name] = true
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, finished, 4)
+
+	// dependents of a failed subtask must not be started
+	finished = map[string]bool{}
+	err = runSubtasksInParallel(gocontext.Background(), metas, 1, func(i int) errors.Error {
+		if metas[i].Name == "a" {
+			return errors.Default.New("failed")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		finished[metas[i].Name] = true
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.False(t, finished["c"])
+}
@@ -20,6 +20,7 @@ package runner
 import (
 	gocontext "context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -29,10 +30,11 @@ import (
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/metrics"
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/core/utils"
-	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	contextimpl "github.com/apache/incubator-devlake/impls/context"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 )
@@ -62,6 +64,11 @@ func RunTask(
 	if task.BeganAt != nil {
 		beganAt = *task.BeganAt
 	}
+	ctx, span := tracing.Start(ctx, "task", append(
+		tracing.PluginAttributes(task.Plugin, task.Options),
+		tracing.PipelineIdKey.Int64(int64(task.PipelineId)),
+		tracing.TaskIdKey.Int64(int64(task.ID)),
+	)...)
 	// make sure task status always correct even if it panicked
 	defer func() {
 		if r := recover(); r != nil {
@@ -78,6 +85,10 @@ func RunTask(
 		finishedAt := time.Now()
 		spentSeconds := finishedAt.Unix() - beganAt.Unix()
 		if err != nil {
+			status := models.TASK_FAILED
+			if errors.Is(err, ErrTimeout) {
+				status = models.TASK_TIMEOUT
+			}
 			lakeErr := errors.AsLakeErrorType(err)
 			subTaskName := "unknown"
 			if lakeErr = lakeErr.As(errors.SubtaskErr); lakeErr != nil {
@@ -88,7 +99,7 @@ func RunTask(
 				lakeErr = errors.Convert(err)
 			}
 			dbe := db.UpdateColumns(task, []dal.DalSet{
-				{ColumnName: "status", Value: models.TASK_FAILED},
+				{ColumnName: "status", Value: status},
 				{ColumnName: "message", Value: lakeErr.Error()},
 				{ColumnName: "error_name", Value: lakeErr.Messages().Format()},
 				{ColumnName: "finished_at", Value: finishedAt},
@@ -109,6 +120,7 @@ func RunTask(
 				logger.Error(dbe, "failed to finalize task status into db (task succeeded)")
 			}
 		}
+		tracing.End(span, err)
 		// update finishedTasks
 		errors.Must(db.UpdateColumn(
 			&models.Pipeline{},
@@ -136,14 +148,35 @@ func RunTask(
 		return dbe
 	}
 
+	timeout, hangTimeout := taskTimeouts(basicRes, task, dbPipeline)
+	watchdog := watchTask(ctx, timeout, hangTimeout, progress)
+	defer watchdog.Stop()
 	err = RunPluginTask(
-		ctx,
+		watchdog.ctx,
 		basicRes.ReplaceLogger(logger),
 		task,
-		progress,
+		watchdog.progress,
 		&dbPipeline.SyncPolicy,
 	)
-	return err
+	return timeoutErrorOf(watchdog.ctx, err)
+}
+
+// taskTimeouts returns the timeout and the hang timeout of the task, the timeout of the task itself takes precedence
+// over the one of the blueprint, which in turn takes precedence over the global config
+func taskTimeouts(basicRes context.BasicRes, task *models.Task, dbPipeline *models.Pipeline) (time.Duration, time.Duration) {
+	cfg := basicRes.GetConfigReader()
+	timeout := cfg.GetDuration("TASK_TIMEOUT")
+	if dbPipeline.TaskTimeoutSeconds > 0 {
+		timeout = time.Duration(dbPipeline.TaskTimeoutSeconds) * time.Second
+	}
+	if task.TimeoutSeconds > 0 {
+		timeout = time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	hangTimeout := cfg.GetDuration("TASK_HANG_TIMEOUT")
+	if dbPipeline.HangTimeoutSeconds > 0 {
+		hangTimeout = time.Duration(dbPipeline.HangTimeoutSeconds) * time.Second
+	}
+	return timeout, hangTimeout
 }
 
 // RunPluginTask FIXME ...
@@ -185,51 +218,9 @@ func RunPluginSubTasks(
 	logger.Info("start plugin")
 	// find out all possible subtasks this plugin can offer
 	subtaskMetas := pluginTask.SubTaskMetas()
-	subtasksFlag := make(map[string]bool)
-	for _, subtaskMeta := range subtaskMetas {
-		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
-	}
-	/* subtasksFlag example
-	subtasksFlag := map[string]bool{
-		"collectProject": true,
-		"convertCommits": true,
-		...
-	}
-	*/
-
-	// user specifies what subtasks to run
-	if len(task.Subtasks) != 0 {
-		// decode user specified subtasks
-		var specifiedTasks []string
-		err := api.Decode(task.Subtasks, &specifiedTasks, nil)
-		if err != nil {
-			return errors.Default.Wrap(err, "subtasks could not be decoded")
-		}
-		if len(specifiedTasks) > 0 {
-			// first, disable all subtasks
-			for task := range subtasksFlag {
-				subtasksFlag[task] = false
-			}
-			// second, check specified subtasks is valid and enable them if so
-			for _, task := range specifiedTasks {
-				if _, ok := subtasksFlag[task]; ok {
-					subtasksFlag[task] = true
-				} else {
-					return errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
-				}
-			}
-		}
-	}
-
-	// 1. make sure `Collect` subtasks skip if `SkipCollectors` is true
-	// 2. make sure `Required` subtasks are always enabled
-	for _, subtaskMeta := range subtaskMetas {
-		if syncPolicy != nil && syncPolicy.SkipCollectors && strings.Contains(strings.ToLower(subtaskMeta.Name), "collect") {
-			subtasksFlag[subtaskMeta.Name] = false
-		}
-		if subtaskMeta.Required {
-			subtasksFlag[subtaskMeta.Name] = true
-		}
+	subtasksFlag, err := SubtasksFlag(task, subtaskMetas, syncPolicy)
+	if err != nil {
+		return err
 	}
 
 	// calculate total step(number of task to run)
@@ -240,6 +231,10 @@ func RunPluginSubTasks(
 		}
 	}
 
+	ctx = plugin.ContextWithTaskId(ctx, task.ID)
+	// subtasks with a timeout cancel the whole task once they run out of time
+	ctx, cancel := gocontext.WithCancelCause(ctx)
+	defer cancel(nil)
 	taskCtx := contextimpl.NewDefaultTaskContext(ctx, basicRes, task.Plugin, subtasksFlag, progress)
 	if closeablePlugin, ok := pluginTask.(plugin.CloseablePluginTask); ok {
 		defer closeablePlugin.Close(taskCtx)
@@ -292,18 +287,7 @@ func RunPluginSubTasks(
 
 	// execute subtasks in order
 	taskCtx.SetProgress(0, steps)
-	subtaskNumber := 0
-	for _, subtaskMeta := range subtaskMetas {
-		subtaskCtx, err := taskCtx.SubTaskContext(subtaskMeta.Name)
-		if err != nil {
-			// sth went wrong
-			return errors.Default.Wrap(err, fmt.Sprintf("error getting context subtask %s", subtaskMeta.Name))
-		}
-		subtaskNumber++
-		if subtaskCtx == nil {
-			// subtask was disabled
-			continue
-		}
+	execute := func(subtaskMeta *plugin.SubTaskMeta, subtaskCtx plugin.SubTaskContext, subtaskNumber int) errors.Error {
 		// run subtask
 		if progress != nil {
 			progress <- plugin.RunningProgress{
@@ -327,13 +311,33 @@ func RunPluginSubTasks(
 		} else {
 			logger.Info("executing subtask %s", subtaskMeta.Name)
 			start := time.Now()
-			err = runSubtask(basicRes, subtaskCtx, task.ID, subtaskNumber, subtaskMeta.EntryPoint)
+			spanCtx, span := tracing.Start(subtaskCtx.GetContext(), "subtask", append(
+				tracing.PluginAttributes(task.Plugin, task.Options),
+				tracing.TaskIdKey.Int64(int64(task.ID)),
+				tracing.SubtaskKey.String(subtaskMeta.Name),
+			)...)
+			err := runWithTimeout(subtaskMeta.Name, subtaskMeta.Timeout, cancel, func() errors.Error {
+				return runSubtask(basicRes, &tracedSubTaskContext{subtaskCtx, spanCtx}, task.ID, subtaskNumber, subtaskMeta.EntryPoint)
+			})
+			tracing.End(span, err)
+			// notify once the subtask status was written into the db
+			if progress != nil {
+				defer func() {
+					progress <- plugin.RunningProgress{
+						Type:          plugin.SubTaskFinished,
+						SubTaskName:   subtaskMeta.Name,
+						SubTaskNumber: subtaskNumber,
+					}
+				}()
+			}
 			logger.Info("subtask %s finished in %d ms", subtaskMeta.Name, time.Since(start).Milliseconds())
+			err = timeoutErrorOf(ctx, err)
+			observeSubtaskDuration(task.Plugin, subtaskMeta.Name, time.Since(start), err)
 			if err != nil {
-				err = errors.SubtaskErr.Wrap(err, fmt.Sprintf("subtask %s ended unexpectedly", subtaskMeta.Name), errors.WithData(&subtaskMeta))
+				err = errors.SubtaskErr.Wrap(err, fmt.Sprintf("subtask %s ended unexpectedly", subtaskMeta.Name), errors.WithData(subtaskMeta))
 				logger.Error(err, "")
 				where := dal.Where("task_id = ? and name = ?", task.ID, subtaskCtx.GetName())
-				if err := basicRes.GetDal().UpdateColumns(subtask, []dal.DalSet{
+				if err := basicRes.GetDal().UpdateColumns(&models.Subtask{}, []dal.DalSet{
 					{ColumnName: "is_failed", Value: true},
 					{ColumnName: "message", Value: err.Error()},
 				}, where); err != nil {
@@ -343,9 +347,41 @@ func RunPluginSubTasks(
 			}
 		}
 		taskCtx.IncProgress(1)
+		return nil
+	}
+
+	// collect the enabled subtasks along with their numbers
+	enabledMetas := make([]plugin.SubTaskMeta, 0, len(subtaskMetas))
+	enabledCtxs := make([]plugin.SubTaskContext, 0, len(subtaskMetas))
+	enabledNumbers := make([]int, 0, len(subtaskMetas))
+	for i, subtaskMeta := range subtaskMetas {
+		subtaskCtx, err := taskCtx.SubTaskContext(subtaskMeta.Name)
+		if err != nil {
+			// sth went wrong
+			return errors.Default.Wrap(err, fmt.Sprintf("error getting context subtask %s", subtaskMeta.Name))
+		}
+		if subtaskCtx == nil {
+			// subtask was disabled
+			continue
+		}
+		enabledMetas = append(enabledMetas, subtaskMeta)
+		enabledCtxs = append(enabledCtxs, subtaskCtx)
+		enabledNumbers = append(enabledNumbers, i+1)
 	}
 
-	return nil
+	maxParallel := basicRes.GetConfigReader().GetInt("SUBTASK_MAX_PARALLEL")
+	if maxParallel <= 1 {
+		for i := range enabledMetas {
+			if err := execute(&enabledMetas[i], enabledCtxs[i], enabledNumbers[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	logger.Info("executing subtasks with up to %d in parallel", maxParallel)
+	return runSubtasksInParallel(ctx, enabledMetas, maxParallel, func(i int) errors.Error {
+		return execute(&enabledMetas[i], enabledCtxs[i], enabledNumbers[i])
+	})
 }
 
 // UpdateProgressDetail FIXME ...
@@ -356,7 +392,16 @@ func UpdateProgressDetail(basicRes context.BasicRes, taskId uint64, progressDeta
 	task := &models.Task{
 		Model: common.Model{ID: taskId},
 	}
-	subtask := &models.Subtask{}
+	if p.SubTaskName != "" && p.SubTaskName != progressDetail.SubTaskName &&
+		(p.Type == plugin.SubTaskSetProgress || p.Type == plugin.SubTaskIncProgress) {
+		// progress of a subtask running in parallel with the current one
+		updateParallelSubTaskProgress(basicRes, taskId, progressDetail, p, skipSubtaskProgressUpdate)
+		return
+	}
+	if p.Type == plugin.SubTaskFinished {
+		// nothing to update, the subtask status was recorded by the runner
+		return
+	}
 	originalFinishedRecords := progressDetail.FinishedRecords
 	switch p.Type {
 	case plugin.TaskSetProgress:
@@ -375,23 +420,65 @@ func UpdateProgressDetail(basicRes context.BasicRes, taskId uint64, progressDeta
 	case plugin.SubTaskIncProgress:
 		progressDetail.FinishedRecords = p.Current
 	case plugin.SetCurrentSubTask:
+		if progressDetail.SubTaskName != "" && progressDetail.SubTaskName != p.SubTaskName && progressDetail.ParallelSubTasks != nil {
+			// the previous current subtask may still be running in parallel, keep tracking its records
+			progressDetail.ParallelSubTasks[progressDetail.SubTaskName] = &models.SubTaskRecords{
+				TotalRecords:    progressDetail.TotalRecords,
+				FinishedRecords: progressDetail.FinishedRecords,
+			}
+		}
 		progressDetail.SubTaskName = p.SubTaskName
 		progressDetail.SubTaskNumber = p.SubTaskNumber
 		// reset finished records
 		progressDetail.FinishedRecords = 0
+		if records, ok := progressDetail.ParallelSubTasks[p.SubTaskName]; ok {
+			progressDetail.TotalRecords = records.TotalRecords
+			progressDetail.FinishedRecords = records.FinishedRecords
+			delete(progressDetail.ParallelSubTasks, p.SubTaskName)
+		}
 	}
 	if skipSubtaskProgressUpdate {
 		return
 	}
-	currentFinishedRecords := progressDetail.FinishedRecords
-	currentTotalRecords := progressDetail.TotalRecords
+	updateSubtaskFinishedRecords(basicRes, taskId, progressDetail.SubTaskName, originalFinishedRecords, progressDetail.FinishedRecords, progressDetail.TotalRecords)
+}
+
+// updateParallelSubTaskProgress tracks records progress of subtasks which are not the current one
+func updateParallelSubTaskProgress(
+	basicRes context.BasicRes,
+	taskId uint64,
+	progressDetail *models.TaskProgressDetail,
+	p *plugin.RunningProgress,
+	skipSubtaskProgressUpdate bool,
+) {
+	if progressDetail.ParallelSubTasks == nil {
+		progressDetail.ParallelSubTasks = make(map[string]*models.SubTaskRecords)
+	}
+	records, ok := progressDetail.ParallelSubTasks[p.SubTaskName]
+	if !ok {
+		records = &models.SubTaskRecords{}
+		progressDetail.ParallelSubTasks[p.SubTaskName] = records
+	}
+	originalFinishedRecords := records.FinishedRecords
+	if p.Type == plugin.SubTaskSetProgress {
+		records.TotalRecords = p.Total
+	} else {
+		records.FinishedRecords = p.Current
+	}
+	if skipSubtaskProgressUpdate {
+		return
+	}
+	updateSubtaskFinishedRecords(basicRes, taskId, p.SubTaskName, originalFinishedRecords, records.FinishedRecords, records.TotalRecords)
+}
+
+func updateSubtaskFinishedRecords(basicRes context.BasicRes, taskId uint64, subtaskName string, originalFinishedRecords, currentFinishedRecords, currentTotalRecords int) {
 	// update progress if progress is more than 1%
 	// or there is progress if no total record provided
 	if (currentTotalRecords > 0 && float64(currentFinishedRecords-originalFinishedRecords)/float64(currentTotalRecords) > 0.01) || (currentTotalRecords <= 0 && currentFinishedRecords > originalFinishedRecords) {
 		// update subtask progress
-		where := dal.Where("task_id = ? and name = ?", taskId, progressDetail.SubTaskName)
-		err := basicRes.GetDal().UpdateColumns(subtask, []dal.DalSet{
-			{ColumnName: "finished_records", Value: progressDetail.FinishedRecords},
+		where := dal.Where("task_id = ? and name = ?", taskId, subtaskName)
+		err := basicRes.GetDal().UpdateColumns(&models.Subtask{}, []dal.DalSet{
+			{ColumnName: "finished_records", Value: currentFinishedRecords},
 		}, where)
 		if err != nil {
 			basicRes.GetLogger().Error(err, "failed to update _devlake_subtasks progress")
@@ -425,6 +512,28 @@ func runSubtask(
 	return entryPoint(ctx)
 }
 
+// tracedSubTaskContext carries the span of the subtask, so the spans started by the subtask become its children
+type tracedSubTaskContext struct {
+	plugin.SubTaskContext
+	ctx gocontext.Context
+}
+
+func (c *tracedSubTaskContext) GetContext() gocontext.Context {
+	return c.ctx
+}
+
+func observeSubtaskDuration(pluginName string, subtaskName string, duration time.Duration, err errors.Error) {
+	result := "success"
+	if errors.Is(err, ErrTimeout) {
+		result = "timeout"
+	} else if errors.Is(err, gocontext.Canceled) {
+		result = "canceled"
+	} else if err != nil {
+		result = "failure"
+	}
+	metrics.SubtaskDuration.WithLabelValues(pluginName, subtaskName, result).Observe(duration.Seconds())
+}
+
 func recordSubtask(basicRes context.BasicRes, subtask *models.Subtask) {
 	where := dal.Where("task_id = ? and name = ?", subtask.TaskID, subtask.Name)
 	if err := basicRes.GetDal().UpdateColumns(subtask, []dal.DalSet{
@@ -438,6 +547,9 @@ func recordSubtask(basicRes context.BasicRes, subtask *models.Subtask) {
 	}
 }
 
+// TaskLogTap returns a writer receiving a copy of the log of the task if set, i.e. to stream the log to clients
+var TaskLogTap func(task *models.Task) io.Writer
+
 func getTaskLogger(parentLogger log.Logger, task *models.Task) (log.Logger, errors.Error) {
 	logger := parentLogger.Nested(fmt.Sprintf("task #%d", task.ID))
 	loggingPath := logruslog.GetTaskLoggerPath(logger.GetConfig(), task)
@@ -445,6 +557,11 @@ func getTaskLogger(parentLogger log.Logger, task *models.Task) (log.Logger, erro
 	if err != nil {
 		return nil, err
 	}
+	if TaskLogTap != nil {
+		if tap := TaskLogTap(task); tap != nil {
+			stream = io.MultiWriter(stream, tap)
+		}
+	}
 	logger.SetStream(&log.LoggerStreamConfig{
 		Path:   loggingPath,
 		Writer: stream,
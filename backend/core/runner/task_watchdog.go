@@ -0,0 +1,156 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	goerror "errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// ErrTimeout is wrapped by the cause of cancelling a task which ran out of time or stopped making progress
+var ErrTimeout = goerror.New("timeout")
+
+func newTimeoutCause(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrTimeout, fmt.Sprintf(format, a...))
+}
+
+// timeoutErrorOf replaces err with a timeout error if ctx was cancelled because of a timeout, so it would not be
+// taken as cancelled by the user
+func timeoutErrorOf(ctx gocontext.Context, err errors.Error) errors.Error {
+	if err == nil || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	if cause := gocontext.Cause(ctx); cause != nil && errors.Is(cause, ErrTimeout) {
+		return errors.Timeout.Wrap(cause, err.Error())
+	}
+	return err
+}
+
+// taskWatchdog cancels a task once it runs longer than the timeout, or no progress was reported within the
+// hang timeout
+type taskWatchdog struct {
+	ctx          gocontext.Context
+	cancel       gocontext.CancelCauseFunc
+	progress     chan plugin.RunningProgress
+	ownsProgress bool
+	lastProgress atomic.Int64
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// watchTask derives the context of a task from ctx, along with a progress channel forwarding everything to
+// `progress` while keeping track of the last time any progress was made. Zero timeout or hangTimeout disables
+// the related check.
+func watchTask(
+	ctx gocontext.Context,
+	timeout time.Duration,
+	hangTimeout time.Duration,
+	progress chan plugin.RunningProgress,
+) *taskWatchdog {
+	w := &taskWatchdog{
+		progress: progress,
+		stop:     make(chan struct{}),
+	}
+	w.ctx, w.cancel = gocontext.WithCancelCause(ctx)
+	w.lastProgress.Store(time.Now().UnixNano())
+	if hangTimeout > 0 {
+		w.progress = make(chan plugin.RunningProgress, cap(progress)+1)
+		w.ownsProgress = true
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for p := range w.progress {
+				w.lastProgress.Store(time.Now().UnixNano())
+				if progress != nil {
+					progress <- p
+				}
+			}
+		}()
+	}
+	if timeout > 0 || hangTimeout > 0 {
+		w.wg.Add(1)
+		go w.watch(timeout, hangTimeout)
+	}
+	return w
+}
+
+func (w *taskWatchdog) watch(timeout time.Duration, hangTimeout time.Duration) {
+	defer w.wg.Done()
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	var check <-chan time.Time
+	if hangTimeout > 0 {
+		interval := hangTimeout / 10
+		if interval < 100*time.Millisecond {
+			interval = 100 * time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.ctx.Done():
+			return
+		case <-deadline:
+			w.cancel(newTimeoutCause("task exceeded the timeout of %s", timeout))
+			return
+		case <-check:
+			idle := time.Since(time.Unix(0, w.lastProgress.Load()))
+			if idle > hangTimeout {
+				w.cancel(newTimeoutCause("task made no progress for %s", idle.Truncate(time.Second)))
+				return
+			}
+		}
+	}
+}
+
+// Stop releases the watchdog, it must be called after the task finished sending progress
+func (w *taskWatchdog) Stop() {
+	close(w.stop)
+	if w.ownsProgress {
+		close(w.progress)
+	}
+	w.wg.Wait()
+	w.cancel(nil)
+}
+
+// runWithTimeout cancels the task through `cancel` if `run` doesn't return within timeout
+func runWithTimeout(name string, timeout time.Duration, cancel gocontext.CancelCauseFunc, run func() errors.Error) errors.Error {
+	if timeout <= 0 {
+		return run()
+	}
+	timer := time.AfterFunc(timeout, func() {
+		cancel(newTimeoutCause("subtask %s exceeded the timeout of %s", name, timeout))
+	})
+	defer timer.Stop()
+	return run()
+}
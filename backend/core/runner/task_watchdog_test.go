@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchTaskTimeout(t *testing.T) {
+	w := watchTask(gocontext.Background(), 50*time.Millisecond, 0, nil)
+	<-w.ctx.Done()
+	err := timeoutErrorOf(w.ctx, errors.Convert(w.ctx.Err()))
+	w.Stop()
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.False(t, errors.Is(err, gocontext.Canceled))
+}
+
+func TestWatchTaskHang(t *testing.T) {
+	progress := make(chan plugin.RunningProgress, 10)
+	w := watchTask(gocontext.Background(), 0, 300*time.Millisecond, progress)
+	// keep reporting progress for a while, the task must not be cancelled meanwhile
+	for i := 0; i < 5; i++ {
+		w.progress <- plugin.RunningProgress{Type: plugin.TaskIncProgress}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Nil(t, w.ctx.Err())
+	assert.Len(t, progress, 5)
+	// then stop reporting
+	select {
+	case <-w.ctx.Done():
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "hanging task was not cancelled")
+	}
+	assert.True(t, errors.Is(gocontext.Cause(w.ctx), ErrTimeout))
+	w.Stop()
+}
+
+func TestWatchTaskCancelledByUser(t *testing.T) {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	w := watchTask(ctx, time.Hour, time.Hour, nil)
+	cancel()
+	<-w.ctx.Done()
+	err := timeoutErrorOf(w.ctx, errors.Convert(w.ctx.Err()))
+	w.Stop()
+	assert.False(t, errors.Is(err, ErrTimeout))
+	assert.True(t, errors.Is(err, gocontext.Canceled))
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	ctx, cancel := gocontext.WithCancelCause(gocontext.Background())
+	err := runWithTimeout("slow", 50*time.Millisecond, cancel, func() errors.Error {
+		<-ctx.Done()
+		return errors.Convert(ctx.Err())
+	})
+	err = timeoutErrorOf(ctx, err)
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Contains(t, err.Error(), "subtask slow exceeded the timeout")
+}
@@ -0,0 +1,183 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	gocontext "context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/apache/incubator-devlake"
+
+const (
+	// EXPORTER_NONE disables tracing
+	EXPORTER_NONE = ""
+	// EXPORTER_OTLP exports spans to an OTLP collector over http
+	EXPORTER_OTLP = "otlp"
+	// EXPORTER_FILE writes spans to a local file, one json object per span
+	EXPORTER_FILE = "file"
+)
+
+// Attribute keys shared by the spans of DevLake
+const (
+	PipelineIdKey   = attribute.Key("devlake.pipeline_id")
+	TaskIdKey       = attribute.Key("devlake.task_id")
+	PluginKey       = attribute.Key("devlake.plugin")
+	SubtaskKey      = attribute.Key("devlake.subtask")
+	ConnectionIdKey = attribute.Key("devlake.connection_id")
+	ScopeIdKey      = attribute.Key("devlake.scope_id")
+)
+
+// ShutdownFunc flushes the pending spans and releases the exporter
+type ShutdownFunc func(ctx gocontext.Context) errors.Error
+
+// Init sets up the global tracer provider according to the TRACING_* configuration, the returned ShutdownFunc
+// should be called before the process exits. Tracing is off unless TRACING_EXPORTER is set, in which case all
+// spans are no-op.
+func Init(cfg config.ConfigReader) (ShutdownFunc, errors.Error) {
+	noop := func(gocontext.Context) errors.Error { return nil }
+	var exporter sdktrace.SpanExporter
+	switch exporterName := cfg.GetString("TRACING_EXPORTER"); exporterName {
+	case EXPORTER_NONE:
+		return noop, nil
+	case EXPORTER_OTLP:
+		var opts []otlptracehttp.Option
+		if endpoint := cfg.GetString("TRACING_OTLP_ENDPOINT"); endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if cfg.GetBool("TRACING_OTLP_INSECURE") {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		e, err := otlptracehttp.New(gocontext.Background(), opts...)
+		if err != nil {
+			return noop, errors.Default.Wrap(err, "failed to create the otlp trace exporter")
+		}
+		exporter = e
+	case EXPORTER_FILE:
+		w, err := openTraceFile(cfg.GetString("TRACING_FILE_PATH"))
+		if err != nil {
+			return noop, err
+		}
+		e, e2 := stdouttrace.New(stdouttrace.WithWriter(w))
+		if e2 != nil {
+			return noop, errors.Default.Wrap(e2, "failed to create the file trace exporter")
+		}
+		exporter = &closingExporter{SpanExporter: e, closer: w}
+	default:
+		return noop, errors.BadInput.New(fmt.Sprintf("unknown TRACING_EXPORTER %s, expected %s or %s", exporterName, EXPORTER_OTLP, EXPORTER_FILE))
+	}
+	provider := NewTracerProvider(exporter, cfg.GetFloat64("TRACING_SAMPLE_RATIO"))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return func(ctx gocontext.Context) errors.Error {
+		return errors.Convert(provider.Shutdown(ctx))
+	}, nil
+}
+
+// NewTracerProvider creates a tracer provider exporting sampled spans in batches with the given exporter
+func NewTracerProvider(exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("devlake"),
+			semconv.ServiceVersion(version.Version),
+		)),
+	)
+}
+
+func openTraceFile(path string) (io.WriteCloser, errors.Error) {
+	if path == "" {
+		return nil, errors.BadInput.New("TRACING_FILE_PATH is required by the file trace exporter")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to create the directory for the trace file")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to open the trace file %s", path))
+	}
+	return f, nil
+}
+
+// closingExporter closes the trace file once the exporter is shut down
+type closingExporter struct {
+	sdktrace.SpanExporter
+	closer io.Closer
+}
+
+func (e *closingExporter) Shutdown(ctx gocontext.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.closer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Start starts a span as a child of the span in ctx, if any
+func Start(ctx gocontext.Context, name string, attrs ...attribute.KeyValue) (gocontext.Context, trace.Span) {
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span if there is any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// scopeIdOptions are the task options identifying the scope, the naming differs from plugin to plugin
+var scopeIdOptions = []string{"scopeId", "fullName", "projectId", "projectKey", "boardId", "repoId", "name"}
+
+// PluginAttributes returns the attributes describing the plugin, connection and scope of a task by its options
+func PluginAttributes(pluginName string, options map[string]interface{}) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{PluginKey.String(pluginName)}
+	if connectionId, ok := options["connectionId"]; ok && connectionId != nil {
+		attrs = append(attrs, ConnectionIdKey.String(fmt.Sprint(connectionId)))
+	}
+	for _, key := range scopeIdOptions {
+		if scopeId, ok := options[key]; ok && scopeId != nil && scopeId != "" {
+			attrs = append(attrs, ScopeIdKey.String(fmt.Sprint(scopeId)))
+			break
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,78 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	gocontext "context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "traces.json")
+	cfg := viper.New()
+	cfg.Set("TRACING_EXPORTER", EXPORTER_FILE)
+	cfg.Set("TRACING_FILE_PATH", path)
+	cfg.Set("TRACING_SAMPLE_RATIO", 1.0)
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+
+	shutdown, err := Init(cfg)
+	assert.Nil(t, err)
+	ctx, taskSpan := Start(gocontext.Background(), "task", PluginAttributes("github", map[string]interface{}{
+		"connectionId": float64(1),
+		"name":         "apache/incubator-devlake",
+	})...)
+	_, subtaskSpan := Start(ctx, "subtask", SubtaskKey.String("collectIssues"))
+	End(subtaskSpan, errors.Default.New("boom"))
+	End(taskSpan, nil)
+	assert.Nil(t, shutdown(gocontext.Background()))
+
+	content, e := os.ReadFile(path)
+	assert.Nil(t, e)
+	assert.Contains(t, string(content), `"Name":"subtask"`)
+	assert.Contains(t, string(content), `"Name":"task"`)
+	assert.Contains(t, string(content), `"Key":"devlake.connection_id"`)
+	assert.Contains(t, string(content), `"Value":"apache/incubator-devlake"`)
+	assert.Contains(t, string(content), `"Description":"boom"`)
+}
+
+func TestInitDisabled(t *testing.T) {
+	cfg := viper.New()
+	shutdown, err := Init(cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(gocontext.Background()))
+
+	cfg.Set("TRACING_EXPORTER", "zipkin")
+	_, err = Init(cfg)
+	assert.NotNil(t, err)
+}
+
+func TestPluginAttributes(t *testing.T) {
+	attrs := PluginAttributes("jira", map[string]interface{}{"connectionId": float64(2), "boardId": float64(8)})
+	assert.Len(t, attrs, 3)
+	assert.Equal(t, "2", attrs[1].Value.AsString())
+	assert.Equal(t, ScopeIdKey, attrs[2].Key)
+	assert.Equal(t, "8", attrs[2].Value.AsString())
+}
@@ -58,7 +58,8 @@ import (
 //
 // How it works:
 //
-//   1. Flush specified `table` and import data from a `csv` file by `ImportCsv` method
+//   1. Flush specified `table` and import data from a `csv` file by `ImportCsv` method, or collect it from the
+//      cassettes recorded from the data source by `UseCassettes` method
 //   2. Execute specified `subtask` by `Subtask` method
 //   3. Verify actual data from specified table against expected data from another `csv` file
 //   4. Repeat step 2 and 3
@@ -117,7 +118,7 @@ func NewDataFlowTester(t *testing.T, pluginName string, pluginMeta plugin.Plugin
 		// grant all on lake_test.* to 'merico'@'%';
 		panic(err)
 	}
-	errors.Must(db.AutoMigrate(&models.SubtaskState{}))
+	errors.Must(db.AutoMigrate(&models.SubtaskState{}, &models.CollectorLatestState{}))
 	df := &DataFlowTester{
 		Cfg:    cfg,
 		Db:     db,
@@ -235,6 +236,24 @@ func (t *DataFlowTester) SubtaskContext(taskData interface{}) plugin.SubTaskCont
 	return contextimpl.NewStandaloneSubTaskContext(context.Background(), runner.CreateBasicRes(t.Cfg, t.Log, t.Db), t.Name, taskData, t.Name, syncPolicy)
 }
 
+// TaskContext creates a task context, i.e. to create the api client of the plugin for collectors
+func (t *DataFlowTester) TaskContext(taskData interface{}) plugin.TaskContext {
+	return t.SubtaskContext(taskData).TaskContext()
+}
+
+// UseCassettes makes the api clients created afterward record their interactions with the data source into the
+// cassettes in `dir`, or replay them from there without the network, so collectors can be verified offline.
+// Record the cassettes once with api.API_CASSETTE_MODE_RECORD against the real data source, then replay them in CI
+// with api.API_CASSETTE_MODE_REPLAY.
+func (t *DataFlowTester) UseCassettes(dir string, mode string) {
+	t.Cfg.Set("API_CASSETTE_MODE", mode)
+	t.Cfg.Set("API_CASSETTE_DIR", dir)
+	t.T.Cleanup(func() {
+		t.Cfg.Set("API_CASSETTE_MODE", "")
+		api.CloseCassettes()
+	})
+}
+
 func filterColumn(column dal.ColumnMeta, opts TableOptions) bool {
 	for _, ignore := range opts.IgnoreFields {
 		if column.Name() == ignore {
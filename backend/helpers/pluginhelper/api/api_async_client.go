@@ -28,6 +28,7 @@ import (
 
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/metrics"
 	plugin "github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/utils"
 )
@@ -44,6 +45,8 @@ type ApiAsyncClient struct {
 	maxRetry     int
 	numOfWorkers int
 	logger       log.Logger
+	// rateLimiter is shared by the tasks calling the same connection, nil if adaptive rate limiting is disabled
+	rateLimiter *ConnectionRateLimiter
 }
 
 const defaultTimeout = 120 * time.Second
@@ -103,6 +106,21 @@ func CreateAsyncApiClient(
 		return nil, err
 	}
 
+	adaptive, err := utils.StrToBoolOr(taskCtx.GetConfig("API_ADAPTIVE_RATE_LIMIT"), true)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse API_ADAPTIVE_RATE_LIMIT")
+	}
+	var connectionRateLimiter *ConnectionRateLimiter
+	// replayed responses carry the headers of the recording, they must not slow down the replay
+	if adaptive && !apiClient.replaying {
+		if apiClient.connection != "" {
+			connectionRateLimiter = getConnectionRateLimiter(apiClient.connection, requests, duration, rateLimiter.TokensCount)
+		} else {
+			connectionRateLimiter = &ConnectionRateLimiter{connection: apiClient.GetConnectionLabel()}
+			connectionRateLimiter.setBudget(requests, duration, rateLimiter.TokensCount)
+		}
+	}
+
 	logger := taskCtx.GetLogger().Nested("api async client")
 	logger.Info(
 		"creating scheduler for api \"%s\", number of workers: %d, %d reqs / %s (interval: %s)",
@@ -124,11 +142,12 @@ func CreateAsyncApiClient(
 
 	// finally, wrap around api client with async sematic
 	return &ApiAsyncClient{
-		apiClient,
-		scheduler,
-		retry,
-		numOfWorkers,
-		logger,
+		ApiClient:       apiClient,
+		WorkerScheduler: scheduler,
+		maxRetry:        retry,
+		numOfWorkers:    numOfWorkers,
+		logger:          logger,
+		rateLimiter:     connectionRateLimiter,
 	}, nil
 }
 
@@ -160,6 +179,11 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		var res *http.Response
 		var respBody []byte
 
+		if apiClient.rateLimiter != nil {
+			if err := apiClient.rateLimiter.Wait(apiClient.WorkerScheduler.ctx); err != nil {
+				return err
+			}
+		}
 		apiClient.logger.Debug("endpoint: %s  method: %s  header: %s  body: %s query: %s", path, method, header, body, query)
 		res, err = apiClient.Do(method, path, query, body, header)
 		if err == ErrIgnoreAndContinue {
@@ -176,6 +200,9 @@ func (apiClient *ApiAsyncClient) DoAsync(
 			respBody, err = io.ReadAll(res.Body)
 			if err == nil {
 				res.Body = io.NopCloser(bytes.NewBuffer(respBody))
+				if apiClient.rateLimiter != nil {
+					apiClient.rateLimiter.Observe(res, respBody)
+				}
 			}
 		}
 
@@ -196,6 +223,7 @@ func (apiClient *ApiAsyncClient) DoAsync(
 			// check whether we still have retry times and not error from handler and canceled error
 			if retry < apiClient.maxRetry && err != context.Canceled {
 				apiClient.logger.Warn(err, "retry #%d calling %s", retry, path)
+				metrics.ApiRetries.WithLabelValues(apiClient.GetConnectionLabel()).Inc()
 				retry++
 				apiClient.NextTick(func() errors.Error {
 					apiClient.SubmitBlocking(request)
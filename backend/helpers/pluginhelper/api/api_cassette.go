@@ -0,0 +1,311 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+const (
+	// API_CASSETTE_MODE_RECORD saves the requests and the responses into cassettes
+	API_CASSETTE_MODE_RECORD = "record"
+	// API_CASSETTE_MODE_REPLAY serves the requests with the responses saved in cassettes, without the network
+	API_CASSETTE_MODE_REPLAY = "replay"
+)
+
+// query parameters carrying credentials are redacted from the recorded urls
+var cassetteRedactedQueryParams = map[string]bool{
+	"access_token":  true,
+	"private_token": true,
+	"token":         true,
+	"api_key":       true,
+	"apikey":        true,
+	"client_secret": true,
+}
+
+var cassetteNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// CassetteInteraction is a request along with its response, saved as a line of a cassette
+type CassetteInteraction struct {
+	Method      string      `json:"method"`
+	Url         string      `json:"url"`
+	RequestBody string      `json:"requestBody,omitempty"`
+	StatusCode  int         `json:"statusCode"`
+	Header      http.Header `json:"header,omitempty"`
+	// Body is the response body if it is valid utf8, BinaryBody otherwise
+	Body       string `json:"body,omitempty"`
+	BinaryBody []byte `json:"binaryBody,omitempty"`
+}
+
+func (i *CassetteInteraction) key() string {
+	return i.Method + " " + i.Url + "\n" + i.RequestBody
+}
+
+// Cassette records http interactions into a ndjson file, or replays them from it. Request headers are never
+// recorded since they carry credentials, requests are matched by their method, url and body.
+type Cassette struct {
+	path string
+	mode string
+	mu   sync.Mutex
+	// file is where the interactions are appended in the record mode
+	file *os.File
+	// interactions are the recorded interactions by their requests in the replay mode, identical requests are
+	// served in the recorded order and the last one keeps being served once all of them were
+	interactions map[string][]*CassetteInteraction
+	served       map[string]int
+}
+
+var (
+	cassettes      = make(map[string]*Cassette)
+	cassettesMutex sync.Mutex
+)
+
+// OpenCassette opens the cassette at the path in the mode, clients opening the same path share the cassette.
+// Recording truncates the cassette the first time it gets opened.
+func OpenCassette(path string, mode string) (*Cassette, errors.Error) {
+	cassettesMutex.Lock()
+	defer cassettesMutex.Unlock()
+	if cassette, ok := cassettes[path]; ok {
+		if cassette.mode != mode {
+			return nil, errors.Default.New(fmt.Sprintf("cassette %s was opened to %s", path, cassette.mode))
+		}
+		return cassette, nil
+	}
+	cassette := &Cassette{path: path, mode: mode}
+	switch mode {
+	case API_CASSETTE_MODE_RECORD:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to create the directory of cassette %s", path))
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to create cassette %s", path))
+		}
+		cassette.file = file
+	case API_CASSETTE_MODE_REPLAY:
+		if err := cassette.load(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported cassette mode %s", mode))
+	}
+	cassettes[path] = cassette
+	return cassette, nil
+}
+
+// CloseCassettes closes all opened cassettes, so they get opened afresh
+func CloseCassettes() {
+	cassettesMutex.Lock()
+	defer cassettesMutex.Unlock()
+	for path, cassette := range cassettes {
+		if cassette.file != nil {
+			cassette.file.Close()
+		}
+		delete(cassettes, path)
+	}
+}
+
+// cassetteFromConfig opens the cassette of the endpoint configured by API_CASSETTE_MODE and API_CASSETTE_DIR,
+// returns nil if cassettes are disabled
+func cassetteFromConfig(cfg config.ConfigReader, endpoint string) (*Cassette, errors.Error) {
+	mode := cfg.GetString("API_CASSETTE_MODE")
+	if mode == "" {
+		return nil, nil
+	}
+	dir := cfg.GetString("API_CASSETTE_DIR")
+	if dir == "" {
+		return nil, errors.BadInput.New("API_CASSETTE_DIR is required by API_CASSETTE_MODE")
+	}
+	name := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return OpenCassette(filepath.Join(dir, cassetteNameSanitizer.ReplaceAllString(name, "_")+".ndjson"), mode)
+}
+
+// WithCassette returns a copy of the client recording or replaying the interactions with the endpoint as configured
+// by API_CASSETTE_MODE, it is meant for the clients not created by NewApiClient, i.e. the ones of graphql clients
+func WithCassette(cfg config.ConfigReader, endpoint string, client *http.Client) (*http.Client, errors.Error) {
+	cassette, err := cassetteFromConfig(cfg, endpoint)
+	if err != nil || cassette == nil {
+		return client, err
+	}
+	wrapped := *client
+	wrapped.Transport = cassette.RoundTripper(client.Transport)
+	return &wrapped, nil
+}
+
+// Replaying tells whether the cassette serves the requests without the network
+func (c *Cassette) Replaying() bool {
+	return c.mode == API_CASSETTE_MODE_REPLAY
+}
+
+// RoundTripper wraps the transport to record or replay the interactions
+func (c *Cassette) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cassetteTransport{cassette: c, next: next}
+}
+
+func (c *Cassette) load() errors.Error {
+	file, err := os.Open(c.path)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to open cassette %s", c.path))
+	}
+	defer file.Close()
+	c.interactions = make(map[string][]*CassetteInteraction)
+	c.served = make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		interaction := &CassetteInteraction{}
+		if err := json.Unmarshal(scanner.Bytes(), interaction); err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("invalid interaction in cassette %s", c.path))
+		}
+		key := interaction.key()
+		c.interactions[key] = append(c.interactions[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to read cassette %s", c.path))
+	}
+	return nil
+}
+
+func (c *Cassette) record(interaction *CassetteInteraction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+func (c *Cassette) replay(key string) *CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interactions := c.interactions[key]
+	if len(interactions) == 0 {
+		return nil
+	}
+	i := c.served[key]
+	if i < len(interactions)-1 {
+		c.served[key] = i + 1
+	}
+	return interactions[i]
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := &CassetteInteraction{
+		Method: req.Method,
+		Url:    redactCassetteUrl(req.URL),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.RequestBody = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.cassette.Replaying() {
+		recorded := t.cassette.replay(interaction.key())
+		if recorded == nil {
+			return nil, fmt.Errorf("no interaction for %s %s was recorded in cassette %s", req.Method, interaction.Url, t.cassette.path)
+		}
+		body := recorded.BinaryBody
+		if body == nil {
+			body = []byte(recorded.Body)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	interaction.StatusCode = res.StatusCode
+	interaction.Header = res.Header.Clone()
+	interaction.Header.Del("Set-Cookie")
+	if utf8.Valid(body) {
+		interaction.Body = string(body)
+	} else {
+		interaction.BinaryBody = body
+	}
+	if err := t.cassette.record(interaction); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// redactCassetteUrl returns the url with the credentials in the query redacted and the query sorted
+func redactCassetteUrl(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for name := range query {
+		if cassetteRedactedQueryParams[strings.ToLower(name)] {
+			query.Set(name, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
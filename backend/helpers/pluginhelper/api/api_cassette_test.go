@@ -0,0 +1,160 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	gocontext "context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	defer CloseCassettes()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"path":%q,"query":%q,"body":%q,"hit":%d}`, r.URL.Path, r.URL.Query().Get("page"), string(body), n)
+	}))
+	path := filepath.Join(t.TempDir(), "cassettes", "example.ndjson")
+
+	recorder, err := OpenCassette(path, API_CASSETTE_MODE_RECORD)
+	assert.Nil(t, err)
+	client := &http.Client{Transport: recorder.RoundTripper(nil)}
+	recorded := []string{
+		cassetteGet(t, client, server.URL+"/issues?page=1&access_token=secret"),
+		cassetteGet(t, client, server.URL+"/issues?page=1&access_token=secret"),
+		cassettePost(t, client, server.URL+"/graphql", `{"query":"a"}`),
+		cassettePost(t, client, server.URL+"/graphql", `{"query":"b"}`),
+	}
+	server.Close()
+	CloseCassettes()
+
+	player, err := OpenCassette(path, API_CASSETTE_MODE_REPLAY)
+	assert.Nil(t, err)
+	client = &http.Client{Transport: player.RoundTripper(nil)}
+	// the query is matched regardless of its order and the credentials
+	res, e := client.Get(server.URL + "/issues?access_token=other&page=1")
+	assert.Nil(t, e)
+	assert.Equal(t, "4999", res.Header.Get("X-RateLimit-Remaining"))
+	assert.Empty(t, res.Header.Get("Set-Cookie"))
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, recorded[0], string(body))
+	// identical requests are served in the recorded order, then the last one is repeated
+	assert.Equal(t, recorded[1], cassetteGet(t, client, server.URL+"/issues?page=1&access_token=other"))
+	assert.Equal(t, recorded[1], cassetteGet(t, client, server.URL+"/issues?page=1&access_token=other"))
+	assert.Equal(t, recorded[3], cassettePost(t, client, server.URL+"/graphql", `{"query":"b"}`))
+	assert.Equal(t, recorded[2], cassettePost(t, client, server.URL+"/graphql", `{"query":"a"}`))
+	_, e = client.Get(server.URL + "/issues?page=2")
+	assert.NotNil(t, e)
+	assert.Equal(t, int32(4), hits)
+}
+
+func TestCassetteRedactsCredentials(t *testing.T) {
+	defer CloseCassettes()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "example.ndjson")
+	recorder, err := OpenCassette(path, API_CASSETTE_MODE_RECORD)
+	assert.Nil(t, err)
+	client := &http.Client{Transport: recorder.RoundTripper(nil)}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/user?private_token=secret", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, e := client.Do(req)
+	assert.Nil(t, e)
+	res.Body.Close()
+	CloseCassettes()
+
+	cassette, e := os.ReadFile(path)
+	assert.Nil(t, e)
+	assert.NotContains(t, string(cassette), "secret")
+	assert.Contains(t, string(cassette), "private_token=REDACTED")
+}
+
+func TestOpenCassetteMode(t *testing.T) {
+	defer CloseCassettes()
+	path := filepath.Join(t.TempDir(), "example.ndjson")
+	_, err := OpenCassette(path, "rewind")
+	assert.NotNil(t, err)
+	_, err = OpenCassette(path, API_CASSETTE_MODE_REPLAY)
+	assert.NotNil(t, err)
+	_, err = OpenCassette(path, API_CASSETTE_MODE_RECORD)
+	assert.Nil(t, err)
+	_, err = OpenCassette(path, API_CASSETTE_MODE_REPLAY)
+	assert.NotNil(t, err)
+}
+
+func cassetteGet(t *testing.T, client *http.Client, url string) string {
+	res, err := client.Get(url)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	return string(body)
+}
+
+func cassettePost(t *testing.T, client *http.Client, url string, body string) string {
+	res, err := client.Post(url, "application/json", strings.NewReader(body))
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	return string(resBody)
+}
+
+type wrappingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *wrappingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req)
+}
+
+func TestApiClientReplayingWithWrappedTransport(t *testing.T) {
+	defer CloseCassettes()
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "example.com.ndjson"), nil, 0644))
+	cfg := viper.New()
+	cfg.Set("API_CASSETTE_MODE", API_CASSETTE_MODE_REPLAY)
+	cfg.Set("API_CASSETTE_DIR", dir)
+	res := unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {})
+	res.On("GetConfigReader").Return(cfg)
+
+	apiClient, err := NewApiClient(gocontext.Background(), "https://example.com/", nil, 0, "", res)
+	assert.Nil(t, err)
+	assert.True(t, apiClient.replaying)
+	// plugins might wrap the transport, i.e. to refresh tokens, the client keeps replaying
+	apiClient.GetClient().Transport = &wrappingTransport{next: apiClient.GetClient().Transport}
+	assert.True(t, apiClient.replaying)
+}
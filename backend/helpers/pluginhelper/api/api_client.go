@@ -30,16 +30,21 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/apache/incubator-devlake/core/plugin"
 
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/metrics"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/core/utils"
 )
 
@@ -67,6 +72,13 @@ type ApiClient struct {
 	afterResponse plugin.ApiClientAfterResponse
 	ctx           gocontext.Context
 	logger        log.Logger
+	// connection identifies the connection in metrics, the endpoint host is used if it is empty
+	connection string
+	// validators of the responses to conditional requests, nil if conditional requests are disabled
+	validators *apiResponseValidators
+	// replaying tells whether the responses are replayed from a cassette, it is kept aside from the transport
+	// since plugins might wrap the transport, i.e. to refresh tokens
+	replaying bool
 }
 
 // NewApiClientFromConnection creates ApiClient based on given connection.
@@ -83,6 +95,17 @@ func NewApiClientFromConnection(
 		return nil, err
 	}
 
+	if toolConnection, ok := connection.(plugin.ToolLayerConnection); ok {
+		apiClient.connection = connectionLabel(toolConnection)
+		// validators are kept by connection since responses differ by credentials
+		if br.GetConfigReader().GetBool("API_CONDITIONAL_REQUESTS") {
+			apiClient.validators = newApiResponseValidators(br.GetDal(), apiClient.connection)
+			if err = apiClient.validators.expire(); err != nil {
+				return nil, errors.Default.Wrap(err, "failed to expire api response validators")
+			}
+		}
+	}
+
 	// if connection needs to prepare the ApiClient, i.e. fetch token for future requests
 	if prepareApiClient, ok := connection.(plugin.PrepareApiClient); ok {
 		err = prepareApiClient.PrepareApiClient(apiClient)
@@ -101,6 +124,12 @@ func NewApiClientFromConnection(
 	return apiClient, nil
 }
 
+// connectionLabel returns the plugin name along with the connection id, i.e. `github#1` for `_tool_github_connections`
+func connectionLabel(connection plugin.ToolLayerConnection) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(connection.TableName(), "_tool_"), "_connections")
+	return fmt.Sprintf("%s#%d", name, connection.ConnectionId())
+}
+
 // NewApiClient creates a new synchronize ApiClient
 func NewApiClient(
 	ctx gocontext.Context,
@@ -137,20 +166,31 @@ func NewApiClient(
 		apiClient.client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
+	// record or replay the interactions for offline tests
+	cassette, err := cassetteFromConfig(cfg, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// the endpoint is never reached while replaying
+	replaying := cassette != nil && cassette.Replaying()
+
 	if proxy != "" {
 		err := apiClient.SetProxy(proxy)
 		if err != nil {
 			return nil, errors.Convert(err)
 		}
 		// check connectivity
-		res, err := apiClient.Get("/", nil, nil)
-		if err != nil {
-			return nil, err
-		}
-		if res.StatusCode == http.StatusBadGateway {
-			return nil, errors.BadInput.New(fmt.Sprintf("fail to connect to %v via %v", endpoint, proxy))
+		if !replaying {
+			res, err := apiClient.Get("/", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if res.StatusCode == http.StatusBadGateway {
+				return nil, errors.BadInput.New(fmt.Sprintf("fail to connect to %v via %v", endpoint, proxy))
+			}
 		}
-	} else {
+	} else if !replaying {
 		// check connectivity
 		parsedUrl, err := url.Parse(endpoint)
 		if err != nil {
@@ -172,6 +212,10 @@ func NewApiClient(
 			return nil, errors.Default.Wrap(err, "Failed to connect")
 		}
 	}
+	if cassette != nil {
+		apiClient.client.Transport = cassette.RoundTripper(apiClient.client.Transport)
+		apiClient.replaying = replaying
+	}
 	apiClient.SetContext(ctx)
 
 	// apply global security settings
@@ -289,7 +333,11 @@ func (apiClient *ApiClient) SetProxy(proxyUrl string) errors.Error {
 		return errors.Convert(err)
 	}
 	if pu.Scheme == "http" || pu.Scheme == "socks5" {
-		apiClient.client.Transport.(*http.Transport).Proxy = http.ProxyURL(pu)
+		transport := apiClient.client.Transport
+		if cassette, ok := transport.(*cassetteTransport); ok {
+			transport = cassette.next
+		}
+		transport.(*http.Transport).Proxy = http.ProxyURL(pu)
 	}
 	return nil
 }
@@ -337,12 +385,24 @@ func (apiClient *ApiClient) Do(
 		}
 		reqBody = bytes.NewBuffer(reqJson)
 	}
+	// the query is left out of the span since it might contain credentials
+	spanUrl, _, _ := strings.Cut(*uri, "?")
+	ctx, span := tracing.Start(
+		apiClient.ctx,
+		fmt.Sprintf("HTTP %s", method),
+		attribute.String("http.method", method),
+		attribute.String("http.url", spanUrl),
+		attribute.String("devlake.connection", apiClient.GetConnectionLabel()),
+	)
+	defer func() {
+		if err == ErrIgnoreAndContinue {
+			tracing.End(span, nil)
+			return
+		}
+		tracing.End(span, err)
+	}()
 	var req *http.Request
-	if apiClient.ctx != nil {
-		req, err = errors.Convert01(http.NewRequestWithContext(apiClient.ctx, method, *uri, reqBody))
-	} else {
-		req, err = errors.Convert01(http.NewRequest(method, *uri, reqBody))
-	}
+	req, err = errors.Convert01(http.NewRequestWithContext(ctx, method, *uri, reqBody))
 	if err != nil {
 		return nil, errors.Default.Wrap(err, fmt.Sprintf("unable to create API request for %s", *uri))
 	}
@@ -354,11 +414,20 @@ func (apiClient *ApiClient) Do(
 			req.Header.Set(name, value)
 		}
 	}
+	conditional := headers.Get(HeaderConditionalRequest) != "" && method == http.MethodGet && apiClient.validators != nil
 	for name, values := range headers {
+		if http.CanonicalHeaderKey(name) == HeaderConditionalRequest {
+			continue
+		}
 		for _, value := range values {
 			req.Header.Add(name, value)
 		}
 	}
+	if conditional {
+		if req, err = apiClient.validators.apply(req, *uri); err != nil {
+			return nil, err
+		}
+	}
 
 	var res *http.Response
 	// authFunc
@@ -378,11 +447,22 @@ func (apiClient *ApiClient) Do(
 		}
 	}
 	apiClient.logDebug("[api-client] %v %v", method, *uri)
+	start := time.Now()
 	res, err = errors.Convert01(apiClient.client.Do(req))
+	apiClient.observeRequest(res, time.Since(start))
+	if res != nil {
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	}
 	if err != nil {
 		apiClient.logError(err, "[api-client] failed to request %s with error", req.URL.String())
 		return nil, err
 	}
+	if conditional {
+		if err = apiClient.validators.save(res); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
 	// after receive
 	if apiClient.afterResponse != nil {
 		err = apiClient.afterResponse(res)
@@ -399,6 +479,30 @@ func (apiClient *ApiClient) Do(
 	return res, nil
 }
 
+// GetConnectionLabel returns the label identifying the connection of the ApiClient in metrics
+func (apiClient *ApiClient) GetConnectionLabel() string {
+	if apiClient.connection != "" {
+		return apiClient.connection
+	}
+	if u, err := url.Parse(apiClient.endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return apiClient.endpoint
+}
+
+func (apiClient *ApiClient) observeRequest(res *http.Response, duration time.Duration) {
+	connection := apiClient.GetConnectionLabel()
+	code := "error"
+	if res != nil {
+		code = strconv.Itoa(res.StatusCode)
+		if res.StatusCode == http.StatusTooManyRequests {
+			metrics.ApiRateLimited.WithLabelValues(connection).Inc()
+		}
+	}
+	metrics.ApiRequests.WithLabelValues(connection, code).Inc()
+	metrics.ApiRequestDuration.WithLabelValues(connection).Observe(duration.Seconds())
+}
+
 // Get FIXME ...
 func (apiClient *ApiClient) Get(
 	path string,
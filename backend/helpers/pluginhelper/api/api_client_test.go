@@ -18,11 +18,20 @@ limitations under the License.
 package api
 
 import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/metrics"
+	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/impls/logruslog"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestApiClientBlackList(t *testing.T) {
@@ -95,3 +104,89 @@ func TestApiClientBlackList(t *testing.T) {
 		})
 	}
 }
+
+type testToolConnection struct{}
+
+func (testToolConnection) TableName() string    { return "_tool_github_connections" }
+func (testToolConnection) ConnectionId() uint64 { return 3 }
+
+func TestApiClientMetrics(t *testing.T) {
+	apiClient := &ApiClient{}
+	apiClient.Setup("https://api.example.com/v3/", nil, 0)
+	assert.Equal(t, "api.example.com", apiClient.GetConnectionLabel())
+	apiClient.connection = connectionLabel(testToolConnection{})
+	assert.Equal(t, "github#3", apiClient.GetConnectionLabel())
+
+	apiClient.observeRequest(&http.Response{StatusCode: http.StatusOK}, time.Second)
+	apiClient.observeRequest(&http.Response{StatusCode: http.StatusTooManyRequests}, time.Second)
+	apiClient.observeRequest(nil, time.Second)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ApiRequests.WithLabelValues("github#3", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ApiRequests.WithLabelValues("github#3", "429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ApiRequests.WithLabelValues("github#3", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ApiRateLimited.WithLabelValues("github#3")))
+}
+
+func TestApiClientConditionalRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(HeaderConditionalRequest))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	var saved *models.ApiResponseValidator
+	notFound := errors.NotFound.New("not found")
+	mockDal := new(mockdal.Dal)
+	mockDal.On("First", mock.Anything, mock.Anything).Return(notFound).Once()
+	mockDal.On("IsErrorNotFound", notFound).Return(true).Once()
+	mockDal.On("CreateOrUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.ApiResponseValidator)
+	}).Return(nil).Once()
+	mockDal.On("First", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*models.ApiResponseValidator) = *saved
+	}).Return(nil).Once()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 0)
+	apiClient.SetContext(gocontext.Background())
+	apiClient.connection = "github#1"
+	apiClient.validators = newApiResponseValidators(mockDal, apiClient.connection)
+	conditional := http.Header{HeaderConditionalRequest: []string{"true"}}
+
+	res, err := apiClient.Get("issues", nil, conditional)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "github#1", saved.Connection)
+	assert.Equal(t, `"v1"`, saved.ETag)
+	assert.NotEmpty(t, saved.UrlHash)
+
+	res, err = apiClient.Get("issues", nil, conditional)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	mockDal.AssertExpectations(t)
+
+	// requests without the marker are sent as they are
+	res, err = apiClient.Get("issues", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestApiResponseValidatorKey(t *testing.T) {
+	// time valued query parameters of incremental collections are left out
+	assert.Equal(t,
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=1&since=2024-01-01T00:00:00Z"),
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=1&since=2024-03-01T00:00:00Z"),
+	)
+	assert.Equal(t,
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=1"),
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=1&updated_after=2024-03-01"),
+	)
+	assert.NotEqual(t,
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=1"),
+		apiResponseValidatorKey("https://api.example.com/repos/a/b/issues?page=2"),
+	)
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
 )
 
 var _ plugin.SubTask = (*ApiCollector)(nil)
@@ -98,6 +99,11 @@ type ApiCollector struct {
 	*RawDataSubTask
 	args        *ApiCollectorArgs
 	urlTemplate *template.Template
+	// checkpointName distinguishes collectors sharing the same raw table, i.e. nested collectors of StatefulApiCollector
+	checkpointName string
+	checkpoints    *collectorCheckpoints
+	// conditional tells whether GET requests are sent conditionally, so unchanged responses are answered with 304
+	conditional bool
 }
 
 // NewApiCollector allocates a new ApiCollector with the given args.
@@ -143,6 +149,26 @@ func NewApiCollector(args ApiCollectorArgs) (*ApiCollector, errors.Error) {
 
 var rawTableAutoMigrateLock sync.Mutex
 
+// countUnmodifiedRows returns the number of raw rows the last response to the request of the 304 response was saved
+// as, or 0 if they are not all there anymore
+func (collector *ApiCollector) countUnmodifiedRows(res *http.Response) (int, errors.Error) {
+	validator := apiResponseValidatorOf(res)
+	if validator == nil || validator.RawCreatedAt == nil || validator.RawRows == 0 {
+		return 0, nil
+	}
+	count, err := collector.args.Ctx.GetDal().Count(
+		dal.From(collector.table),
+		dal.Where("params = ? AND created_at = ?", collector.params, *validator.RawCreatedAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if int(count) != validator.RawRows {
+		return 0, nil
+	}
+	return validator.RawRows, nil
+}
+
 func (collector *ApiCollector) ensureRawTable() errors.Error {
 	db := collector.args.Ctx.GetDal()
 	rawTableAutoMigrateLock.Lock()
@@ -167,8 +193,18 @@ func (collector *ApiCollector) Execute() errors.Error {
 	if syncPolicy != nil && syncPolicy.FullSync {
 		isIncremental = false
 	}
-	// flush data if not incremental collection
-	if !isIncremental {
+	collector.checkpoints, err = newCollectorCheckpoints(collector.args.Ctx, collector.table, collector.params, collector.checkpointName)
+	if err != nil {
+		return err
+	}
+	// raw rows of unchanged responses are reused, which are kept by incremental collections only
+	collector.conditional = isIncremental && collector.args.Method != http.MethodPost
+	resuming := collector.checkpoints.IsResuming()
+	if resuming {
+		logger.Info("resume api collection from the last committed pages")
+	}
+	// flush data if not incremental collection, data committed before the interruption must be kept when resuming
+	if !isIncremental && !resuming {
 		err = db.Delete(&RawData{}, dal.From(collector.table), dal.Where("params = ?", collector.params))
 		if err != nil {
 			return errors.Default.Wrap(err, "error deleting data from collector")
@@ -233,6 +269,7 @@ func (collector *ApiCollector) Execute() errors.Error {
 		err = errors.Default.Wrap(err, "Error waiting for async Collector execution")
 	} else {
 		logger.Info("end api collection without error")
+		err = collector.checkpoints.Close()
 	}
 
 	return err
@@ -250,8 +287,15 @@ func (collector *ApiCollector) exec(input interface{}) {
 		Page: 1,
 		Size: collector.args.PageSize,
 	}
+	if collector.checkpoints.IsDone(inputJson) {
+		// all pages of the input were committed before the interruption
+		return
+	}
 	// fetch the detail
 	if collector.args.PageSize <= 0 {
+		if committed, _ := collector.checkpoints.IsPageCommitted(inputJson, 1); committed {
+			return
+		}
 		collector.fetchAsync(reqData, nil)
 		// fetch pages sequentially
 	} else if collector.args.GetNextPageCustomData != nil {
@@ -267,16 +311,22 @@ func (collector *ApiCollector) exec(input interface{}) {
 
 // fetchPagesSequentially fetches data of all pages in order to build RequestData by prev response
 func (collector *ApiCollector) fetchPagesSequentially(reqData *RequestData) {
+	// continue from the page after the last committed one, only string cursors could be restored
+	if cp := collector.checkpoints.Get(reqData.InputJSON); cp != nil && cp.NextPage > 1 && cp.NextCursor != nil {
+		reqData.Pager.Page = cp.NextPage
+		reqData.Pager.Skip = cp.NextSkip
+		reqData.CustomData = *cp.NextCursor
+	}
 	var collect func() errors.Error
 	collect = func() errors.Error {
 		collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
 			if count < collector.args.PageSize {
-				return nil
+				return collector.commitSequentialPage(reqData, nil)
 			}
 			customData, err := collector.args.GetNextPageCustomData(reqData, res)
 			if err != nil {
 				if errors.Is(err, ErrFinishCollect) {
-					return nil
+					return collector.commitSequentialPage(reqData, nil)
 				} else {
 					panic(err)
 				}
@@ -284,6 +334,9 @@ func (collector *ApiCollector) fetchPagesSequentially(reqData *RequestData) {
 			reqData.CustomData = customData
 			reqData.Pager.Skip += collector.args.PageSize
 			reqData.Pager.Page += 1
+			if err := collector.commitSequentialPage(reqData, customData); err != nil {
+				return err
+			}
 			collector.args.ApiClient.NextTick(collect)
 			return nil
 		})
@@ -292,23 +345,31 @@ func (collector *ApiCollector) fetchPagesSequentially(reqData *RequestData) {
 	collector.args.ApiClient.NextTick(collect)
 }
 
+// commitSequentialPage records where the sequential collection should continue, or that it is done when
+// nextCustomData is nil
+func (collector *ApiCollector) commitSequentialPage(reqData *RequestData, nextCustomData interface{}) errors.Error {
+	return collector.checkpoints.Commit(reqData.InputJSON, func(cp *models.CollectorCheckpoint) {
+		if nextCustomData == nil {
+			cp.Done = true
+			return
+		}
+		if cursor, ok := nextCustomData.(string); ok {
+			cp.NextPage = reqData.Pager.Page
+			cp.NextSkip = reqData.Pager.Skip
+			cp.NextCursor = &cursor
+		}
+	}, nil)
+}
+
 // fetchPagesDetermined fetches data of all pages for APIs that return paging information
 func (collector *ApiCollector) fetchPagesDetermined(reqData *RequestData) {
-	// fetch first page
-	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
-		totalPages, err := collector.args.GetTotalPages(res, collector.args)
-		if err != nil {
-			// Some APIs might or might not return total pages/records based on total number of records
-			// check https://github.com/apache/incubator-devlake/issues/8187 for details
-			if err == ErrUndetermined {
-				collector.fetchPagesUndetermined(reqData, true)
-				return nil
-			}
-			return errors.Default.Wrap(err, "fetchPagesDetermined get totalPages failed")
-		}
+	fetchOtherPages := func(totalPages int) {
 		// spawn a none blocking go routine to fetch other pages
 		collector.args.ApiClient.NextTick(func() errors.Error {
 			for page := 2; page <= totalPages; page++ {
+				if committed, _ := collector.checkpoints.IsPageCommitted(reqData.InputJSON, page); committed {
+					continue
+				}
 				reqDataTemp := &RequestData{
 					Pager: &Pager{
 						Page: page,
@@ -322,6 +383,33 @@ func (collector *ApiCollector) fetchPagesDetermined(reqData *RequestData) {
 			}
 			return nil
 		})
+	}
+	// the first page was committed already, fetch other pages directly
+	if cp := collector.checkpoints.Get(reqData.InputJSON); cp != nil && cp.TotalPages > 0 {
+		if committed, _ := collector.checkpoints.IsPageCommitted(reqData.InputJSON, 1); committed {
+			fetchOtherPages(cp.TotalPages)
+			return
+		}
+	}
+	// fetch first page
+	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
+		totalPages, err := collector.args.GetTotalPages(res, collector.args)
+		if err != nil {
+			// Some APIs might or might not return total pages/records based on total number of records
+			// check https://github.com/apache/incubator-devlake/issues/8187 for details
+			if err == ErrUndetermined {
+				collector.fetchPagesUndetermined(reqData, true)
+				return nil
+			}
+			return errors.Default.Wrap(err, "fetchPagesDetermined get totalPages failed")
+		}
+		err = collector.checkpoints.Commit(reqData.InputJSON, func(cp *models.CollectorCheckpoint) {
+			cp.TotalPages = totalPages
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fetchOtherPages(totalPages)
 		return nil
 	})
 }
@@ -364,6 +452,18 @@ func (collector *ApiCollector) fetchPagesUndetermined(reqData *RequestData, skip
 		}
 		var collect func() errors.Error
 		collect = func() errors.Error {
+			// skip pages committed before the interruption
+			for {
+				committed, hasMore := collector.checkpoints.IsPageCommitted(reqDataCopy.InputJSON, reqDataCopy.Pager.Page)
+				if !committed {
+					break
+				}
+				if !hasMore {
+					return nil
+				}
+				reqDataCopy.Pager.Skip += collector.args.PageSize * concurrency
+				reqDataCopy.Pager.Page += concurrency
+			}
 			collector.fetchAsync(&reqDataCopy, func(count int, body []byte, res *http.Response) errors.Error {
 				if count < collector.args.PageSize {
 					return nil
@@ -409,6 +509,12 @@ func (collector *ApiCollector) SetAfterResponse(f plugin.ApiClientAfterResponse)
 }
 
 func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int, []byte, *http.Response) errors.Error) {
+	// the handler of pages fetched one by one reads the next page from the response, which 304 responses lack
+	conditional := collector.conditional && (handler == nil || collector.args.GetNextPageCustomData == nil && collector.args.GetTotalPages == nil)
+	collector.fetchAsyncConditionally(reqData, handler, conditional)
+}
+
+func (collector *ApiCollector) fetchAsyncConditionally(reqData *RequestData, handler func(int, []byte, *http.Response) errors.Error, conditional bool) {
 	if reqData.Pager == nil {
 		reqData.Pager = &Pager{
 			Page: 1,
@@ -442,6 +548,13 @@ func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int
 			panic(err)
 		}
 	}
+	if conditional {
+		apiHeader = apiHeader.Clone()
+		if apiHeader == nil {
+			apiHeader = http.Header{}
+		}
+		apiHeader.Set(HeaderConditionalRequest, "true")
+	}
 	logger := collector.args.Ctx.GetLogger()
 	logger.Debug("fetchAsync <<< enqueueing for %s %v", apiUrl, apiQuery)
 	responseHandler := func(res *http.Response) errors.Error {
@@ -454,37 +567,78 @@ func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int
 		}
 		res.Body.Close()
 		res.Body = io.NopCloser(bytes.NewBuffer(body))
-		// convert body to array of RawJSON
-		items, err := collector.args.ResponseParser(res)
-		if err != nil {
-			if errors.Is(err, ErrFinishCollect) {
-				logger.Info("a fetch stop by parser, reqInput: #%s", reqData.Params)
-				handler = nil
-			} else {
-				return errors.Default.Wrap(err, fmt.Sprintf("error parsing response from %s", apiUrl))
+		var items []json.RawMessage
+		count := 0
+		if res.StatusCode == http.StatusNotModified {
+			// the response is unchanged, its raw rows collected before are reused
+			var countErr errors.Error
+			count, countErr = collector.countUnmodifiedRows(res)
+			if countErr != nil {
+				return countErr
+			}
+			if count == 0 {
+				// the raw rows were removed, i.e. by the retention, the response has to be collected again
+				collector.args.ApiClient.NextTick(func() errors.Error {
+					collector.fetchAsyncConditionally(reqData, handler, false)
+					return nil
+				})
+				return nil
+			}
+			logger.Debug("fetchAsync === %s was not modified, %d rows were reused", apiUrl, count)
+		} else {
+			// convert body to array of RawJSON
+			var parseErr errors.Error
+			items, parseErr = collector.args.ResponseParser(res)
+			if parseErr != nil {
+				if errors.Is(parseErr, ErrFinishCollect) {
+					logger.Info("a fetch stop by parser, reqInput: #%s", reqData.Params)
+					handler = nil
+				} else {
+					return errors.Default.Wrap(parseErr, fmt.Sprintf("error parsing response from %s", apiUrl))
+				}
+			}
+			count = len(items)
+		}
+		// save to db along with the checkpoint, rows of a page committed before the interruption are kept already
+		page := reqData.Pager.Page
+		if committed, _ := collector.checkpoints.IsPageCommitted(reqData.InputJSON, page); !committed {
+			urlString := res.Request.URL.String()
+			compression := rawDataCompression()
+			// rows of a response share the creation time, so the retention could tell them from later responses
+			createdAt := time.Now()
+			rows := make([]*RawData, len(items))
+			for i, msg := range items {
+				data, err := EncodeRawData(msg, compression)
+				if err != nil {
+					return err
+				}
+				rows[i] = &RawData{
+					Params:    collector.params,
+					Data:      data,
+					Url:       urlString,
+					Input:     reqData.InputJSON,
+					CreatedAt: createdAt,
+				}
+			}
+			hasMore := handler != nil && count >= collector.args.PageSize
+			commitErr := collector.checkpoints.CommitPage(reqData.InputJSON, page, hasMore, func(tx dal.Dal) errors.Error {
+				if len(rows) == 0 {
+					return nil
+				}
+				err := tx.Create(rows, dal.From(collector.table))
+				if err != nil {
+					return errors.Default.Wrap(err, fmt.Sprintf("error inserting raw rows into %s", collector.table))
+				}
+				return recordApiResponseRawRows(tx, res, createdAt, len(rows))
+			})
+			if commitErr != nil {
+				return commitErr
 			}
 		}
-		// save to db
-		count := len(items)
 		if count == 0 {
 			collector.args.Ctx.IncProgress(1)
 			return nil
 		}
-		db := collector.args.Ctx.GetDal()
-		urlString := res.Request.URL.String()
-		rows := make([]*RawData, count)
-		for i, msg := range items {
-			rows[i] = &RawData{
-				Params: collector.params,
-				Data:   msg,
-				Url:    urlString,
-				Input:  reqData.InputJSON,
-			}
-		}
-		err = db.Create(rows, dal.From(collector.table))
-		if err != nil {
-			return errors.Default.Wrap(err, fmt.Sprintf("error inserting raw rows into %s", collector.table))
-		}
 		logger.Debug("fetchAsync === total %d rows were saved into database", count)
 		// increase progress only when it was not nested
 		collector.args.Ctx.IncProgress(1)
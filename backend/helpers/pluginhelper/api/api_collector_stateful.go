@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/apache/incubator-devlake/core/errors"
@@ -36,6 +37,7 @@ type StatefulApiCollector struct {
 	// *ApiCollector
 	// *GraphqlCollector
 	nestedCollectors []plugin.SubTask
+	rawDataSubTask   *RawDataSubTask
 }
 
 // NewStatefulApiCollector create a new StatefulApiCollector
@@ -52,6 +54,7 @@ func NewStatefulApiCollector(args RawDataSubTaskArgs) (*StatefulApiCollector, er
 	return &StatefulApiCollector{
 		RawDataSubTaskArgs:    args,
 		CollectorStateManager: *stateManager,
+		rawDataSubTask:        rawDataSubTask,
 	}, nil
 }
 
@@ -63,6 +66,7 @@ func (m *StatefulApiCollector) InitCollector(args ApiCollectorArgs) errors.Error
 	if err != nil {
 		return err
 	}
+	apiCollector.checkpointName = strconv.Itoa(len(m.nestedCollectors))
 	m.nestedCollectors = append(m.nestedCollectors, apiCollector)
 	return nil
 }
@@ -87,6 +91,7 @@ func (m *StatefulApiCollector) InitGraphQLCollector(args GraphqlCollectorArgs) e
 	if err != nil {
 		return err
 	}
+	graphqlCollector.checkpointName = strconv.Itoa(len(m.nestedCollectors))
 	m.nestedCollectors = append(m.nestedCollectors, graphqlCollector)
 	return nil
 }
@@ -99,6 +104,10 @@ func (m *StatefulApiCollector) Execute() errors.Error {
 			return err
 		}
 	}
+	err := deleteCollectorCheckpoints(m.Ctx.GetDal(), m.rawDataSubTask.table, m.rawDataSubTask.params)
+	if err != nil {
+		return err
+	}
 
 	return m.CollectorStateManager.Close()
 }
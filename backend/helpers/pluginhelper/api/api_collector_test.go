@@ -19,15 +19,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/helpers/unithelper"
 	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	mockplugin "github.com/apache/incubator-devlake/mocks/core/plugin"
 	mockapi "github.com/apache/incubator-devlake/mocks/helpers/pluginhelper/api"
 
 	"github.com/stretchr/testify/assert"
@@ -107,3 +111,181 @@ func TestFetchPageUndetermined(t *testing.T) {
 
 	mockDal.AssertExpectations(t)
 }
+
+func TestFetchPageUndeterminedResume(t *testing.T) {
+	// page 1 was committed before the task got interrupted
+	mockDal := new(mockdal.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("All", mock.AnythingOfType("*[]*models.CollectorCheckpoint"), mock.Anything).Return(nil).Once()
+	mockDal.On("All", mock.AnythingOfType("*[]*models.CollectorCheckpointPage"), mock.Anything).Run(func(args mock.Arguments) {
+		rows := args.Get(0).(*[]*models.CollectorCheckpointPage)
+		*rows = []*models.CollectorCheckpointPage{{
+			TaskId:    1,
+			InputHash: hashCheckpointInput([]byte("null")),
+			Page:      1,
+			HasMore:   true,
+		}}
+	}).Return(nil).Once()
+	mockTx := new(mockdal.Transaction)
+	mockTx.On("Create", mock.AnythingOfType("*models.CollectorCheckpointPage"), mock.Anything).Run(func(args mock.Arguments) {
+		assert.Equal(t, 2, args.Get(0).(*models.CollectorCheckpointPage).Page)
+	}).Return(nil).Once()
+	mockTx.On("Commit").Return(nil).Once()
+	mockDal.On("Begin").Return(mockTx).Once()
+	// raw data must not be flushed, only checkpoints would be deleted: pages of other tasks when started and
+	// everything once finished
+	mockDal.On("Delete", mock.AnythingOfType("*models.CollectorCheckpointPage"), mock.Anything).Return(nil).Twice()
+	mockDal.On("Delete", mock.AnythingOfType("*models.CollectorCheckpoint"), mock.Anything).Return(nil).Once()
+
+	mockCtx := new(mockplugin.SubTaskContext)
+	mockCtx.On("GetDal").Return(mockDal)
+	mockCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	mockCtx.On("SetProgress", mock.Anything, mock.Anything)
+	mockCtx.On("IncProgress", mock.Anything, mock.Anything)
+	mockCtx.On("GetName").Return("test")
+	mockCtx.On("GetContext").Return(plugin.ContextWithTaskId(context.Background(), 1))
+	mockTaskContext := new(mockplugin.TaskContext)
+	mockTaskContext.On("SyncPolicy").Return(nil)
+	mockCtx.On("TaskContext").Return(mockTaskContext)
+
+	// only page 2 is expected to be requested
+	requestedPages := []string{}
+	mockApi := new(mockapi.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		requestedPages = append(requestedPages, args.Get(0).(string))
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{},
+			},
+			Body: io.NopCloser(bytes.NewBufferString("[]")),
+		}
+		handler := args.Get(3).(plugin.ApiAsyncCallback)
+		handler(res)
+	}).Once()
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:     mockCtx,
+			Table:   "whatever rawtable",
+			Options: &TestOpts{},
+		},
+		ApiClient:      mockApi,
+		UrlTemplate:    "page/{{ .Pager.Page }}",
+		Concurrency:    1,
+		PageSize:       3,
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, []string{"page/2"}, requestedPages)
+
+	mockDal.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+func TestFetchNotModified(t *testing.T) {
+	// the raw rows of the unchanged response are reused, nothing is saved
+	mockDal := new(mockdal.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Count", mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+
+	rawCreatedAt := time.Now()
+	validator := &models.ApiResponseValidator{ETag: `"v1"`, RawCreatedAt: &rawCreatedAt, RawRows: 3}
+	mockApi := new(mockapi.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		assert.Equal(t, "true", args.Get(2).(http.Header).Get(HeaderConditionalRequest))
+		res := &http.Response{
+			StatusCode: http.StatusNotModified,
+			Request:    conditionalRequest("issues", validator),
+			Body:       io.NopCloser(bytes.NewBufferString("")),
+		}
+		handler := args.Get(3).(plugin.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:     unithelper.DummySubTaskContext(mockDal),
+			Table:   "whatever rawtable",
+			Options: &TestOpts{},
+		},
+		ApiClient:      mockApi,
+		Incremental:    true,
+		UrlTemplate:    "issues",
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	mockDal.AssertExpectations(t)
+	mockDal.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockApi.AssertExpectations(t)
+}
+
+func TestFetchNotModifiedWithoutRows(t *testing.T) {
+	// the raw rows of the unchanged response were removed, so it is collected again unconditionally
+	mockDal := new(mockdal.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+
+	requests := 0
+	mockApi := new(mockapi.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		requests++
+		res := &http.Response{
+			StatusCode: http.StatusNotModified,
+			Request:    conditionalRequest("issues", &models.ApiResponseValidator{ETag: `"v1"`}),
+			Body:       io.NopCloser(bytes.NewBufferString("")),
+		}
+		if requests == 1 {
+			assert.Equal(t, "true", args.Get(2).(http.Header).Get(HeaderConditionalRequest))
+		} else {
+			assert.Empty(t, args.Get(2).(http.Header).Get(HeaderConditionalRequest))
+			res.StatusCode = http.StatusOK
+			res.Request = &http.Request{URL: &url.URL{Path: "issues"}}
+			res.Body = io.NopCloser(bytes.NewBufferString("[1,2,3]"))
+		}
+		handler := args.Get(3).(plugin.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Twice()
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:     unithelper.DummySubTaskContext(mockDal),
+			Table:   "whatever rawtable",
+			Options: &TestOpts{},
+		},
+		ApiClient:      mockApi,
+		Incremental:    true,
+		UrlTemplate:    "issues",
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, 2, requests)
+
+	mockDal.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+}
+
+func conditionalRequest(path string, validator *models.ApiResponseValidator) *http.Request {
+	req := &http.Request{URL: &url.URL{Path: path}}
+	return req.WithContext(context.WithValue(context.Background(), apiResponseValidatorCtxKey{}, validator))
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models/common"
 	plugin "github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ApiExtractorArgs FIXME ...
@@ -83,11 +85,19 @@ func (extractor *ApiExtractor) Execute() errors.Error {
 		dal.Orderby("id ASC"),
 	}
 
+	_, span := tracing.Start(
+		extractor.args.Ctx.GetContext(),
+		"dal.Cursor",
+		attribute.String("db.sql.table", extractor.table),
+	)
 	count, err := db.Count(clauses...)
 	if err != nil {
+		tracing.End(span, err)
 		return errors.Default.Wrap(err, "error getting count of clauses")
 	}
+	span.SetAttributes(attribute.Int64("db.rows", count))
 	cursor, err := db.Cursor(clauses...)
+	tracing.End(span, err)
 	if err != nil {
 		return errors.Default.Wrap(err, "error running DB query")
 	}
@@ -111,6 +121,10 @@ func (extractor *ApiExtractor) Execute() errors.Error {
 		if err != nil {
 			return errors.Default.Wrap(err, "error fetching row")
 		}
+		row.Data, err = DecodeRawData(row.Data)
+		if err != nil {
+			return err
+		}
 
 		results, err := extractor.args.Extract(row)
 		if err != nil {
@@ -0,0 +1,260 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/metrics"
+)
+
+const (
+	// rateLimitSafetyFactor keeps a margin of the remaining requests for other clients of the same credentials
+	rateLimitSafetyFactor = 0.95
+	// minRateLimitBackoff is the first pause after a secondary rate limit without Retry-After, it doubles on every hit
+	minRateLimitBackoff = time.Minute
+	maxRateLimitBackoff = 30 * time.Minute
+)
+
+var (
+	connectionRateLimiters      = make(map[string]*ConnectionRateLimiter)
+	connectionRateLimitersMutex sync.Mutex
+)
+
+// ConnectionRateLimiter is a token bucket shared by all the tasks calling the same connection in the process,
+// its rate adapts to the rate limit headers of the responses and it pauses when the data source asks to back off
+type ConnectionRateLimiter struct {
+	mu          sync.Mutex
+	connection  string
+	maxRate     float64 // requests per second allowed by the calculated budget
+	rate        float64 // requests per second allowed right now
+	burst       float64
+	tokens      float64
+	last        time.Time
+	resetAt     time.Time // when the current rate limit window of the data source resets
+	pausedUntil time.Time
+	backoff     time.Duration
+	// scale multiplies the remaining requests reported by the headers, i.e. the number of tokens rotated
+	scale int
+}
+
+// getConnectionRateLimiter returns the rate limiter of the connection, the budget of the latest task wins
+func getConnectionRateLimiter(connection string, requests int, duration time.Duration, scale int) *ConnectionRateLimiter {
+	connectionRateLimitersMutex.Lock()
+	defer connectionRateLimitersMutex.Unlock()
+	limiter, ok := connectionRateLimiters[connection]
+	if !ok {
+		limiter = &ConnectionRateLimiter{connection: connection}
+		connectionRateLimiters[connection] = limiter
+	}
+	limiter.setBudget(requests, duration, scale)
+	return limiter
+}
+
+func (l *ConnectionRateLimiter) setBudget(requests int, duration time.Duration, scale int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	maxRate := float64(requests) / duration.Seconds()
+	if maxRate <= 0 || math.IsInf(maxRate, 0) || math.IsNaN(maxRate) {
+		maxRate = 1
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	isNew := l.last.IsZero()
+	l.maxRate = maxRate
+	l.burst = math.Max(1, maxRate)
+	l.scale = scale
+	if isNew || l.rate > maxRate || l.resetAt.IsZero() {
+		l.rate = maxRate
+	}
+	if isNew {
+		l.tokens = l.burst
+		l.last = time.Now()
+	}
+}
+
+// Wait blocks until a request may be sent to the connection or the context is done
+func (l *ConnectionRateLimiter) Wait(ctx context.Context) errors.Error {
+	for {
+		delay := l.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Convert(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again
+func (l *ConnectionRateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if !l.resetAt.IsZero() && !now.Before(l.resetAt) {
+		// a new window started, the whole budget is available again
+		l.rate = l.maxRate
+		l.resetAt = time.Time{}
+	}
+	if now.After(l.last) {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Observe adapts the limiter to the rate limit headers of the response, the headers of GitHub, GitLab, Jira and
+// Bitbucket are understood. Responses rejected by a (secondary) rate limit pause all the requests of the connection
+func (l *ConnectionRateLimiter) Observe(res *http.Response, body []byte) {
+	l.observe(res, body, time.Now())
+}
+
+func (l *ConnectionRateLimiter) observe(res *http.Response, body []byte, now time.Time) {
+	if res == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	remaining, hasRemaining := parseRateLimitRemaining(res.Header)
+	reset, hasReset := parseRateLimitReset(res.Header, now)
+	retryAfter, hasRetryAfter := parseRetryAfter(res.Header, now)
+	if hasRemaining {
+		metrics.ApiRateLimitRemaining.WithLabelValues(l.connection).Set(float64(remaining * l.scale))
+	}
+	if hasReset {
+		metrics.ApiRateLimitResetSeconds.WithLabelValues(l.connection).Set(math.Max(0, reset.Sub(now).Seconds()))
+	}
+
+	if isRateLimited(res, body, hasRetryAfter, hasRemaining && remaining == 0) {
+		// as recommended by GitHub: honor Retry-After, then the reset of an exhausted window, then back off exponentially
+		var until time.Time
+		switch {
+		case hasRetryAfter:
+			until = now.Add(retryAfter)
+		case hasRemaining && remaining == 0 && hasReset && reset.After(now):
+			until = reset
+		default:
+			l.backoff = time.Duration(math.Min(float64(maxRateLimitBackoff), math.Max(float64(minRateLimitBackoff), float64(2*l.backoff))))
+			until = now.Add(l.backoff)
+		}
+		if until.After(l.pausedUntil) {
+			l.pausedUntil = until
+		}
+		l.tokens = 0
+		return
+	}
+	if res.StatusCode < http.StatusBadRequest {
+		l.backoff = 0
+	}
+
+	if !hasRemaining || !hasReset || !reset.After(now) {
+		return
+	}
+	if remaining == 0 {
+		// the window is exhausted although the request went through, wait for the reset
+		if reset.After(l.pausedUntil) {
+			l.pausedUntil = reset
+		}
+		return
+	}
+	// spread what is left evenly over the rest of the window
+	rate := float64(remaining*l.scale) * rateLimitSafetyFactor / reset.Sub(now).Seconds()
+	l.rate = math.Max(math.Min(rate, l.maxRate), math.SmallestNonzeroFloat64)
+	l.resetAt = reset
+}
+
+func isRateLimited(res *http.Response, body []byte, hasRetryAfter bool, exhausted bool) bool {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		// GitHub answers both the primary and the secondary rate limits with 403
+		return hasRetryAfter || exhausted || strings.Contains(strings.ToLower(string(body)), "rate limit")
+	}
+	return false
+}
+
+func parseRateLimitRemaining(header http.Header) (int, bool) {
+	for _, name := range []string{"X-RateLimit-Remaining", "RateLimit-Remaining"} {
+		if v := header.Get(name); v != "" {
+			if remaining, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && remaining >= 0 {
+				return remaining, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseRateLimitReset reads the reset as unix seconds (GitHub, GitLab), seconds from now (IETF draft) or a
+// timestamp (Jira, Bitbucket)
+func parseRateLimitReset(header http.Header, now time.Time) (time.Time, bool) {
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		v := strings.TrimSpace(header.Get(name))
+		if v == "" {
+			continue
+		}
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if seconds > 1e9 {
+				return time.Unix(seconds, 0), true
+			}
+			return now.Add(time.Duration(seconds) * time.Second), true
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02T15:04Z"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
@@ -0,0 +1,156 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rateLimitResponse(status int, headers map[string]string) *http.Response {
+	res := &http.Response{StatusCode: status, Header: http.Header{}}
+	for k, v := range headers {
+		res.Header.Set(k, v)
+	}
+	return res
+}
+
+func newTestRateLimiter(requests int, duration time.Duration, scale int) *ConnectionRateLimiter {
+	limiter := &ConnectionRateLimiter{connection: "test#1"}
+	limiter.setBudget(requests, duration, scale)
+	return limiter
+}
+
+func TestParseRateLimitReset(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	for value, expected := range map[string]time.Time{
+		strconv.FormatInt(now.Add(time.Hour).Unix(), 10): now.Add(time.Hour),
+		"30":                            now.Add(30 * time.Second),
+		"2026-10-17T10:05Z":             now.Add(5 * time.Minute),
+		"2026-10-17T10:05:00Z":          now.Add(5 * time.Minute),
+		"Sat, 17 Oct 2026 10:10:00 GMT": now.Add(10 * time.Minute),
+	} {
+		reset, ok := parseRateLimitReset(http.Header{"X-Ratelimit-Reset": {value}}, now)
+		assert.True(t, ok, value)
+		assert.True(t, expected.Equal(reset), value)
+	}
+	reset, ok := parseRateLimitReset(http.Header{"Ratelimit-Reset": {"60"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Minute), reset)
+	_, ok = parseRateLimitReset(http.Header{}, now)
+	assert.False(t, ok)
+}
+
+func TestConnectionRateLimiterAdaptsToRemaining(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(3600, time.Hour, 2)
+	assert.Equal(t, 1.0, limiter.rate)
+
+	// 95 requests left per token for the 2 tokens within 100 seconds
+	limiter.observe(rateLimitResponse(http.StatusOK, map[string]string{
+		"X-RateLimit-Remaining": "50",
+		"X-RateLimit-Reset":     strconv.FormatInt(now.Add(100*time.Second).Unix(), 10),
+	}), nil, now)
+	assert.InDelta(t, 0.95, limiter.rate, 0.02)
+
+	// the budget is never exceeded
+	limiter.observe(rateLimitResponse(http.StatusOK, map[string]string{
+		"RateLimit-Remaining": "5000",
+		"RateLimit-Reset":     "100",
+	}), nil, now)
+	assert.Equal(t, 1.0, limiter.rate)
+
+	// the whole budget is back once the window resets
+	limiter.observe(rateLimitResponse(http.StatusOK, map[string]string{
+		"X-RateLimit-Remaining": "1",
+		"X-RateLimit-Reset":     "100",
+	}), nil, now)
+	assert.Less(t, limiter.rate, 0.1)
+	limiter.reserve(now.Add(101 * time.Second))
+	assert.Equal(t, 1.0, limiter.rate)
+}
+
+func TestConnectionRateLimiterPauses(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(3600, time.Hour, 1)
+
+	// Retry-After comes first
+	limiter.observe(rateLimitResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}), nil, now)
+	assert.Equal(t, 30*time.Second, limiter.reserve(now))
+	assert.Equal(t, 10*time.Second, limiter.reserve(now.Add(20*time.Second)))
+
+	// then the reset of the exhausted window
+	limiter = newTestRateLimiter(3600, time.Hour, 1)
+	limiter.observe(rateLimitResponse(http.StatusForbidden, map[string]string{
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     strconv.FormatInt(now.Add(time.Minute).Unix(), 10),
+	}), nil, now)
+	assert.InDelta(t, time.Minute.Seconds(), limiter.reserve(now).Seconds(), 1)
+
+	// a forbidden response unrelated to rate limits does not pause
+	limiter = newTestRateLimiter(3600, time.Hour, 1)
+	limiter.observe(rateLimitResponse(http.StatusForbidden, nil), []byte(`{"message":"Resource not accessible by integration"}`), now)
+	assert.Equal(t, time.Duration(0), limiter.reserve(now))
+}
+
+func TestConnectionRateLimiterSecondaryRateLimitBackoff(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(3600, time.Hour, 1)
+	body := []byte(`{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		limiter.observe(rateLimitResponse(http.StatusForbidden, nil), body, now)
+		assert.Equal(t, expected, limiter.reserve(now))
+		now = now.Add(expected)
+	}
+	for i := 0; i < 10; i++ {
+		limiter.observe(rateLimitResponse(http.StatusForbidden, nil), body, now)
+	}
+	assert.Equal(t, maxRateLimitBackoff, limiter.backoff)
+
+	// a successful response resets the backoff
+	limiter.observe(rateLimitResponse(http.StatusOK, nil), nil, now)
+	assert.Equal(t, time.Duration(0), limiter.backoff)
+}
+
+func TestConnectionRateLimiterWait(t *testing.T) {
+	limiter := newTestRateLimiter(3600*20, time.Hour, 1)
+	start := time.Now()
+	// the burst of one second goes through at once, the next request waits for a token
+	for i := 0; i < 21; i++ {
+		assert.Nil(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	limiter.observe(rateLimitResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "60"}), nil, time.Now())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, limiter.Wait(ctx))
+}
+
+func TestGetConnectionRateLimiterShared(t *testing.T) {
+	a := getConnectionRateLimiter("test#shared", 3600, time.Hour, 1)
+	b := getConnectionRateLimiter("test#shared", 7200, time.Hour, 1)
+	assert.Same(t, a, b)
+	assert.Equal(t, 2.0, b.maxRate)
+	assert.NotSame(t, a, getConnectionRateLimiter("test#other", 3600, time.Hour, 1))
+}
@@ -18,9 +18,11 @@ limitations under the License.
 package api
 
 import (
-	"github.com/apache/incubator-devlake/core/errors"
 	"net/http"
 	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/metrics"
 )
 
 // ApiRateLimitCalculator is A helper to calculate api rate limit dynamically, assuming api returning remaining/resettime information
@@ -31,6 +33,9 @@ type ApiRateLimitCalculator struct {
 	Method                 string
 	ApiPath                string
 	DynamicRateLimit       func(res *http.Response) (int, time.Duration, errors.Error)
+	// TokensCount is the number of tokens rotated by the connection, rate limit headers report the remaining
+	// requests of a single token
+	TokensCount int
 }
 
 // Calculate FIXME ...
@@ -57,6 +62,9 @@ func (c *ApiRateLimitCalculator) Calculate(apiClient *ApiClient) (int, time.Dura
 			if err != nil {
 				return requests, duration, err
 			}
+			connection := apiClient.GetConnectionLabel()
+			metrics.ApiRateLimitRemaining.WithLabelValues(connection).Set(float64(requests))
+			metrics.ApiRateLimitResetSeconds.WithLabelValues(connection).Set(duration.Seconds())
 
 			if duration == 0 {
 				return c.GlobalRateLimitPerHour, 1 * time.Hour, nil
@@ -0,0 +1,145 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// HeaderConditionalRequest marks a GET request of ApiClient.Do to be sent with the validators of the last response
+// to the same url, so the server could answer `304 Not Modified` if nothing changed, which the caller has to handle.
+// The marker itself is never sent.
+const HeaderConditionalRequest = "X-Devlake-Conditional-Request"
+
+// apiResponseValidatorTtl is how long validators are kept without being refreshed by a successful response, so the
+// validators of urls no longer requested don't pile up
+const apiResponseValidatorTtl = 30 * 24 * time.Hour
+
+type apiResponseValidatorCtxKey struct{}
+
+// apiResponseValidators persists the `ETag` and `Last-Modified` validators of the responses of a connection by url
+type apiResponseValidators struct {
+	db         dal.Dal
+	connection string
+}
+
+func newApiResponseValidators(db dal.Dal, connection string) *apiResponseValidators {
+	return &apiResponseValidators{db: db, connection: connection}
+}
+
+// apiResponseValidatorKey hashes the url without its time valued query parameters, i.e. `since` or `updated_after`
+// of incremental collections, so the validators of a resource are kept in one row across collections
+func apiResponseValidatorKey(rawUrl string) string {
+	if u, err := url.Parse(rawUrl); err == nil {
+		query := u.Query()
+		for name, values := range query {
+			if len(values) == 1 {
+				if _, err := common.ConvertStringToTime(values[0]); err == nil {
+					query.Del(name)
+				}
+			}
+		}
+		u.RawQuery = query.Encode()
+		rawUrl = u.String()
+	}
+	hash := sha256.Sum256([]byte(rawUrl))
+	return hex.EncodeToString(hash[:])
+}
+
+// apiResponseValidatorOf returns the validator the request of the response was sent with, nil if it was not sent
+// conditionally
+func apiResponseValidatorOf(res *http.Response) *models.ApiResponseValidator {
+	if res == nil || res.Request == nil {
+		return nil
+	}
+	validator, _ := res.Request.Context().Value(apiResponseValidatorCtxKey{}).(*models.ApiResponseValidator)
+	return validator
+}
+
+// expire removes the validators which were not refreshed within apiResponseValidatorTtl
+func (v *apiResponseValidators) expire() errors.Error {
+	return v.db.Delete(
+		&models.ApiResponseValidator{},
+		dal.Where("connection = ? AND updated_at < ?", v.connection, time.Now().Add(-apiResponseValidatorTtl)),
+	)
+}
+
+// apply adds the validators of the last response to the url into the request, the returned request carries the
+// validator so it could be found by apiResponseValidatorOf
+func (v *apiResponseValidators) apply(req *http.Request, url string) (*http.Request, errors.Error) {
+	validator := &models.ApiResponseValidator{}
+	key := apiResponseValidatorKey(url)
+	err := v.db.First(validator, dal.Where("connection = ? AND url_hash = ?", v.connection, key))
+	if err != nil {
+		if !v.db.IsErrorNotFound(err) {
+			return nil, err
+		}
+		validator = &models.ApiResponseValidator{Connection: v.connection, UrlHash: key}
+	}
+	if validator.ETag != "" {
+		req.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validator.LastModified)
+	}
+	return req.WithContext(context.WithValue(req.Context(), apiResponseValidatorCtxKey{}, validator)), nil
+}
+
+// save records the validators of a successful response, the raw rows of the previous response are forgotten until
+// the collector records the new ones
+func (v *apiResponseValidators) save(res *http.Response) errors.Error {
+	validator := apiResponseValidatorOf(res)
+	if validator == nil || res.StatusCode != http.StatusOK {
+		return nil
+	}
+	validator.ETag = res.Header.Get("ETag")
+	validator.LastModified = res.Header.Get("Last-Modified")
+	validator.RawCreatedAt = nil
+	validator.RawRows = 0
+	if validator.ETag == "" && validator.LastModified == "" {
+		return v.db.Delete(&models.ApiResponseValidator{}, dal.Where("connection = ? AND url_hash = ?", validator.Connection, validator.UrlHash))
+	}
+	return v.db.CreateOrUpdate(validator)
+}
+
+// recordApiResponseRawRows records the raw rows the response was saved as, so they could be reused once the server
+// answers `304 Not Modified` to the next request
+func recordApiResponseRawRows(db dal.Dal, res *http.Response, createdAt time.Time, rows int) errors.Error {
+	validator := apiResponseValidatorOf(res)
+	if validator == nil || res.StatusCode != http.StatusOK {
+		return nil
+	}
+	return db.UpdateColumns(
+		&models.ApiResponseValidator{},
+		[]dal.DalSet{
+			{ColumnName: "raw_created_at", Value: createdAt},
+			{ColumnName: "raw_rows", Value: rows},
+		},
+		dal.Where("connection = ? AND url_hash = ?", validator.Connection, validator.UrlHash),
+	)
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // BatchSave performs multiple records persistence of a specific type in one sql query to improve the performance
@@ -43,6 +45,7 @@ type BatchSave struct {
 	valueIndex map[string]int
 	primaryKey []reflect.StructField
 	tableName  string
+	rowsSaved  prometheus.Counter
 	mutex      sync.Mutex
 	lastErr    errors.Error
 }
@@ -74,9 +77,21 @@ func NewBatchSave(basicRes context.BasicRes, slotType reflect.Type, size int, ta
 		valueIndex: make(map[string]int),
 		primaryKey: primaryKey,
 		tableName:  tn,
+		rowsSaved:  metrics.BatchSaveRows.WithLabelValues(batchSaveTableLabel(slotType, tn)),
 	}, nil
 }
 
+// batchSaveTableLabel returns the name of the table the records would be saved into for metrics labelling
+func batchSaveTableLabel(slotType reflect.Type, tableName string) string {
+	if tableName != "" {
+		return tableName
+	}
+	if tabler, ok := reflect.New(slotType.Elem()).Interface().(dal.Tabler); ok {
+		return tabler.TableName()
+	}
+	return slotType.Elem().Name()
+}
+
 // Add record to cache. BatchSave would flush them into Database when cache is max out
 func (c *BatchSave) Add(slot interface{}) errors.Error {
 	// type checking
@@ -134,6 +149,7 @@ func (c *BatchSave) flushWithoutLocking() errors.Error {
 		return err
 	}
 	c.log.Debug("batch save flush total %d records to database", c.current)
+	c.rowsSaved.Add(float64(c.current))
 	c.current = 0
 	c.valueIndex = make(map[string]int)
 	return nil
@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+)
+
+var chatUrlRegexp = regexp.MustCompile(`https?://[^\s<>|"'\[\]()]+`)
+var chatIssueKeyRegexp = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+)-\d+\b`)
+
+// ChatMessageLinker finds pull requests and issues mentioned in chat messages, either by
+// their urls or by issue keys like `DEVLAKE-123`. Issue keys are only looked up among the
+// boards of the projects the channel belongs to, and only if their prefix is a key of those
+// boards, so tokens like `UTF-8` are left alone
+type ChatMessageLinker struct {
+	db        dal.Dal
+	boardIds  []string
+	issueKeys map[string]bool
+}
+
+// NewChatMessageLinker creates a new ChatMessageLinker for messages of the chat channel
+func NewChatMessageLinker(db dal.Dal, channelId string) (*ChatMessageLinker, errors.Error) {
+	l := &ChatMessageLinker{db: db, issueKeys: make(map[string]bool)}
+	err := db.Pluck(
+		"DISTINCT pm_board.row_id",
+		&l.boardIds,
+		dal.From("project_mapping pm_channel"),
+		dal.Join("INNER JOIN project_mapping pm_board ON pm_board.project_name = pm_channel.project_name AND pm_board.table = ?", ticket.Board{}.TableName()),
+		dal.Where("pm_channel.table = ? AND pm_channel.row_id = ?", crossdomain.ChatChannel{}.TableName(), channelId),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to find boards of the chat channel")
+	}
+	if len(l.boardIds) == 0 {
+		return l, nil
+	}
+	var keys []string
+	err = db.Pluck(
+		"DISTINCT SUBSTRING(i.issue_key, 1, POSITION('-' IN i.issue_key) - 1)",
+		&keys,
+		dal.From("issues i"),
+		dal.Join("INNER JOIN board_issues bi ON bi.issue_id = i.id"),
+		dal.Where("bi.board_id IN ? AND i.issue_key LIKE ?", l.boardIds, "%-%"),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to find issue keys of the chat channel")
+	}
+	for _, key := range keys {
+		l.issueKeys[key] = true
+	}
+	return l, nil
+}
+
+// Link returns ChatMessagePullRequest and ChatMessageIssue records for the message
+func (l *ChatMessageLinker) Link(messageId, content string) ([]interface{}, errors.Error) {
+	urls, issueKeys := extractChatMentions(content, l.issueKeys)
+	if len(urls) == 0 && len(issueKeys) == 0 {
+		return nil, nil
+	}
+	var results []interface{}
+	if len(urls) > 0 {
+		var pullRequestIds []string
+		err := l.db.Pluck("id", &pullRequestIds, dal.From(&code.PullRequest{}), dal.Where("url IN ?", urls))
+		if err != nil {
+			return nil, err
+		}
+		for _, pullRequestId := range pullRequestIds {
+			results = append(results, &crossdomain.ChatMessagePullRequest{
+				MessageId:     messageId,
+				PullRequestId: pullRequestId,
+			})
+		}
+	}
+	var issueIds []string
+	clauses := []dal.Clause{dal.From(&ticket.Issue{})}
+	issueKeysOfBoards := "issue_key IN ? AND id IN (SELECT issue_id FROM board_issues WHERE board_id IN ?)"
+	switch {
+	case len(urls) > 0 && len(issueKeys) > 0:
+		clauses = append(clauses, dal.Where("url IN ? OR "+issueKeysOfBoards, urls, issueKeys, l.boardIds))
+	case len(urls) > 0:
+		clauses = append(clauses, dal.Where("url IN ?", urls))
+	default:
+		clauses = append(clauses, dal.Where(issueKeysOfBoards, issueKeys, l.boardIds))
+	}
+	err := l.db.Pluck("id", &issueIds, clauses...)
+	if err != nil {
+		return nil, err
+	}
+	for _, issueId := range issueIds {
+		results = append(results, &crossdomain.ChatMessageIssue{
+			MessageId: messageId,
+			IssueId:   issueId,
+		})
+	}
+	return results, nil
+}
+
+// extractChatMentions returns candidate urls and issue keys of the known keys mentioned in the content. Urls are
+// normalized and shortened by up to 2 path segments so that links like `.../pull/1/files` still match
+func extractChatMentions(content string, knownKeys map[string]bool) ([]string, []string) {
+	var urls, issueKeys []string
+	seen := make(map[string]bool)
+	for _, raw := range chatUrlRegexp.FindAllString(content, -1) {
+		u, err := url.Parse(strings.TrimRight(raw, ".,;:!?>"))
+		if err != nil {
+			continue
+		}
+		u.RawQuery = ""
+		u.Fragment = ""
+		u.Path = strings.TrimRight(u.Path, "/")
+		for i := 0; i < 3 && u.Path != ""; i++ {
+			candidate := u.String()
+			if !seen[candidate] {
+				seen[candidate] = true
+				urls = append(urls, candidate)
+			}
+			u.Path = u.Path[:strings.LastIndex(u.Path, "/")]
+		}
+	}
+	// urls might contain issue keys as well, e.g. jira browse links, which is fine
+	for _, match := range chatIssueKeyRegexp.FindAllStringSubmatch(content, -1) {
+		issueKey := match[0]
+		if knownKeys[match[1]] && !seen[issueKey] {
+			seen[issueKey] = true
+			issueKeys = append(issueKeys, issueKey)
+		}
+	}
+	return urls, issueKeys
+}
@@ -0,0 +1,49 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractChatMentions(t *testing.T) {
+	knownKeys := map[string]bool{"DEVLAKE": true}
+	urls, issueKeys := extractChatMentions("please review <https://github.com/apache/incubator-devlake/pull/123/files?w=1|#123>, it fixes DEVLAKE-42 and https://github.com/apache/incubator-devlake/issues/7.", knownKeys)
+	assert.Equal(t, []string{
+		"https://github.com/apache/incubator-devlake/pull/123/files",
+		"https://github.com/apache/incubator-devlake/pull/123",
+		"https://github.com/apache/incubator-devlake/pull",
+		"https://github.com/apache/incubator-devlake/issues/7",
+		"https://github.com/apache/incubator-devlake/issues",
+		"https://github.com/apache/incubator-devlake",
+	}, urls)
+	assert.Equal(t, []string{"DEVLAKE-42"}, issueKeys)
+
+	urls, issueKeys = extractChatMentions("nothing to see here", knownKeys)
+	assert.Empty(t, urls)
+	assert.Empty(t, issueKeys)
+
+	// tokens which look like issue keys are ignored unless they are keys of the project
+	urls, issueKeys = extractChatMentions("saved as UTF-8 per ISO-9001, see DEVLAKE-7 and OTHER-8", knownKeys)
+	assert.Empty(t, urls)
+	assert.Equal(t, []string{"DEVLAKE-7"}, issueKeys)
+	_, issueKeys = extractChatMentions("see DEVLAKE-7", nil)
+	assert.Empty(t, issueKeys)
+}
@@ -0,0 +1,275 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// collectorCheckpoints keeps track of the pages committed by a collector for each of its inputs. Raw rows of a
+// page and the row of the page are saved in the same transaction, so a resumed task could skip everything committed
+// before it was interrupted without losing or duplicating any raw data.
+// Checkpoints belong to a specific task, they are only reused when the same task gets resumed, and would be
+// removed once the collection finished successfully.
+type collectorCheckpoints struct {
+	db          dal.Dal
+	table       string
+	params      string
+	collector   string
+	taskId      uint64
+	mu          sync.Mutex
+	checkpoints map[string]*models.CollectorCheckpoint
+	// pages maps the input hash to its committed page numbers and whether the collection continued after them
+	pages map[string]map[int]bool
+}
+
+func newCollectorCheckpoints(ctx plugin.SubTaskContext, table, params, collector string) (*collectorCheckpoints, errors.Error) {
+	c := &collectorCheckpoints{
+		db:          ctx.GetDal(),
+		table:       table,
+		params:      params,
+		collector:   collector,
+		taskId:      plugin.TaskIdFromContext(ctx.GetContext()),
+		checkpoints: make(map[string]*models.CollectorCheckpoint),
+		pages:       make(map[string]map[int]bool),
+	}
+	if c.taskId == 0 {
+		// not running inside a task, i.e. directrun
+		return c, nil
+	}
+	var rows []*models.CollectorCheckpoint
+	err := c.db.All(&rows, c.where())
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load collector checkpoints")
+	}
+	var pages []*models.CollectorCheckpointPage
+	err = c.db.All(&pages, c.where(), dal.Where("task_id = ?", c.taskId))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load collector checkpoint pages")
+	}
+	stale := false
+	for _, row := range rows {
+		if row.TaskId == c.taskId {
+			c.checkpoints[row.InputHash] = row
+		} else {
+			stale = true
+		}
+	}
+	for _, page := range pages {
+		c.pagesOf(page.InputHash)[page.Page] = page.HasMore
+	}
+	if stale {
+		err = c.db.Delete(&models.CollectorCheckpoint{}, c.where(), dal.Where("task_id <> ?", c.taskId))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to delete stale collector checkpoints")
+		}
+	}
+	// pages are committed without their checkpoint, so they are cleaned up regardless
+	err = c.db.Delete(&models.CollectorCheckpointPage{}, c.where(), dal.Where("task_id <> ?", c.taskId))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to delete stale collector checkpoint pages")
+	}
+	return c, nil
+}
+
+func (c *collectorCheckpoints) where() dal.Clause {
+	return dal.Where("raw_table = ? AND params = ? AND collector = ?", c.table, c.params, c.collector)
+}
+
+// pagesOf returns the committed pages of the input, the caller must hold the mutex unless initializing
+func (c *collectorCheckpoints) pagesOf(inputHash string) map[int]bool {
+	pages := c.pages[inputHash]
+	if pages == nil {
+		pages = make(map[int]bool)
+		c.pages[inputHash] = pages
+	}
+	return pages
+}
+
+// IsResuming tells whether the collection was interrupted and is being resumed by the same task
+func (c *collectorCheckpoints) IsResuming() bool {
+	return c != nil && (len(c.checkpoints) > 0 || len(c.pages) > 0)
+}
+
+// IsDone tells whether all pages of the input were committed
+func (c *collectorCheckpoints) IsDone(inputJSON []byte) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inputHash := hashCheckpointInput(inputJSON)
+	cp := c.checkpoints[inputHash]
+	return cp != nil && (cp.Done || cp.TotalPages > 0 && len(c.pages[inputHash]) >= cp.TotalPages)
+}
+
+// IsPageCommitted tells whether the page of the input was committed and whether the collection continued after it
+func (c *collectorCheckpoints) IsPageCommitted(inputJSON []byte, page int) (committed bool, hasMore bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hasMore, committed = c.pages[hashCheckpointInput(inputJSON)][page]
+	return
+}
+
+// Get returns a copy of the checkpoint of the input, or nil if nothing was committed for it
+func (c *collectorCheckpoints) Get(inputJSON []byte) *models.CollectorCheckpoint {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := c.checkpoints[hashCheckpointInput(inputJSON)]
+	if cp == nil {
+		return nil
+	}
+	cpCopy := *cp
+	return &cpCopy
+}
+
+// Commit updates the checkpoint of the input and saves it along with whatever `save` writes in one transaction
+func (c *collectorCheckpoints) Commit(
+	inputJSON []byte,
+	update func(cp *models.CollectorCheckpoint),
+	save func(tx dal.Dal) errors.Error,
+) errors.Error {
+	if c == nil || c.taskId == 0 {
+		return c.saveWithoutCheckpoint(save)
+	}
+	inputHash := hashCheckpointInput(inputJSON)
+	c.mu.Lock()
+	cp := c.checkpoints[inputHash]
+	if cp == nil {
+		cp = &models.CollectorCheckpoint{
+			RawTable:  c.table,
+			Params:    c.params,
+			Collector: c.collector,
+			InputHash: inputHash,
+			TaskId:    c.taskId,
+		}
+		c.checkpoints[inputHash] = cp
+	}
+	update(cp)
+	cpCopy := *cp
+	c.mu.Unlock()
+	return c.inTransaction(save, func(tx dal.Dal) errors.Error {
+		return tx.CreateOrUpdate(&cpCopy)
+	})
+}
+
+// CommitPage saves the page of the input along with whatever `save` writes in one transaction, hasMore tells whether
+// the collection continues after the page
+func (c *collectorCheckpoints) CommitPage(
+	inputJSON []byte,
+	page int,
+	hasMore bool,
+	save func(tx dal.Dal) errors.Error,
+) errors.Error {
+	if c == nil || c.taskId == 0 {
+		return c.saveWithoutCheckpoint(save)
+	}
+	inputHash := hashCheckpointInput(inputJSON)
+	err := c.inTransaction(save, func(tx dal.Dal) errors.Error {
+		return tx.Create(&models.CollectorCheckpointPage{
+			RawTable:  c.table,
+			Params:    c.params,
+			Collector: c.collector,
+			InputHash: inputHash,
+			Page:      page,
+			TaskId:    c.taskId,
+			HasMore:   hasMore,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pagesOf(inputHash)[page] = hasMore
+	return nil
+}
+
+func (c *collectorCheckpoints) saveWithoutCheckpoint(save func(tx dal.Dal) errors.Error) errors.Error {
+	if save == nil {
+		return nil
+	}
+	if c == nil {
+		return errors.Default.New("collector checkpoints are not initialized")
+	}
+	return save(c.db)
+}
+
+func (c *collectorCheckpoints) inTransaction(save func(tx dal.Dal) errors.Error, checkpoint func(tx dal.Dal) errors.Error) (err errors.Error) {
+	tx := c.db.Begin()
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if save != nil {
+		if err = save(tx); err != nil {
+			return err
+		}
+	}
+	if err = checkpoint(tx); err != nil {
+		return errors.Default.Wrap(err, "failed to save collector checkpoint")
+	}
+	return tx.Commit()
+}
+
+// Close removes all checkpoints of the collector, it should be called after the collection finished successfully.
+// Checkpoints of nested collectors are kept until all of them are finished, otherwise a resumed task would collect
+// everything of the finished ones again.
+func (c *collectorCheckpoints) Close() errors.Error {
+	if c == nil || c.taskId == 0 || c.collector != "" {
+		return nil
+	}
+	c.mu.Lock()
+	c.checkpoints = make(map[string]*models.CollectorCheckpoint)
+	c.pages = make(map[string]map[int]bool)
+	c.mu.Unlock()
+	err := c.db.Delete(&models.CollectorCheckpointPage{}, c.where())
+	if err != nil {
+		return err
+	}
+	return c.db.Delete(&models.CollectorCheckpoint{}, c.where())
+}
+
+// deleteCollectorCheckpoints removes checkpoints of all collectors sharing the raw table and params
+func deleteCollectorCheckpoints(db dal.Dal, table, params string) errors.Error {
+	where := dal.Where("raw_table = ? AND params = ?", table, params)
+	err := db.Delete(&models.CollectorCheckpointPage{}, where)
+	if err != nil {
+		return err
+	}
+	return db.Delete(&models.CollectorCheckpoint{}, where)
+}
+
+func hashCheckpointInput(inputJSON []byte) string {
+	sum := sha256.Sum256(inputJSON)
+	return hex.EncodeToString(sum[:])
+}
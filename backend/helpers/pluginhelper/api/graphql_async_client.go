@@ -23,11 +23,13 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/core/utils"
 	"sync"
 	"time"
 
 	"github.com/merico-ai/graphql"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // GraphqlAsyncClient send graphql one by one
@@ -164,7 +166,12 @@ func (apiClient *GraphqlAsyncClient) Query(q interface{}, variables map[string]i
 			return nil, nil
 		default:
 			var dataErrors []graphql.DataError
-			dataErrors, err := apiClient.client.Query(apiClient.ctx, q, variables)
+			ctx, span := tracing.Start(apiClient.ctx, "GraphQL query", attribute.Int("graphql.retry", retryTime))
+			dataErrors, err := apiClient.client.Query(ctx, q, variables)
+			if err == nil && len(dataErrors) > 0 {
+				span.SetAttributes(attribute.Int("graphql.data_errors", len(dataErrors)))
+			}
+			tracing.End(span, err)
 			if err == context.Canceled {
 				return nil, err
 			}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
 	plugin "github.com/apache/incubator-devlake/core/plugin"
 	"github.com/merico-ai/graphql"
 )
@@ -89,6 +90,9 @@ type GraphqlCollector struct {
 	args         *GraphqlCollectorArgs
 	workerErrors []error
 	batchSave    *BatchSave
+	// checkpointName distinguishes collectors sharing the same raw table, i.e. nested collectors of StatefulApiCollector
+	checkpointName string
+	checkpoints    *collectorCheckpoints
 }
 
 // ErrFinishCollect is an error which will finish this collector
@@ -139,8 +143,16 @@ func (collector *GraphqlCollector) Execute() errors.Error {
 	if err != nil {
 		return errors.Default.Wrap(err, "error running auto-migrate")
 	}
-	// flush data if not incremental collection
-	if !collector.args.Incremental {
+	collector.checkpoints, err = newCollectorCheckpoints(collector.args.Ctx, collector.table, collector.params, collector.checkpointName)
+	if err != nil {
+		return err
+	}
+	resuming := collector.checkpoints.IsResuming()
+	if resuming {
+		logger.Info("resume graphql collection from the last committed pages")
+	}
+	// flush data if not incremental collection, data committed before the interruption must be kept when resuming
+	if !collector.args.Incremental && !resuming {
 		err = db.Delete(&RawData{}, dal.From(collector.table), dal.Where("params = ?", collector.params))
 		if err != nil {
 			return errors.Default.Wrap(err, "error deleting data from collector")
@@ -196,7 +208,10 @@ func (collector *GraphqlCollector) Execute() errors.Error {
 	}
 
 	err = collector.batchSave.Close()
-	return err
+	if err != nil {
+		return err
+	}
+	return collector.checkpoints.Close()
 }
 
 func (collector *GraphqlCollector) exec(input interface{}) {
@@ -211,6 +226,13 @@ func (collector *GraphqlCollector) exec(input interface{}) {
 		SkipCursor: nil,
 		Size:       collector.args.PageSize,
 	}
+	// skip the input or continue from the page after the last committed one
+	if cp := collector.checkpoints.Get(inputJson); cp != nil {
+		if cp.Done {
+			return
+		}
+		reqData.Pager.SkipCursor = cp.NextCursor
+	}
 	if collector.args.GetPageInfo != nil {
 		collector.fetchOneByOne(reqData)
 	} else {
@@ -263,7 +285,6 @@ func (collector *GraphqlCollector) fetchAsync(reqData *GraphqlRequestData, handl
 	}
 
 	logger := collector.args.Ctx.GetLogger()
-	db := collector.args.Ctx.GetDal()
 	dataErrors, err := collector.args.GraphqlClient.Query(query, variables)
 	if err != nil {
 		if err == context.Canceled {
@@ -297,20 +318,6 @@ func (collector *GraphqlCollector) fetchAsync(reqData *GraphqlRequestData, handl
 	}
 
 	results, err := collector.args.ResponseParser(query)
-	for _, result := range results {
-		row := &RawData{
-			Params: collector.params,
-			Data:   result,
-			Url:    queryStr,
-			Input:  variablesJson,
-		}
-		// collector.batchSave.Add(row)
-		err = db.Create(row, dal.From(collector.table))
-		if err != nil {
-			collector.checkError(errors.Default.Wrap(err, `not created row table in graphql collector`))
-			return
-		}
-	}
 	if err != nil {
 		if errors.Is(err, ErrFinishCollect) {
 			logger.Info("collector finish by parser")
@@ -320,6 +327,48 @@ func (collector *GraphqlCollector) fetchAsync(reqData *GraphqlRequestData, handl
 			return
 		}
 	}
+	// save rows along with the cursor of the next page, so a resumed collection could continue from there
+	var pageInfo *GraphqlQueryPageInfo
+	var pageInfoErr error
+	if handler != nil && collector.args.GetPageInfo != nil {
+		// errors would be reported by the handler
+		pageInfo, pageInfoErr = collector.args.GetPageInfo(query, collector.args)
+	}
+	err = collector.checkpoints.Commit(reqData.InputJSON, func(cp *models.CollectorCheckpoint) {
+		if pageInfoErr != nil {
+			return
+		}
+		if pageInfo != nil && pageInfo.HasNextPage {
+			cursor := pageInfo.EndCursor
+			cp.NextCursor = &cursor
+		} else {
+			cp.Done = true
+		}
+	}, func(tx dal.Dal) errors.Error {
+		compression := rawDataCompression()
+		for _, result := range results {
+			data, err := EncodeRawData(result, compression)
+			if err != nil {
+				return err
+			}
+			row := &RawData{
+				Params: collector.params,
+				Data:   data,
+				Url:    queryStr,
+				Input:  variablesJson,
+			}
+			// collector.batchSave.Add(row)
+			err = tx.Create(row, dal.From(collector.table))
+			if err != nil {
+				return errors.Default.Wrap(err, `not created row table in graphql collector`)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		collector.checkError(err)
+		return
+	}
 
 	collector.args.Ctx.IncProgress(1)
 	if handler != nil {
@@ -0,0 +1,126 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	RAW_DATA_COMPRESSION_NONE = ""
+	RAW_DATA_COMPRESSION_GZIP = "gzip"
+	RAW_DATA_COMPRESSION_ZSTD = "zstd"
+)
+
+// payloads smaller than this are stored as they are since compressing them saves little, if anything
+const rawDataCompressionMinSize = 256
+
+// compressed payloads are recognized by the magic numbers of the formats, a json payload never starts with them
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+var (
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdOnce    sync.Once
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder = errors.Must1(zstd.NewWriter(nil))
+		zstdDecoder = errors.Must1(zstd.NewReader(nil))
+	})
+}
+
+// ValidateRawDataCompression checks if the compression is supported
+func ValidateRawDataCompression(compression string) errors.Error {
+	switch compression {
+	case RAW_DATA_COMPRESSION_NONE, RAW_DATA_COMPRESSION_GZIP, RAW_DATA_COMPRESSION_ZSTD:
+		return nil
+	}
+	return errors.BadInput.New(fmt.Sprintf("unsupported raw data compression %s", compression))
+}
+
+// rawDataCompression returns the compression of newly collected payloads configured by RAW_DATA_COMPRESSION
+func rawDataCompression() string {
+	return config.GetConfig().GetString("RAW_DATA_COMPRESSION")
+}
+
+// EncodeRawData compresses the payload to be stored in the `data` column of a raw table
+func EncodeRawData(data []byte, compression string) ([]byte, errors.Error) {
+	if compression == RAW_DATA_COMPRESSION_NONE || len(data) < rawDataCompressionMinSize || IsRawDataCompressed(data) {
+		return data, nil
+	}
+	switch compression {
+	case RAW_DATA_COMPRESSION_GZIP:
+		buf := &bytes.Buffer{}
+		writer := gzip.NewWriter(buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, errors.Default.Wrap(err, "failed to compress raw data")
+		}
+		if err := writer.Close(); err != nil {
+			return nil, errors.Default.Wrap(err, "failed to compress raw data")
+		}
+		return buf.Bytes(), nil
+	case RAW_DATA_COMPRESSION_ZSTD:
+		initZstd()
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/4)), nil
+	}
+	return nil, ValidateRawDataCompression(compression)
+}
+
+// DecodeRawData decompresses the payload read from the `data` column of a raw table, payloads stored as they are
+// get returned untouched, so extractors can process tables holding both
+func DecodeRawData(data []byte) ([]byte, errors.Error) {
+	switch {
+	case bytes.HasPrefix(data, zstdMagic):
+		initZstd()
+		decoded, err := zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to decompress raw data")
+		}
+		return decoded, nil
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to decompress raw data")
+		}
+		defer reader.Close()
+		decoded, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to decompress raw data")
+		}
+		return decoded, nil
+	}
+	return data, nil
+}
+
+// IsRawDataCompressed tells whether the payload was compressed by EncodeRawData
+func IsRawDataCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, zstdMagic) || bytes.HasPrefix(data, gzipMagic)
+}
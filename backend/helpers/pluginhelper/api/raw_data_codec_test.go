@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeRawData(t *testing.T) {
+	payload := []byte(`{"id":1,"title":"` + string(bytes.Repeat([]byte("a"), 1024)) + `"}`)
+	for _, compression := range []string{RAW_DATA_COMPRESSION_GZIP, RAW_DATA_COMPRESSION_ZSTD} {
+		encoded, err := EncodeRawData(payload, compression)
+		assert.Nil(t, err)
+		assert.True(t, IsRawDataCompressed(encoded), compression)
+		assert.Less(t, len(encoded), len(payload), compression)
+		decoded, err := DecodeRawData(encoded)
+		assert.Nil(t, err)
+		assert.Equal(t, payload, decoded, compression)
+	}
+}
+
+func TestEncodeRawDataPassthrough(t *testing.T) {
+	small := []byte(`{"id":1}`)
+	encoded, err := EncodeRawData(small, RAW_DATA_COMPRESSION_ZSTD)
+	assert.Nil(t, err)
+	assert.Equal(t, small, encoded)
+
+	large := bytes.Repeat([]byte("a"), 1024)
+	encoded, err = EncodeRawData(large, RAW_DATA_COMPRESSION_NONE)
+	assert.Nil(t, err)
+	assert.Equal(t, large, encoded)
+
+	decoded, err := DecodeRawData(small)
+	assert.Nil(t, err)
+	assert.Equal(t, small, decoded)
+
+	_, err = EncodeRawData(large, "lz4")
+	assert.NotNil(t, err)
+}
@@ -71,21 +71,22 @@ func (c *defaultExecContext) SetProgress(progressType plugin.ProgressType, curre
 
 	if c.progress != nil {
 		c.progress <- plugin.RunningProgress{
-			Type:    progressType,
-			Current: current,
-			Total:   total,
+			Type:        progressType,
+			Current:     current,
+			Total:       total,
+			SubTaskName: c.name,
 		}
 	}
 }
 
 func (c *defaultExecContext) IncProgress(progressType plugin.ProgressType, quantity int) {
-	atomic.AddInt64(&c.current, int64(quantity))
-	current := c.current
+	current := atomic.AddInt64(&c.current, int64(quantity))
 	if c.progress != nil {
 		c.progress <- plugin.RunningProgress{
-			Type:    progressType,
-			Current: int(current),
-			Total:   c.total,
+			Type:        progressType,
+			Current:     int(current),
+			Total:       c.total,
+			SubTaskName: c.name,
 		}
 		// subtask progress may go too fast, remove old messages because they don't matter any more
 		if progressType == plugin.SubTaskSetProgress {
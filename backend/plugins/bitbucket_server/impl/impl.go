@@ -24,6 +24,7 @@ import (
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	coreModels "github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/plugins/bitbucket_server/api"
@@ -72,6 +73,7 @@ func (p BitbucketServer) GetTablesInfo() []dal.Tabler {
 		&models.BitbucketServerPrComment{},
 		&models.BitbucketServerRepo{},
 		&models.BitbucketServerPrCommit{},
+		&models.BitbucketServerBuild{},
 		&models.BitbucketServerScopeConfig{},
 	}
 }
@@ -95,11 +97,15 @@ func (p BitbucketServer) SubTaskMetas() []plugin.SubTaskMeta {
 		tasks.CollectApiPrCommitsMeta,
 		tasks.ExtractApiPrCommitsMeta,
 
+		tasks.CollectApiBuildsMeta,
+		tasks.ExtractApiBuildsMeta,
+
 		tasks.ConvertRepoMeta, // ?
 		tasks.ConvertPullRequestsMeta,
 
 		tasks.ConvertPrCommentsMeta,
 		tasks.ConvertPrCommitsMeta,
+		tasks.ConvertBuildsMeta,
 
 		tasks.ConvertUsersMeta,
 	}
@@ -133,6 +139,12 @@ func (p BitbucketServer) PrepareTaskData(taskCtx plugin.TaskContext, options map
 	}
 
 	regexEnricher := helper.NewRegexEnricher()
+	if err := regexEnricher.TryAdd(devops.DEPLOYMENT, op.DeploymentPattern); err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid value for `deploymentPattern`")
+	}
+	if err := regexEnricher.TryAdd(devops.PRODUCTION, op.ProductionPattern); err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid value for `productionPattern`")
+	}
 	taskData := &tasks.BitbucketServerTaskData{
 		Options:       op,
 		ApiClient:     apiClient,
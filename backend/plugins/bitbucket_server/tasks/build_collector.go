@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/bitbucket_server/models"
+)
+
+const RAW_BUILD_TABLE = "bitbucket_server_api_builds"
+
+var CollectApiBuildsMeta = plugin.SubTaskMeta{
+	Name:             "collectApiBuilds",
+	EntryPoint:       CollectApiBuilds,
+	EnabledByDefault: true,
+	Description:      "Collect build statuses of pull request commits and merge commits from Bitbucket Server api",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD},
+	DependencyTables: []string{
+		models.BitbucketServerPullRequest{}.TableName(),
+		models.BitbucketServerPrCommit{}.TableName(),
+	},
+	ProductTables: []string{RAW_BUILD_TABLE},
+}
+
+func CollectApiBuilds(taskCtx plugin.SubTaskContext) errors.Error {
+	rawDataSubTaskArgs, data := CreateRawDataSubTaskArgs(taskCtx, RAW_BUILD_TABLE)
+
+	iterator, err := GetCommitsIterator(taskCtx)
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs:    *rawDataSubTaskArgs,
+		ApiClient:             data.ApiClient,
+		PageSize:              100,
+		GetNextPageCustomData: GetNextPageCustomData,
+		Query:                 GetQueryForNextPage,
+		Input:                 iterator,
+		UrlTemplate:           "rest/build-status/1.0/commits/{{ .Input.CommitSha }}",
+		ResponseParser:        GetRawMessageFromResponse,
+		AfterResponse:         ignoreHTTPStatus404,
+	})
+	if err != nil {
+		return err
+	}
+
+	return collector.Execute()
+}
+
+// GetCommitsIterator returns the distinct commits of the repo's pull requests, including the merge commits
+// which are usually the ones being deployed
+func GetCommitsIterator(taskCtx plugin.SubTaskContext) (*helper.QueueIterator, errors.Error) {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*BitbucketServerTaskData)
+
+	var prCommitShas []string
+	err := db.Pluck("DISTINCT commit_sha", &prCommitShas,
+		dal.From(&models.BitbucketServerPrCommit{}),
+		dal.Where("connection_id = ? AND repo_id = ?", data.Options.ConnectionId, data.Options.FullName),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var mergeCommitShas []string
+	err = db.Pluck("DISTINCT merge_commit_sha", &mergeCommitShas,
+		dal.From(&models.BitbucketServerPullRequest{}),
+		dal.Where("connection_id = ? AND repo_id = ? AND merge_commit_sha != ''", data.Options.ConnectionId, data.Options.FullName),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator := helper.NewQueueIterator()
+	seen := make(map[string]bool)
+	for _, sha := range append(prCommitShas, mergeCommitShas...) {
+		if sha == "" || seen[sha] {
+			continue
+		}
+		seen[sha] = true
+		iterator.Push(&BitbucketServerCommitInput{CommitSha: sha})
+	}
+	return iterator, nil
+}
@@ -0,0 +1,151 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/bitbucket_server/models"
+)
+
+var ConvertBuildsMeta = plugin.SubTaskMeta{
+	Name:             "convertBuilds",
+	EntryPoint:       ConvertBuilds,
+	EnabledByDefault: true,
+	Description:      "Convert tool layer table bitbucket_server_builds into domain layer tables cicd_pipelines, cicd_tasks and cicd_pipeline_commits",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD},
+	DependencyTables: []string{models.BitbucketServerBuild{}.TableName()},
+	ProductTables: []string{
+		devops.CICDPipeline{}.TableName(),
+		devops.CICDTask{}.TableName(),
+		devops.CiCDPipelineCommit{}.TableName(),
+	},
+}
+
+func ConvertBuilds(taskCtx plugin.SubTaskContext) errors.Error {
+	rawDataSubTaskArgs, data := CreateRawDataSubTaskArgs(taskCtx, RAW_BUILD_TABLE)
+	db := taskCtx.GetDal()
+
+	repo := &models.BitbucketServerRepo{}
+	err := db.First(repo, dal.Where("connection_id = ? AND bitbucket_id = ?", data.Options.ConnectionId, data.Options.FullName))
+	if err != nil {
+		return err
+	}
+	repoId := didgen.NewDomainIdGenerator(&models.BitbucketServerRepo{}).Generate(repo.ConnectionId, repo.BitbucketId)
+
+	cursor, err := db.Cursor(
+		dal.From(&models.BitbucketServerBuild{}),
+		dal.Where("connection_id = ? AND repo_id = ?", data.Options.ConnectionId, data.Options.FullName),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	buildIdGen := didgen.NewDomainIdGenerator(&models.BitbucketServerBuild{})
+	resultRule := &devops.ResultRule{
+		Success: []string{models.BUILD_SUCCESSFUL},
+		Failure: []string{models.BUILD_FAILED, models.BUILD_CANCELLED},
+		Default: devops.RESULT_DEFAULT,
+	}
+	statusRule := &devops.StatusRule{
+		Done:       []string{models.BUILD_SUCCESSFUL, models.BUILD_FAILED, models.BUILD_CANCELLED},
+		InProgress: []string{models.BUILD_INPROGRESS},
+		Default:    devops.STATUS_OTHER,
+	}
+
+	converter, err := helper.NewDataConverter(helper.DataConverterArgs{
+		RawDataSubTaskArgs: *rawDataSubTaskArgs,
+		InputRowType:       reflect.TypeOf(models.BitbucketServerBuild{}),
+		Input:              cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			build := inputRow.(*models.BitbucketServerBuild)
+			// a build status has no stages, so it becomes a pipeline with a single task
+			id := buildIdGen.Generate(build.ConnectionId, build.RepoId, build.CommitSha, build.BuildKey)
+			displayTitle := build.Name
+			if build.BuildNumber != "" {
+				displayTitle = build.Name + " #" + build.BuildNumber
+			}
+			status := devops.GetStatus(statusRule, build.State)
+			startedDate := build.BitbucketServerCreatedAt
+			datesInfo := devops.TaskDatesInfo{
+				CreatedDate: build.BitbucketServerCreatedAt,
+				StartedDate: &startedDate,
+			}
+			if status == devops.STATUS_DONE {
+				datesInfo.FinishedDate = build.BitbucketServerUpdatedAt
+			}
+
+			pipeline := &devops.CICDPipeline{
+				DomainEntity: domainlayer.DomainEntity{
+					Id: id,
+				},
+				Name:           build.Name,
+				DisplayTitle:   displayTitle,
+				Url:            build.Url,
+				Result:         devops.GetResult(resultRule, build.State),
+				Status:         status,
+				OriginalResult: build.State,
+				OriginalStatus: build.State,
+				Type:           build.Type,
+				Environment:    build.Environment,
+				DurationSec:    build.DurationSec,
+				TaskDatesInfo:  datesInfo,
+				CicdScopeId:    repoId,
+			}
+			task := &devops.CICDTask{
+				DomainEntity: domainlayer.DomainEntity{
+					Id: id,
+				},
+				Name:           build.Name,
+				PipelineId:     id,
+				Result:         pipeline.Result,
+				Status:         pipeline.Status,
+				OriginalResult: build.State,
+				OriginalStatus: build.State,
+				Type:           build.Type,
+				Environment:    build.Environment,
+				DurationSec:    build.DurationSec,
+				TaskDatesInfo:  datesInfo,
+				CicdScopeId:    repoId,
+			}
+			pipelineCommit := &devops.CiCDPipelineCommit{
+				PipelineId:   id,
+				CommitSha:    build.CommitSha,
+				Branch:       build.Ref,
+				RepoId:       repoId,
+				RepoUrl:      repo.HTMLUrl,
+				DisplayTitle: displayTitle,
+				Url:          build.Url,
+			}
+			return []interface{}{pipeline, task, pipelineCommit}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
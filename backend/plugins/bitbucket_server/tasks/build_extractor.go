@@ -0,0 +1,117 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/bitbucket_server/models"
+)
+
+var ExtractApiBuildsMeta = plugin.SubTaskMeta{
+	Name:             "extractApiBuilds",
+	EntryPoint:       ExtractApiBuilds,
+	EnabledByDefault: true,
+	Description:      "Extract raw build statuses into tool layer table bitbucket_server_builds",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD},
+	DependencyTables: []string{RAW_BUILD_TABLE},
+	ProductTables:    []string{models.BitbucketServerBuild{}.TableName()},
+}
+
+// ApiBuildResponse covers both the legacy build status and the newer build fields (buildNumber, duration, ref...)
+// added by Bitbucket Data Center 7.4+
+type ApiBuildResponse struct {
+	State       string `json:"state"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Url         string `json:"url"`
+	Description string `json:"description"`
+	DateAdded   int64  `json:"dateAdded"`
+	BuildNumber string `json:"buildNumber"`
+	Ref         string `json:"ref"`
+	Duration    int64  `json:"duration"`
+	CreatedDate int64  `json:"createdDate"`
+	UpdatedDate int64  `json:"updatedDate"`
+}
+
+func ExtractApiBuilds(taskCtx plugin.SubTaskContext) errors.Error {
+	rawDataSubTaskArgs, data := CreateRawDataSubTaskArgs(taskCtx, RAW_BUILD_TABLE)
+
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: *rawDataSubTaskArgs,
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			apiBuild := &ApiBuildResponse{}
+			err := errors.Convert(json.Unmarshal(row.Data, apiBuild))
+			if err != nil {
+				return nil, err
+			}
+			input := &BitbucketServerCommitInput{}
+			err = errors.Convert(json.Unmarshal(row.Input, input))
+			if err != nil {
+				return nil, err
+			}
+
+			name := apiBuild.Name
+			if name == "" {
+				name = apiBuild.Key
+			}
+			build := &models.BitbucketServerBuild{
+				ConnectionId: data.Options.ConnectionId,
+				RepoId:       data.Options.FullName,
+				CommitSha:    input.CommitSha,
+				BuildKey:     apiBuild.Key,
+				Name:         name,
+				Url:          apiBuild.Url,
+				Description:  apiBuild.Description,
+				State:        apiBuild.State,
+				BuildNumber:  apiBuild.BuildNumber,
+				Ref:          strings.TrimPrefix(apiBuild.Ref, "refs/heads/"),
+				Type:         data.RegexEnricher.ReturnNameIfMatched(devops.DEPLOYMENT, apiBuild.Key, name),
+				Environment:  data.RegexEnricher.ReturnNameIfOmittedOrMatched(devops.PRODUCTION, apiBuild.Key, name),
+			}
+			// the legacy api only tells when the status was last posted
+			updatedAt := time.UnixMilli(apiBuild.DateAdded)
+			if apiBuild.UpdatedDate > 0 {
+				updatedAt = time.UnixMilli(apiBuild.UpdatedDate)
+			}
+			build.BitbucketServerUpdatedAt = &updatedAt
+			build.BitbucketServerCreatedAt = updatedAt
+			if apiBuild.CreatedDate > 0 {
+				build.BitbucketServerCreatedAt = time.UnixMilli(apiBuild.CreatedDate)
+			}
+			if apiBuild.Duration > 0 {
+				build.DurationSec = float64(apiBuild.Duration) / 1000
+			} else if build.State != models.BUILD_INPROGRESS {
+				build.DurationSec = updatedAt.Sub(build.BitbucketServerCreatedAt).Seconds()
+			}
+
+			return []interface{}{build}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return extractor.Execute()
+}
@@ -33,7 +33,7 @@ var CollectApiPullRequestsMeta = plugin.SubTaskMeta{
 	EnabledByDefault: true,
 	Required:         false,
 	Description:      "Collect PullRequests data from Bitbucket Server api",
-	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW},
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW, plugin.DOMAIN_TYPE_CICD},
 	ProductTables:    []string{RAW_PULL_REQUEST_TABLE},
 }
 
@@ -30,7 +30,7 @@ var CollectApiPrCommitsMeta = plugin.SubTaskMeta{
 	EntryPoint:       CollectApiPullRequestCommits,
 	EnabledByDefault: true,
 	Description:      "Collect PullRequestCommits data from Bitbucket Server api",
-	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW},
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW, plugin.DOMAIN_TYPE_CICD},
 	ProductTables:    []string{RAW_PULL_REQUEST_COMMITS_TABLE},
 }
 
@@ -36,7 +36,7 @@ var ExtractApiPullRequestsMeta = plugin.SubTaskMeta{
 	EnabledByDefault: true,
 	Required:         false,
 	Description:      "Extract raw PullRequests data into tool layer table bitbucket_pull_requests",
-	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW},
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CODE_REVIEW, plugin.DOMAIN_TYPE_CICD},
 }
 
 type ApiPrResponse struct {
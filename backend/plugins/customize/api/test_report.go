@@ -0,0 +1,77 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/customize/service"
+)
+
+// ImportTestReport accepts a JUnit, xUnit.net v2 or TRX test report and saves its results to the qa domain
+// @Summary      Upload a test report
+// @Description  Upload a JUnit XML, xUnit.net v2 XML or TRX test report, results are saved into qa_test_cases and qa_test_case_executions.
+// @Description  The executions are linked to the given CI/CD pipeline, or to the latest pipeline of the given commit.
+// @Tags 		 plugins/customize
+// @Accept       multipart/form-data
+// @Param        qaProjectId formData string true "the ID of the QA project"
+// @Param        qaProjectName formData string false "the name of the QA project, defaults to qaProjectId"
+// @Param        format formData string false "junit, xunit or trx, detected from the report when omitted"
+// @Param        commitSha formData string false "the commit under test"
+// @Param        cicdPipelineId formData string false "the ID of the cicd_pipeline that ran the tests"
+// @Param        file formData file true "select file to upload"
+// @Produce      json
+// @Success      200  {object} service.TestReportSummary
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/customize/testreports [post]
+func (h *Handlers) ImportTestReport(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	file, err := h.extractFile(input)
+	if err != nil {
+		return nil, err
+	}
+	// nolint
+	defer file.Close()
+
+	reportInput := &service.TestReportInput{
+		QaProjectId:    strings.TrimSpace(input.Request.FormValue("qaProjectId")),
+		QaProjectName:  strings.TrimSpace(input.Request.FormValue("qaProjectName")),
+		Format:         strings.ToLower(strings.TrimSpace(input.Request.FormValue("format"))),
+		CommitSha:      strings.TrimSpace(input.Request.FormValue("commitSha")),
+		CicdPipelineId: strings.TrimSpace(input.Request.FormValue("cicdPipelineId")),
+	}
+	if reportInput.QaProjectId == "" {
+		return nil, errors.BadInput.New("empty qaProjectId")
+	}
+	switch reportInput.Format {
+	case "", service.TEST_REPORT_JUNIT, service.TEST_REPORT_XUNIT, service.TEST_REPORT_TRX:
+	default:
+		return nil, errors.BadInput.New("format should be one of junit, xunit and trx")
+	}
+	if headers := input.Request.MultipartForm.File["file"]; len(headers) > 0 {
+		reportInput.Name = headers[0].Filename
+	}
+	summary, err := h.svc.ImportTestReport(reportInput, file)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: summary, Status: http.StatusOK}, nil
+}
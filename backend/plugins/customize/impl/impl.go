@@ -50,12 +50,15 @@ func (p Customize) Init(basicRes context.BasicRes) errors.Error {
 func (p Customize) GetTablesInfo() []dal.Tabler {
 	return []dal.Tabler{
 		&models.CustomizedField{},
+		&models.TestReport{},
 	}
 }
 
 func (p Customize) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.ExtractCustomizedFieldsMeta,
+		tasks.ConvertTestReportsMeta,
+		tasks.DetectFlakyTestsMeta,
 	}
 }
 
@@ -126,5 +129,8 @@ func (p Customize) ApiResources() map[string]map[string]plugin.ApiResourceHandle
 		"csvfiles/qa_test_case_executions.csv": {
 			"POST": handlers.ImportQaTestCaseExecutions,
 		},
+		"testreports": {
+			"POST": handlers.ImportTestReport,
+		},
 	}
 }
@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// TestReport keeps an uploaded JUnit/xUnit/TRX report so it can be converted again, e.g. after the
+// cicd pipelines of its commit have been collected
+type TestReport struct {
+	common.Model
+	QaProjectId    string `gorm:"type:varchar(255);index"`
+	Name           string `gorm:"type:varchar(255)"`
+	Format         string `gorm:"type:varchar(20)"`
+	CommitSha      string `gorm:"type:varchar(40)"`
+	CicdPipelineId string `gorm:"type:varchar(255)"`
+	ReportedAt     time.Time
+	// Content is the gzipped report
+	Content []byte `json:"-"`
+}
+
+func (TestReport) TableName() string {
+	return "_tool_customize_test_reports"
+}
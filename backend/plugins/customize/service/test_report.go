@@ -0,0 +1,456 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/qa"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	customizeModels "github.com/apache/incubator-devlake/plugins/customize/models"
+)
+
+const (
+	// flakyWindow is the number of latest executions of a test case considered by the flaky detection
+	flakyWindow = 20
+	// flakyFlips is the number of status changes within the window that marks a test case as flaky
+	flakyFlips = 2
+	// testReportBatchSize is the number of rows loaded or saved by a statement
+	testReportBatchSize = 500
+)
+
+// TestReportInput describes an uploaded test report
+type TestReportInput struct {
+	QaProjectId    string
+	QaProjectName  string
+	Name           string
+	Format         string
+	CommitSha      string
+	CicdPipelineId string
+}
+
+// TestReportSummary is returned after a test report was imported
+type TestReportSummary struct {
+	ReportId       uint64 `json:"reportId"`
+	Format         string `json:"format"`
+	CicdPipelineId string `json:"cicdPipelineId"`
+	CommitSha      string `json:"commitSha"`
+	Total          int    `json:"total"`
+	Passed         int    `json:"passed"`
+	Failed         int    `json:"failed"`
+	Skipped        int    `json:"skipped"`
+	FlakyTests     int    `json:"flakyTests"`
+}
+
+// ImportTestReport saves a JUnit/xUnit/TRX report, converts it into `qa_test_cases` and
+// `qa_test_case_executions` and refreshes the flaky flags of the qa project
+func (s *Service) ImportTestReport(input *TestReportInput, file io.Reader) (*TestReportSummary, errors.Error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to read test report")
+	}
+	if input.Format == "" {
+		input.Format, err = DetectTestReportFormat(content)
+		if err != nil {
+			return nil, errors.Convert(err)
+		}
+	}
+	// parse before saving anything so malformed reports are rejected
+	if _, err = ParseTestReport(input.Format, content); err != nil {
+		return nil, errors.Convert(err)
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err = writer.Write(content); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to compress test report")
+	}
+	if err = writer.Close(); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to compress test report")
+	}
+	if input.QaProjectName == "" {
+		input.QaProjectName = input.QaProjectId
+	}
+	err = s.dal.CreateOrUpdate(&qa.QaProject{
+		DomainEntityExtended: domainlayer.DomainEntityExtended{
+			Id: input.QaProjectId,
+		},
+		Name: input.QaProjectName,
+	})
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	report := &customizeModels.TestReport{
+		QaProjectId:    input.QaProjectId,
+		Name:           input.Name,
+		Format:         input.Format,
+		CommitSha:      input.CommitSha,
+		CicdPipelineId: input.CicdPipelineId,
+		ReportedAt:     time.Now(),
+		Content:        compressed.Bytes(),
+	}
+	err = s.dal.Create(report)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to save test report")
+	}
+	summary, err := s.convertTestReport(report)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	summary.FlakyTests, err = s.DetectFlakyTests(input.QaProjectId)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	return summary, nil
+}
+
+// ConvertTestReports converts all the stored reports of the qa project again, so that reports
+// uploaded before their cicd pipelines were collected get linked
+func (s *Service) ConvertTestReports(qaProjectId string) errors.Error {
+	cursor, err := s.dal.Cursor(
+		dal.From(&customizeModels.TestReport{}),
+		dal.Where("qa_project_id = ?", qaProjectId),
+		dal.Orderby("id"),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	for cursor.Next() {
+		report := &customizeModels.TestReport{}
+		err = s.dal.Fetch(cursor, report)
+		if err != nil {
+			return err
+		}
+		_, err = s.convertTestReport(report)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertTestReport maps the results of a stored report into the qa domain
+func (s *Service) convertTestReport(report *customizeModels.TestReport) (*TestReportSummary, errors.Error) {
+	content, err := gunzip(report.Content)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to decompress test report %d", report.ID))
+	}
+	results, err := ParseTestReport(report.Format, content)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	pipelineId, commitSha, err := s.findTestReportPipeline(report)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	origin := common.RawDataOrigin{
+		RawDataTable:  report.TableName(),
+		RawDataParams: report.QaProjectId,
+		RawDataId:     report.ID,
+	}
+	summary := &TestReportSummary{
+		ReportId:       report.ID,
+		Format:         report.Format,
+		CicdPipelineId: pipelineId,
+		CommitSha:      commitSha,
+		Total:          len(results),
+	}
+	// a test reported more than once is saved once, the last result wins
+	testCases := make(map[string]*qa.QaTestCase)
+	testCaseIds := make([]string, 0, len(results))
+	executions := make(map[string]*qa.QaTestCaseExecution)
+	executionIds := make([]string, 0, len(results))
+	addExecution := func(execution *qa.QaTestCaseExecution) {
+		if _, ok := executions[execution.Id]; !ok {
+			executionIds = append(executionIds, execution.Id)
+		}
+		executions[execution.Id] = execution
+	}
+	for _, result := range results {
+		testCase := &qa.QaTestCase{
+			DomainEntityExtended: domainlayer.DomainEntityExtended{
+				Id:        testCaseId(report.QaProjectId, result.FullName()),
+				NoPKModel: common.NoPKModel{RawDataOrigin: origin},
+			},
+			Name:        truncate(result.FullName(), 255),
+			CreateTime:  report.ReportedAt,
+			Type:        "functional",
+			QaProjectId: report.QaProjectId,
+		}
+		if _, ok := testCases[testCase.Id]; !ok {
+			testCaseIds = append(testCaseIds, testCase.Id)
+		}
+		testCases[testCase.Id] = testCase
+		if result.Status == TEST_RESULT_SKIPPED {
+			summary.Skipped++
+			continue
+		}
+		if result.Status == TEST_RESULT_SUCCESS {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+		startTime := report.ReportedAt
+		if result.StartTime != nil {
+			startTime = *result.StartTime
+		}
+		execution := &qa.QaTestCaseExecution{
+			DomainEntityExtended: domainlayer.DomainEntityExtended{
+				Id:        fmt.Sprintf("%s:%d", testCase.Id, report.ID),
+				NoPKModel: common.NoPKModel{RawDataOrigin: origin},
+			},
+			QaProjectId:    report.QaProjectId,
+			QaTestCaseId:   testCase.Id,
+			CreateTime:     report.ReportedAt,
+			StartTime:      startTime,
+			FinishTime:     startTime.Add(time.Duration(result.DurationSec * float64(time.Second))),
+			Status:         result.Status,
+			CicdPipelineId: pipelineId,
+			CommitSha:      commitSha,
+		}
+		// failed attempts of a test that passed on rerun are recorded as separate executions
+		for i := 1; i <= result.FlakyFailures; i++ {
+			rerun := *execution
+			rerun.Id = fmt.Sprintf("%s:rerun%d", execution.Id, i)
+			rerun.Status = TEST_RESULT_FAILED
+			rerun.FinishTime = rerun.StartTime
+			addExecution(&rerun)
+		}
+		addExecution(execution)
+	}
+	// keep the time the test cases were first seen and their flaky flags
+	for start := 0; start < len(testCaseIds); start += testReportBatchSize {
+		end := start + testReportBatchSize
+		if end > len(testCaseIds) {
+			end = len(testCaseIds)
+		}
+		var existing []*qa.QaTestCase
+		err = s.dal.All(&existing, dal.Where("id IN ?", testCaseIds[start:end]))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to load qa_test_cases")
+		}
+		for _, e := range existing {
+			testCases[e.Id].CreateTime = e.CreateTime
+			testCases[e.Id].IsFlaky = e.IsFlaky
+		}
+	}
+
+	// the report is converted as a whole, a failure leaves the previous conversion in place
+	tx := s.dal.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logruslog.Global.Error(rollbackErr, "failed to rollback the conversion of test report %d", report.ID)
+			}
+			if r != nil {
+				panic(r)
+			}
+		}
+	}()
+	// executions are recreated on every conversion
+	err = tx.Delete(&qa.QaTestCaseExecution{}, dal.Where("_raw_data_table = ? AND _raw_data_id = ?", origin.RawDataTable, origin.RawDataId))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to delete old qa_test_case_executions of test report %d", report.ID))
+	}
+	err = saveInBatches(tx, testCaseIds, testCases)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to save qa_test_cases")
+	}
+	err = saveInBatches(tx, executionIds, executions)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to save qa_test_case_executions")
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to commit the conversion of test report")
+	}
+	return summary, nil
+}
+
+// saveInBatches saves the rows in the order of their ids with one statement per batch
+func saveInBatches[T any](tx dal.Transaction, ids []string, rows map[string]*T) errors.Error {
+	for start := 0; start < len(ids); start += testReportBatchSize {
+		end := start + testReportBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := make([]*T, 0, end-start)
+		for _, id := range ids[start:end] {
+			batch = append(batch, rows[id])
+		}
+		if err := tx.CreateOrUpdate(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findTestReportPipeline links a report to the given cicd pipeline, or to the latest pipeline of its commit
+func (s *Service) findTestReportPipeline(report *customizeModels.TestReport) (string, string, errors.Error) {
+	pipelineCommit := &devops.CiCDPipelineCommit{}
+	if report.CicdPipelineId != "" {
+		if report.CommitSha != "" {
+			return report.CicdPipelineId, report.CommitSha, nil
+		}
+		err := s.dal.First(pipelineCommit, dal.Where("pipeline_id = ?", report.CicdPipelineId))
+		if err != nil && !s.dal.IsErrorNotFound(err) {
+			return "", "", errors.Default.Wrap(err, "failed to load cicd_pipeline_commit")
+		}
+		return report.CicdPipelineId, pipelineCommit.CommitSha, nil
+	}
+	if report.CommitSha == "" {
+		return "", "", nil
+	}
+	err := s.dal.First(pipelineCommit,
+		dal.Select("pc.*"),
+		dal.From("cicd_pipeline_commits pc"),
+		dal.Join("LEFT JOIN cicd_pipelines p ON p.id = pc.pipeline_id"),
+		dal.Where("pc.commit_sha = ?", report.CommitSha),
+		dal.Orderby("p.finished_date DESC"),
+	)
+	if err != nil {
+		if s.dal.IsErrorNotFound(err) {
+			return "", report.CommitSha, nil
+		}
+		return "", "", errors.Default.Wrap(err, "failed to load cicd_pipeline_commit")
+	}
+	return pipelineCommit.PipelineId, report.CommitSha, nil
+}
+
+// flakyExecution is the part of a qa_test_case_execution needed to detect flaky tests
+type flakyExecution struct {
+	QaTestCaseId   string
+	Status         string
+	CommitSha      string
+	CicdPipelineId string
+	CreateTime     time.Time
+}
+
+// DetectFlakyTests updates `qa_test_cases.is_flaky` of the qa project and returns the number of flaky tests
+func (s *Service) DetectFlakyTests(qaProjectId string) (int, errors.Error) {
+	cursor, err := s.dal.Cursor(
+		dal.Select("qa_test_case_id, status, commit_sha, cicd_pipeline_id, create_time"),
+		dal.From(&qa.QaTestCaseExecution{}),
+		dal.Where("qa_project_id = ? AND status IN ?", qaProjectId, []string{TEST_RESULT_SUCCESS, TEST_RESULT_FAILED}),
+		dal.Orderby("qa_test_case_id, start_time DESC, id"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close()
+	var flakyIds []string
+	var executions []flakyExecution
+	flush := func() {
+		if len(executions) > 0 && isFlaky(executions) {
+			flakyIds = append(flakyIds, executions[0].QaTestCaseId)
+		}
+		executions = executions[:0]
+	}
+	for cursor.Next() {
+		execution := flakyExecution{}
+		err = s.dal.Fetch(cursor, &execution)
+		if err != nil {
+			return 0, err
+		}
+		if len(executions) > 0 && executions[0].QaTestCaseId != execution.QaTestCaseId {
+			flush()
+		}
+		if len(executions) < flakyWindow {
+			executions = append(executions, execution)
+		}
+	}
+	flush()
+	err = s.dal.UpdateColumn(&qa.QaTestCase{}, "is_flaky", false, dal.Where("qa_project_id = ? AND is_flaky = ?", qaProjectId, true))
+	if err != nil {
+		return 0, err
+	}
+	for start := 0; start < len(flakyIds); start += 500 {
+		end := start + 500
+		if end > len(flakyIds) {
+			end = len(flakyIds)
+		}
+		err = s.dal.UpdateColumn(&qa.QaTestCase{}, "is_flaky", true, dal.Where("id IN ?", flakyIds[start:end]))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(flakyIds), nil
+}
+
+// isFlaky tells whether the executions (latest first) of a test case both passed and failed on the
+// same code, or keep flipping between passing and failing
+func isFlaky(executions []flakyExecution) bool {
+	statuses := make(map[string]string)
+	flips := 0
+	for i, execution := range executions {
+		key := execution.CommitSha
+		if key == "" {
+			key = execution.CicdPipelineId
+		}
+		if key == "" {
+			key = execution.CreateTime.String()
+		}
+		if status, ok := statuses[key]; ok && status != execution.Status {
+			return true
+		}
+		statuses[key] = execution.Status
+		if i > 0 && executions[i-1].Status != execution.Status {
+			flips++
+		}
+	}
+	return flips >= flakyFlips
+}
+
+func testCaseId(qaProjectId, fullName string) string {
+	id := fmt.Sprintf("%s:%s", qaProjectId, fullName)
+	if len(id) <= 450 {
+		return id
+	}
+	sum := sha1.Sum([]byte(fullName))
+	return fmt.Sprintf("%s:%s", qaProjectId, hex.EncodeToString(sum[:]))
+}
+
+// truncate keeps the first max characters of s, as varchar columns count characters rather than bytes
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+func gunzip(content []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	// nolint
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
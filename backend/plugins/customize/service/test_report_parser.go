@@ -0,0 +1,354 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// supported test report formats
+const (
+	TEST_REPORT_JUNIT = "junit"
+	TEST_REPORT_XUNIT = "xunit"
+	TEST_REPORT_TRX   = "trx"
+)
+
+// statuses of a parsed test result, skipped tests don't produce executions
+const (
+	TEST_RESULT_SUCCESS = "SUCCESS"
+	TEST_RESULT_FAILED  = "FAILED"
+	TEST_RESULT_SKIPPED = "SKIPPED"
+)
+
+// TestResult is the outcome of a single test case in a report
+type TestResult struct {
+	ClassName   string
+	Name        string
+	Status      string
+	Message     string
+	StartTime   *time.Time
+	DurationSec float64
+	// FlakyFailures counts the failed attempts of a test that passed on rerun (surefire flakyFailure/rerunFailure)
+	FlakyFailures int
+}
+
+// FullName returns the name identifying the test case across reports
+func (r *TestResult) FullName() string {
+	if r.ClassName == "" {
+		return r.Name
+	}
+	if strings.HasPrefix(r.Name, r.ClassName+".") {
+		return r.Name
+	}
+	return r.ClassName + "." + r.Name
+}
+
+// DetectTestReportFormat tells the format of a report by its root element
+func DetectTestReportFormat(content []byte) (string, errors.Error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.BadInput.New("empty test report")
+		}
+		if err != nil {
+			return "", errors.BadInput.Wrap(err, "test report is not a valid xml")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "testsuites", "testsuite":
+				return TEST_REPORT_JUNIT, nil
+			case "assemblies", "assembly":
+				return TEST_REPORT_XUNIT, nil
+			case "TestRun":
+				return TEST_REPORT_TRX, nil
+			default:
+				return "", errors.BadInput.New(fmt.Sprintf("unsupported test report root element <%s>", start.Name.Local))
+			}
+		}
+	}
+}
+
+// ParseTestReport parses a JUnit, xUnit.net v2 or TRX report, the format is detected when empty
+func ParseTestReport(format string, content []byte) ([]TestResult, errors.Error) {
+	var err errors.Error
+	if format == "" {
+		format, err = DetectTestReportFormat(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case TEST_REPORT_JUNIT:
+		return parseJunitReport(content)
+	case TEST_REPORT_XUNIT:
+		return parseXunitReport(content)
+	case TEST_REPORT_TRX:
+		return parseTrxReport(content)
+	}
+	return nil, errors.BadInput.New(fmt.Sprintf("unsupported test report format %s", format))
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (m *junitMessage) String() string {
+	if m.Message != "" {
+		return m.Message
+	}
+	return strings.TrimSpace(m.Text)
+}
+
+type junitTestCase struct {
+	Name          string         `xml:"name,attr"`
+	ClassName     string         `xml:"classname,attr"`
+	Time          string         `xml:"time,attr"`
+	Failure       *junitMessage  `xml:"failure"`
+	Error         *junitMessage  `xml:"error"`
+	Skipped       *junitMessage  `xml:"skipped"`
+	FlakyFailures []junitMessage `xml:"flakyFailure"`
+	FlakyErrors   []junitMessage `xml:"flakyError"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+	Cases     []junitTestCase  `xml:"testcase"`
+}
+
+func parseJunitReport(content []byte) ([]TestResult, errors.Error) {
+	// the root element is either <testsuites> or a single <testsuite>
+	var root junitTestSuite
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse junit report")
+	}
+	var results []TestResult
+	var walk func(suite *junitTestSuite, startTime *time.Time)
+	walk = func(suite *junitTestSuite, startTime *time.Time) {
+		if t := parseReportTime(suite.Timestamp); t != nil {
+			startTime = t
+		}
+		for _, c := range suite.Cases {
+			result := TestResult{
+				ClassName:     c.ClassName,
+				Name:          c.Name,
+				Status:        TEST_RESULT_SUCCESS,
+				StartTime:     startTime,
+				DurationSec:   parseSeconds(c.Time),
+				FlakyFailures: len(c.FlakyFailures) + len(c.FlakyErrors),
+			}
+			if result.ClassName == "" {
+				result.ClassName = suite.Name
+			}
+			switch {
+			case c.Failure != nil:
+				result.Status = TEST_RESULT_FAILED
+				result.Message = c.Failure.String()
+			case c.Error != nil:
+				result.Status = TEST_RESULT_FAILED
+				result.Message = c.Error.String()
+			case c.Skipped != nil:
+				result.Status = TEST_RESULT_SKIPPED
+				result.Message = c.Skipped.String()
+			}
+			results = append(results, result)
+		}
+		for i := range suite.Suites {
+			walk(&suite.Suites[i], startTime)
+		}
+	}
+	walk(&root, nil)
+	return results, nil
+}
+
+type xunitReport struct {
+	Assemblies []xunitAssembly `xml:"assembly"`
+	// a report may also be a single <assembly>
+	xunitAssembly
+}
+
+type xunitAssembly struct {
+	RunDate     string `xml:"run-date,attr"`
+	RunTime     string `xml:"run-time,attr"`
+	Collections []struct {
+		Tests []xunitTest `xml:"test"`
+	} `xml:"collection"`
+}
+
+type xunitTest struct {
+	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr"`
+	Method  string `xml:"method,attr"`
+	Time    string `xml:"time,attr"`
+	Result  string `xml:"result,attr"`
+	Failure *struct {
+		Message string `xml:"message"`
+	} `xml:"failure"`
+	Reason string `xml:"reason"`
+}
+
+func parseXunitReport(content []byte) ([]TestResult, errors.Error) {
+	var report xunitReport
+	if err := xml.Unmarshal(content, &report); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse xunit report")
+	}
+	assemblies := append(report.Assemblies, report.xunitAssembly)
+	var results []TestResult
+	for _, assembly := range assemblies {
+		startTime := parseReportTime(strings.TrimSpace(assembly.RunDate + "T" + assembly.RunTime))
+		for _, collection := range assembly.Collections {
+			for _, test := range collection.Tests {
+				result := TestResult{
+					ClassName:   test.Type,
+					Name:        test.Method,
+					StartTime:   startTime,
+					DurationSec: parseSeconds(test.Time),
+				}
+				if result.Name == "" {
+					result.Name = test.Name
+				}
+				switch strings.ToLower(test.Result) {
+				case "pass":
+					result.Status = TEST_RESULT_SUCCESS
+				case "fail":
+					result.Status = TEST_RESULT_FAILED
+					if test.Failure != nil {
+						result.Message = strings.TrimSpace(test.Failure.Message)
+					}
+				default:
+					result.Status = TEST_RESULT_SKIPPED
+					result.Message = strings.TrimSpace(test.Reason)
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+type trxReport struct {
+	Results []struct {
+		TestId    string `xml:"testId,attr"`
+		TestName  string `xml:"testName,attr"`
+		Outcome   string `xml:"outcome,attr"`
+		Duration  string `xml:"duration,attr"`
+		StartTime string `xml:"startTime,attr"`
+		Message   string `xml:"Output>ErrorInfo>Message"`
+	} `xml:"Results>UnitTestResult"`
+	Definitions []struct {
+		Id     string `xml:"id,attr"`
+		Method struct {
+			ClassName string `xml:"className,attr"`
+			Name      string `xml:"name,attr"`
+		} `xml:"TestMethod"`
+	} `xml:"TestDefinitions>UnitTest"`
+}
+
+func parseTrxReport(content []byte) ([]TestResult, errors.Error) {
+	var report trxReport
+	if err := xml.Unmarshal(content, &report); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse trx report")
+	}
+	classNames := make(map[string]string, len(report.Definitions))
+	for _, definition := range report.Definitions {
+		classNames[definition.Id] = definition.Method.ClassName
+	}
+	results := make([]TestResult, 0, len(report.Results))
+	for _, r := range report.Results {
+		result := TestResult{
+			ClassName: classNames[r.TestId],
+			Name:      r.TestName,
+			StartTime: parseReportTime(r.StartTime),
+			Message:   strings.TrimSpace(r.Message),
+		}
+		if d, err := parseTrxDuration(r.Duration); err == nil {
+			result.DurationSec = d.Seconds()
+		}
+		switch r.Outcome {
+		case "Passed", "PassedButRunAborted", "Warning":
+			result.Status = TEST_RESULT_SUCCESS
+		case "Failed", "Error", "Timeout", "Aborted":
+			result.Status = TEST_RESULT_FAILED
+		default:
+			result.Status = TEST_RESULT_SKIPPED
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseSeconds parses durations like "1.234" or "1,234.5"
+func parseSeconds(s string) float64 {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
+
+// parseTrxDuration parses durations like "00:00:01.2345678"
+func parseTrxDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second)), nil
+}
+
+var reportTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+func parseReportTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "T" {
+		return nil
+	}
+	for _, layout := range reportTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
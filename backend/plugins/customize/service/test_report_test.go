@@ -0,0 +1,258 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/qa"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	customizeModels "github.com/apache/incubator-devlake/plugins/customize/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const junitReportXml = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="com.example.CalculatorTest" timestamp="2026-10-01T10:00:00" tests="4">
+    <testcase name="testAdd" classname="com.example.CalculatorTest" time="0.012"/>
+    <testcase name="testDivide" classname="com.example.CalculatorTest" time="1,234.5">
+      <failure message="expected 2 but was 3">stacktrace</failure>
+    </testcase>
+    <testcase name="testRetry" classname="com.example.CalculatorTest" time="0.5">
+      <flakyFailure message="timeout"/>
+    </testcase>
+    <testcase name="testSkipped" classname="com.example.CalculatorTest">
+      <skipped/>
+    </testcase>
+    <testsuite name="nested">
+      <testcase name="testNested" time="0.1">
+        <error message="NullPointerException"/>
+      </testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+const xunitReportXml = `<?xml version="1.0" encoding="utf-8"?>
+<assemblies>
+  <assembly name="Tests.dll" run-date="2026-10-01" run-time="10:00:00">
+    <collection name="Calculator">
+      <test name="Tests.CalculatorTest.Add" type="Tests.CalculatorTest" method="Add" time="0.01" result="Pass"/>
+      <test name="Tests.CalculatorTest.Divide" type="Tests.CalculatorTest" method="Divide" time="0.02" result="Fail">
+        <failure><message>divide by zero</message></failure>
+      </test>
+      <test name="Tests.CalculatorTest.Todo" type="Tests.CalculatorTest" method="Todo" time="0" result="Skip">
+        <reason>not implemented</reason>
+      </test>
+    </collection>
+  </assembly>
+</assemblies>`
+
+const trxReportXml = `<?xml version="1.0" encoding="UTF-8"?>
+<TestRun id="1" xmlns="http://microsoft.com/schemas/VisualStudio/TeamTest/2010">
+  <Results>
+    <UnitTestResult testId="a" testName="Add" outcome="Passed" duration="00:00:01.5000000" startTime="2026-10-01T10:00:00.0000000+00:00"/>
+    <UnitTestResult testId="b" testName="Divide" outcome="Failed" duration="00:01:00" startTime="2026-10-01T10:00:02.0000000+00:00">
+      <Output><ErrorInfo><Message>divide by zero</Message></ErrorInfo></Output>
+    </UnitTestResult>
+    <UnitTestResult testId="c" testName="Todo" outcome="NotExecuted"/>
+  </Results>
+  <TestDefinitions>
+    <UnitTest id="a"><TestMethod className="Tests.CalculatorTest" name="Add"/></UnitTest>
+    <UnitTest id="b"><TestMethod className="Tests.CalculatorTest" name="Divide"/></UnitTest>
+  </TestDefinitions>
+</TestRun>`
+
+func TestDetectTestReportFormat(t *testing.T) {
+	for content, want := range map[string]string{
+		junitReportXml:               TEST_REPORT_JUNIT,
+		`<testsuite name="single"/>`: TEST_REPORT_JUNIT,
+		xunitReportXml:               TEST_REPORT_XUNIT,
+		trxReportXml:                 TEST_REPORT_TRX,
+	} {
+		format, err := DetectTestReportFormat([]byte(content))
+		assert.Nil(t, err)
+		assert.Equal(t, want, format)
+	}
+	_, err := DetectTestReportFormat([]byte(`<html></html>`))
+	assert.NotNil(t, err)
+	_, err = DetectTestReportFormat([]byte(`not xml`))
+	assert.NotNil(t, err)
+}
+
+func TestParseJunitReport(t *testing.T) {
+	results, err := ParseTestReport("", []byte(junitReportXml))
+	assert.Nil(t, err)
+	assert.Len(t, results, 5)
+	startTime := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "com.example.CalculatorTest.testAdd", results[0].FullName())
+	assert.Equal(t, TEST_RESULT_SUCCESS, results[0].Status)
+	assert.Equal(t, 0.012, results[0].DurationSec)
+	assert.Equal(t, startTime, *results[0].StartTime)
+
+	assert.Equal(t, TEST_RESULT_FAILED, results[1].Status)
+	assert.Equal(t, "expected 2 but was 3", results[1].Message)
+	assert.Equal(t, 1234.5, results[1].DurationSec)
+
+	assert.Equal(t, TEST_RESULT_SUCCESS, results[2].Status)
+	assert.Equal(t, 1, results[2].FlakyFailures)
+
+	assert.Equal(t, TEST_RESULT_SKIPPED, results[3].Status)
+
+	assert.Equal(t, "nested.testNested", results[4].FullName())
+	assert.Equal(t, TEST_RESULT_FAILED, results[4].Status)
+	assert.Equal(t, "NullPointerException", results[4].Message)
+	assert.Equal(t, startTime, *results[4].StartTime)
+}
+
+func TestParseXunitReport(t *testing.T) {
+	results, err := ParseTestReport(TEST_REPORT_XUNIT, []byte(xunitReportXml))
+	assert.Nil(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "Tests.CalculatorTest.Add", results[0].FullName())
+	assert.Equal(t, TEST_RESULT_SUCCESS, results[0].Status)
+	assert.Equal(t, time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC), *results[0].StartTime)
+	assert.Equal(t, TEST_RESULT_FAILED, results[1].Status)
+	assert.Equal(t, "divide by zero", results[1].Message)
+	assert.Equal(t, TEST_RESULT_SKIPPED, results[2].Status)
+	assert.Equal(t, "not implemented", results[2].Message)
+}
+
+func TestParseTrxReport(t *testing.T) {
+	results, err := ParseTestReport(TEST_REPORT_TRX, []byte(trxReportXml))
+	assert.Nil(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "Tests.CalculatorTest.Add", results[0].FullName())
+	assert.Equal(t, TEST_RESULT_SUCCESS, results[0].Status)
+	assert.Equal(t, 1.5, results[0].DurationSec)
+	assert.Equal(t, TEST_RESULT_FAILED, results[1].Status)
+	assert.Equal(t, 60.0, results[1].DurationSec)
+	assert.Equal(t, "divide by zero", results[1].Message)
+	assert.Equal(t, "Todo", results[2].FullName())
+	assert.Equal(t, TEST_RESULT_SKIPPED, results[2].Status)
+	assert.Nil(t, results[2].StartTime)
+}
+
+func TestIsFlaky(t *testing.T) {
+	run := func(status, sha string) flakyExecution {
+		return flakyExecution{Status: status, CommitSha: sha}
+	}
+	tests := []struct {
+		name       string
+		executions []flakyExecution
+		want       bool
+	}{
+		{"always passing", []flakyExecution{run("SUCCESS", "a"), run("SUCCESS", "b")}, false},
+		{"fixed by a new commit", []flakyExecution{run("SUCCESS", "b"), run("FAILED", "a")}, false},
+		{"passed and failed on the same commit", []flakyExecution{run("SUCCESS", "a"), run("FAILED", "a")}, true},
+		{"keeps flipping", []flakyExecution{run("SUCCESS", "c"), run("FAILED", "b"), run("SUCCESS", "a")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isFlaky(tt.executions))
+		})
+	}
+}
+
+func storedJunitReport(t *testing.T) *customizeModels.TestReport {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(junitReportXml))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	report := &customizeModels.TestReport{
+		QaProjectId:    "qa1",
+		Format:         TEST_REPORT_JUNIT,
+		CommitSha:      "015e3d3b",
+		CicdPipelineId: "pipeline1",
+		ReportedAt:     time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
+		Content:        compressed.Bytes(),
+	}
+	report.ID = 7
+	return report
+}
+
+func TestConvertTestReport(t *testing.T) {
+	firstSeen := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	mockDal := new(mockdal.Dal)
+	mockDal.On("All", mock.AnythingOfType("*[]*qa.QaTestCase"), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]*qa.QaTestCase) = []*qa.QaTestCase{{
+			DomainEntityExtended: domainlayer.DomainEntityExtended{Id: "qa1:com.example.CalculatorTest.testAdd"},
+			CreateTime:           firstSeen,
+			IsFlaky:              true,
+		}}
+	}).Return(nil).Once()
+	mockTx := new(mockdal.Transaction)
+	mockDal.On("Begin").Return(mockTx).Once()
+	mockTx.On("Delete", mock.AnythingOfType("*qa.QaTestCaseExecution"), mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*qa.QaTestCase"), mock.Anything).Run(func(args mock.Arguments) {
+		testCases := args.Get(0).([]*qa.QaTestCase)
+		assert.Len(t, testCases, 5)
+		assert.Equal(t, firstSeen, testCases[0].CreateTime)
+		assert.True(t, testCases[0].IsFlaky)
+		assert.Equal(t, time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), testCases[1].CreateTime)
+	}).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*qa.QaTestCaseExecution"), mock.Anything).Run(func(args mock.Arguments) {
+		executions := args.Get(0).([]*qa.QaTestCaseExecution)
+		// 4 executed tests along with the failed attempt of the flaky one
+		assert.Len(t, executions, 5)
+		assert.Equal(t, "qa1:com.example.CalculatorTest.testRetry:7:rerun1", executions[2].Id)
+		assert.Equal(t, TEST_RESULT_FAILED, executions[2].Status)
+		assert.Equal(t, "pipeline1", executions[3].CicdPipelineId)
+	}).Return(nil).Once()
+	mockTx.On("Commit").Return(nil).Once()
+
+	summary, err := NewService(mockDal).convertTestReport(storedJunitReport(t))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, summary.Total)
+	assert.Equal(t, 2, summary.Passed)
+	assert.Equal(t, 2, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	mockDal.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+func TestConvertTestReportRollsBack(t *testing.T) {
+	mockDal := new(mockdal.Dal)
+	mockDal.On("All", mock.Anything, mock.Anything).Return(nil).Once()
+	mockTx := new(mockdal.Transaction)
+	mockDal.On("Begin").Return(mockTx).Once()
+	mockTx.On("Delete", mock.Anything, mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*qa.QaTestCase"), mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*qa.QaTestCaseExecution"), mock.Anything).Return(errors.Default.New("deadlock")).Once()
+	mockTx.On("Rollback").Return(nil).Once()
+
+	_, err := NewService(mockDal).convertTestReport(storedJunitReport(t))
+	assert.NotNil(t, err)
+	mockTx.AssertExpectations(t)
+	mockTx.AssertNotCalled(t, "Commit")
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	// multi-byte characters are never split
+	assert.Equal(t, "测试", truncate("测试用例", 2))
+	assert.Equal(t, "测试用例", truncate("测试用例", 4))
+}
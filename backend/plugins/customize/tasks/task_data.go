@@ -26,6 +26,8 @@ type MappingRules struct {
 
 type Options struct {
 	TransformationRules []MappingRules `json:"transformationRules"`
+	// QaProjectId enables converting the uploaded test reports of the qa project
+	QaProjectId string `json:"qaProjectId"`
 }
 
 type TaskData struct {
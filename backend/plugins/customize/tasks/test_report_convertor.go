@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/customize/service"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertTestReports
+
+var ConvertTestReportsMeta = plugin.SubTaskMeta{Name: "convertTestReports",
+	EntryPoint:       ConvertTestReports,
+	EnabledByDefault: true,
+	Description:      "convert uploaded test reports into qa_test_cases and qa_test_case_executions",
+}
+
+// ConvertTestReports converts the uploaded test reports of the qa project again, linking them to the cicd pipelines collected since
+func ConvertTestReports(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TaskData)
+	if data == nil || data.Options == nil || data.Options.QaProjectId == "" {
+		return nil
+	}
+	return service.NewService(taskCtx.GetDal()).ConvertTestReports(data.Options.QaProjectId)
+}
+
+var _ plugin.SubTaskEntryPoint = DetectFlakyTests
+
+var DetectFlakyTestsMeta = plugin.SubTaskMeta{Name: "detectFlakyTests",
+	EntryPoint:       DetectFlakyTests,
+	EnabledByDefault: true,
+	Description:      "flag qa_test_cases which both passed and failed on the same code",
+}
+
+// DetectFlakyTests updates the flaky flag of the test cases of the qa project
+func DetectFlakyTests(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TaskData)
+	if data == nil || data.Options == nil || data.Options.QaProjectId == "" {
+		return nil
+	}
+	count, err := service.NewService(taskCtx.GetDal()).DetectFlakyTests(data.Options.QaProjectId)
+	if err != nil {
+		return err
+	}
+	taskCtx.GetLogger().Info("%d flaky tests found in qa project %s", count, data.Options.QaProjectId)
+	return nil
+}
@@ -18,47 +18,446 @@ limitations under the License.
 package api
 
 import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
+	coreModels "github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
 	"github.com/apache/incubator-devlake/core/plugin"
-	"net/http"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/dora/tasks"
 )
 
-const RAW_DEPLOYMENTS_TABLE = `dora_deplyments`
+// DoraDeploymentReq is validated the same way as the deployment request of the webhook plugin
+type DoraDeploymentReq struct {
+	Id                  string `mapstructure:"id" validate:"required"`
+	DisplayTitle        string `mapstructure:"displayTitle"`
+	Result              string `mapstructure:"result"`
+	Environment         string `validate:"omitempty,oneof=PRODUCTION STAGING TESTING DEVELOPMENT"`
+	OriginalEnvironment string `mapstructure:"originalEnvironment"`
+	Name                string `mapstructure:"name"`
+	// DeploymentCommits is used for multiple commits in one deployment
+	DeploymentCommits []DoraDeploymentCommitReq `mapstructure:"deploymentCommits" validate:"required,min=1,dive"`
+	CreatedDate       *time.Time                `mapstructure:"createdDate"`
+	StartedDate       *time.Time                `mapstructure:"startedDate" validate:"required"`
+	FinishedDate      *time.Time                `mapstructure:"finishedDate" validate:"required"`
+}
 
-//TODO Please modify the folowing code to adapt to your plugin
-/*
-POST /plugins/dora/deployments
-{
-	TODO
+type DoraDeploymentCommitReq struct {
+	DisplayTitle string     `mapstructure:"displayTitle"`
+	RepoId       string     `mapstructure:"repoId"`
+	RepoUrl      string     `mapstructure:"repoUrl" validate:"required"`
+	Name         string     `mapstructure:"name"`
+	RefName      string     `mapstructure:"refName"`
+	CommitSha    string     `mapstructure:"commitSha" validate:"required"`
+	CommitMsg    string     `mapstructure:"commitMsg"`
+	Result       string     `mapstructure:"result"`
+	Status       string     `mapstructure:"status"`
+	CreatedDate  *time.Time `mapstructure:"createdDate"`
+	StartedDate  *time.Time `mapstructure:"startedDate"`
+	FinishedDate *time.Time `mapstructure:"finishedDate"`
 }
-*/
-func PostDeployments(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
-	// TODO
-	return &plugin.ApiResourceOutput{Body: nil, Status: http.StatusOK}, nil
+
+type DoraIssueReq struct {
+	Url            string     `mapstructure:"url"`
+	IssueKey       string     `mapstructure:"issueKey" validate:"required"`
+	Title          string     `mapstructure:"title" validate:"required"`
+	Description    string     `mapstructure:"description"`
+	Type           string     `mapstructure:"type"`
+	Status         string     `mapstructure:"status" validate:"omitempty,oneof=TODO DONE IN_PROGRESS"`
+	OriginalStatus string     `mapstructure:"originalStatus"`
+	ResolutionDate *time.Time `mapstructure:"resolutionDate"`
+	CreatedDate    *time.Time `mapstructure:"createdDate" validate:"required"`
+	UpdatedDate    *time.Time `mapstructure:"updatedDate"`
+	Priority       string     `mapstructure:"priority"`
+	Severity       string     `mapstructure:"severity"`
+	Component      string     `mapstructure:"component"`
+	CreatorId      string     `mapstructure:"creatorId"`
+	CreatorName    string     `mapstructure:"creatorName"`
+	AssigneeId     string     `mapstructure:"assigneeId"`
+	AssigneeName   string     `mapstructure:"assigneeName"`
 }
 
-const RAW_ISSUES_TABLE = `dora_issues`
+// PostDeployments
+// @Summary push a deployment of a project
+// @Description Create or update a deployment and its deployment commits under the project, posting the same id again updates the deployment.<br/>
+// @Description example: {"id":"deploy-1","startedDate":"2024-01-01T12:00:00+00:00","finishedDate":"2024-01-01T12:30:00+00:00","deploymentCommits":[{"repoUrl":"https://github.com/apache/incubator-devlake","commitSha":"015e3d3b480e417aede5a1293bd61de9b0fd051d"}]}
+// @Tags plugins/dora
+// @Param projectName path string true "project name"
+// @Param body body DoraDeploymentReq true "json body"
+// @Success 200  {object} devops.CICDDeployment
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /plugins/dora/projects/{projectName}/deployments [POST]
+func PostDeployments(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	projectName := input.Params["projectName"]
+	request := &DoraDeploymentReq{}
+	err := helper.DecodeMapStruct(input.Body, request, true)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid deployment")
+	}
+	if e := vld.Struct(request); e != nil {
+		return nil, errors.BadInput.Wrap(e, "invalid deployment")
+	}
 
-//TODO Please modify the folowing code to adapt to your plugin
-/*
-POST /plugins/dora/issues
-{
-	TODO
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	scopeId, err := ensureProjectScope(tx, projectName, newProjectCicdScope)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := saveDeployment(tx, scopeId, request)
+	if err != nil {
+		return nil, err
+	}
+	err = reconnectIncidentsAfter(tx, projectName, deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: deployment, Status: http.StatusOK}, nil
 }
-*/
+
+// PostIssues
+// @Summary push an incident of a project
+// @Description Create or update an incident issue under the project and connect it to the deployment which caused it, posting the same issueKey again updates the issue.<br/>
+// @Description example: {"issueKey":"INC-1","title":"service is down","createdDate":"2024-01-01T13:00:00+00:00"}
+// @Tags plugins/dora
+// @Param projectName path string true "project name"
+// @Param body body DoraIssueReq true "json body"
+// @Success 200  {object} ticket.Issue
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /plugins/dora/projects/{projectName}/issues [POST]
 func PostIssues(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
-	// TODO
-	return &plugin.ApiResourceOutput{Body: nil, Status: http.StatusOK}, nil
-}
+	projectName := input.Params["projectName"]
+	request := &DoraIssueReq{}
+	err := helper.DecodeMapStruct(input.Body, request, true)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid issue")
+	}
+	if e := vld.Struct(request); e != nil {
+		return nil, errors.BadInput.Wrap(e, "invalid issue")
+	}
 
-//TODO Please modify the folowing code to adapt to your plugin
-/*
-POST /plugins/dora/issues/:id/close
-{
-	TODO
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	boardId, err := ensureProjectScope(tx, projectName, newProjectBoard)
+	if err != nil {
+		return nil, err
+	}
+	issue := &ticket.Issue{
+		DomainEntity:   domainlayer.NewDomainEntity(generateId(boardId, request.IssueKey)),
+		Url:            request.Url,
+		IssueKey:       request.IssueKey,
+		Title:          request.Title,
+		Description:    request.Description,
+		Type:           request.Type,
+		OriginalType:   request.Type,
+		Status:         request.Status,
+		OriginalStatus: request.OriginalStatus,
+		ResolutionDate: request.ResolutionDate,
+		CreatedDate:    request.CreatedDate,
+		UpdatedDate:    request.UpdatedDate,
+		Priority:       request.Priority,
+		Severity:       request.Severity,
+		Component:      request.Component,
+		CreatorName:    request.CreatorName,
+		AssigneeName:   request.AssigneeName,
+	}
+	if issue.Type == "" {
+		issue.Type = ticket.INCIDENT
+	}
+	if issue.Status == "" {
+		issue.Status = ticket.TODO
+		if issue.ResolutionDate != nil {
+			issue.Status = ticket.DONE
+		}
+	}
+	if request.CreatorId != "" {
+		issue.CreatorId = generateId(boardId, request.CreatorId)
+	}
+	if request.AssigneeId != "" {
+		issue.AssigneeId = generateId(boardId, request.AssigneeId)
+	}
+	err = saveIssue(tx, projectName, boardId, issue)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: issue, Status: http.StatusOK}, nil
 }
-*/
+
+// CloseIssues
+// @Summary close an incident of a project
+// @Description Set the status of the issue to DONE, the resolution date defaults to now if it was not set.
+// @Tags plugins/dora
+// @Param projectName path string true "project name"
+// @Param issueKey path string true "issue key"
+// @Success 200  {object} ticket.Issue
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /plugins/dora/projects/{projectName}/issues/{issueKey}/close [POST]
 func CloseIssues(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
-	// TODO
-	return &plugin.ApiResourceOutput{Body: nil, Status: http.StatusOK}, nil
+	projectName := input.Params["projectName"]
+	var err errors.Error
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	boardId, err := ensureProjectScope(tx, projectName, newProjectBoard)
+	if err != nil {
+		return nil, err
+	}
+	issue := &ticket.Issue{}
+	err = tx.First(issue, dal.Where("id = ?", generateId(boardId, input.Params["issueKey"])))
+	if err != nil {
+		if tx.IsErrorNotFound(err) {
+			return nil, errors.NotFound.Wrap(err, "issue not found")
+		}
+		return nil, err
+	}
+	issue.Status = ticket.DONE
+	issue.OriginalStatus = ""
+	if issue.ResolutionDate == nil {
+		now := time.Now()
+		issue.ResolutionDate = &now
+	}
+	err = saveIssue(tx, projectName, boardId, issue)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: issue, Status: http.StatusOK}, nil
+}
+
+// ensureProjectScope makes sure the project owns a scope in which the pushed data is stored,
+// so the data can be found through project_mapping like data collected by other plugins
+func ensureProjectScope(tx dal.Transaction, projectName string, newScope func(scopeId, projectName string) plugin.Scope) (string, errors.Error) {
+	if projectName == "" {
+		return "", errors.BadInput.New("missing projectName")
+	}
+	err := tx.First(&coreModels.Project{}, dal.Where("name = ?", projectName))
+	if err != nil {
+		if tx.IsErrorNotFound(err) {
+			return "", errors.NotFound.New(fmt.Sprintf("project not found: %s", projectName))
+		}
+		return "", err
+	}
+	scopeId := generateId("dora", projectName)
+	scope := newScope(scopeId, projectName)
+	err = tx.CreateIfNotExist(scope)
+	if err != nil {
+		return "", err
+	}
+	err = tx.CreateIfNotExist(&crossdomain.ProjectMapping{
+		ProjectName: projectName,
+		Table:       scope.TableName(),
+		RowId:       scopeId,
+	})
+	if err != nil {
+		return "", err
+	}
+	return scopeId, nil
+}
+
+func newProjectCicdScope(scopeId, projectName string) plugin.Scope {
+	return devops.NewCicdScope(scopeId, projectName)
+}
+
+func newProjectBoard(scopeId, projectName string) plugin.Scope {
+	board := &ticket.Board{
+		DomainEntity: domainlayer.NewDomainEntity(scopeId),
+		Name:         projectName,
+	}
+	board.CreatedDate = &board.CreatedAt
+	return board
+}
+
+func saveDeployment(tx dal.Transaction, scopeId string, request *DoraDeploymentReq) (*devops.CICDDeployment, errors.Error) {
+	deploymentId := generateId(scopeId, request.Id)
+	createdDate := *request.StartedDate
+	if request.CreatedDate != nil {
+		createdDate = *request.CreatedDate
+	}
+	if request.Result == "" {
+		request.Result = devops.RESULT_SUCCESS
+	}
+	if request.Environment == "" {
+		request.Environment = devops.PRODUCTION
+	}
+	duration := float64(request.FinishedDate.Sub(*request.StartedDate).Milliseconds() / 1e3)
+	name := request.Name
+	if name == "" {
+		var commitShaList []string
+		for _, commit := range request.DeploymentCommits {
+			commitShaList = append(commitShaList, commit.CommitSha)
+		}
+		name = fmt.Sprintf(`deploy %s to %s`, strings.Join(commitShaList, ","), request.Environment)
+	}
+
+	deploymentCommits := make([]*devops.CicdDeploymentCommit, len(request.DeploymentCommits))
+	for i, commit := range request.DeploymentCommits {
+		if commit.Result == "" {
+			commit.Result = request.Result
+		}
+		if commit.Status == "" {
+			commit.Status = devops.STATUS_DONE
+		}
+		if commit.Name == "" {
+			commit.Name = fmt.Sprintf(`deployment for %s`, commit.CommitSha)
+		}
+		if commit.CreatedDate == nil {
+			commit.CreatedDate = &createdDate
+		}
+		if commit.StartedDate == nil {
+			commit.StartedDate = request.StartedDate
+		}
+		if commit.FinishedDate == nil {
+			commit.FinishedDate = request.FinishedDate
+		}
+		urlHash16 := fmt.Sprintf("%x", md5.Sum([]byte(commit.RepoUrl)))[:16]
+		deploymentCommits[i] = &devops.CicdDeploymentCommit{
+			DomainEntity:     domainlayer.NewDomainEntity(generateId(deploymentId, urlHash16, commit.CommitSha)),
+			CicdDeploymentId: deploymentId,
+			CicdScopeId:      scopeId,
+			Result:           commit.Result,
+			Status:           commit.Status,
+			OriginalResult:   commit.Result,
+			OriginalStatus:   commit.Status,
+			TaskDatesInfo: devops.TaskDatesInfo{
+				CreatedDate:  *commit.CreatedDate,
+				StartedDate:  commit.StartedDate,
+				FinishedDate: commit.FinishedDate,
+			},
+			DurationSec:         &duration,
+			RepoId:              commit.RepoId,
+			Name:                commit.Name,
+			DisplayTitle:        commit.DisplayTitle,
+			RepoUrl:             commit.RepoUrl,
+			Environment:         request.Environment,
+			OriginalEnvironment: request.OriginalEnvironment,
+			RefName:             commit.RefName,
+			CommitSha:           commit.CommitSha,
+			CommitMsg:           commit.CommitMsg,
+		}
+	}
+
+	// commits removed from a re-posted deployment must not be counted anymore
+	err := tx.Delete(&devops.CicdDeploymentCommit{}, dal.Where("cicd_deployment_id = ?", deploymentId))
+	if err != nil {
+		return nil, err
+	}
+	err = tx.CreateOrUpdate(deploymentCommits)
+	if err != nil {
+		return nil, err
+	}
+	deployment := deploymentCommits[0].ToDeploymentWithCustomDisplayTitle(request.DisplayTitle)
+	deployment.Name = name
+	deployment.CreatedDate = createdDate
+	deployment.StartedDate = request.StartedDate
+	deployment.FinishedDate = request.FinishedDate
+	deployment.Result = request.Result
+	deployment.OriginalResult = request.Result
+	err = tx.CreateOrUpdate(deployment)
+	if err != nil {
+		return nil, err
+	}
+	return deployment, nil
+}
+
+// saveIssue saves the issue to the project board, and saves the incident along with its causing deployment if the issue is an incident
+func saveIssue(tx dal.Transaction, projectName string, boardId string, issue *ticket.Issue) errors.Error {
+	if issue.ResolutionDate != nil && issue.CreatedDate != nil && !issue.ResolutionDate.Before(*issue.CreatedDate) {
+		leadTimeMinutes := uint(issue.ResolutionDate.Sub(*issue.CreatedDate).Minutes())
+		issue.LeadTimeMinutes = &leadTimeMinutes
+	}
+	err := tx.CreateOrUpdate(issue)
+	if err != nil {
+		return err
+	}
+	err = tx.CreateOrUpdate(&ticket.BoardIssue{BoardId: boardId, IssueId: issue.Id})
+	if err != nil {
+		return err
+	}
+	if !issue.IsIncident() {
+		return nil
+	}
+	incident, e := issue.ToIncident(boardId)
+	if e != nil {
+		return errors.Convert(e)
+	}
+	err = tx.CreateOrUpdate(incident)
+	if err != nil {
+		return err
+	}
+	assignee, e := issue.ToIncidentAssignee()
+	if e != nil {
+		return errors.Convert(e)
+	}
+	err = tx.CreateOrUpdate(assignee)
+	if err != nil {
+		return err
+	}
+	return connectIncidentToDeployment(tx, projectName, incident)
+}
+
+// connectIncidentToDeployment links the incident to its causing deployment right away
+// instead of waiting for the next run of the ConnectIncidentToDeployment subtask
+func connectIncidentToDeployment(tx dal.Transaction, projectName string, incident *ticket.Incident) errors.Error {
+	deploymentId, err := tasks.FindIncidentCausingDeployment(tx, projectName, incident)
+	if err != nil {
+		return err
+	}
+	if deploymentId == "" {
+		return tx.Delete(
+			&crossdomain.ProjectIncidentDeploymentRelationship{},
+			dal.Where("id = ? AND project_name = ?", incident.Id, projectName),
+		)
+	}
+	return tx.CreateOrUpdate(&crossdomain.ProjectIncidentDeploymentRelationship{
+		DomainEntity: domainlayer.NewDomainEntity(incident.Id),
+		ProjectName:  projectName,
+		DeploymentId: deploymentId,
+	})
+}
+
+// reconnectIncidentsAfter links the incidents created after a new production deployment to it,
+// they used to be linked to an earlier deployment or none
+func reconnectIncidentsAfter(tx dal.Transaction, projectName string, deployment *devops.CICDDeployment) errors.Error {
+	if deployment.Environment != devops.PRODUCTION || deployment.Result != devops.RESULT_SUCCESS {
+		return nil
+	}
+	var incidents []*ticket.Incident
+	err := tx.All(
+		&incidents,
+		dal.Select("i.*"),
+		dal.From("incidents i"),
+		dal.Join("INNER JOIN project_mapping pm ON pm.row_id = i.scope_id AND pm.table = i.table"),
+		dal.Where("pm.project_name = ? AND i.created_date > ?", projectName, deployment.FinishedDate),
+	)
+	if err != nil {
+		return err
+	}
+	for _, incident := range incidents {
+		err = connectIncidentToDeployment(tx, projectName, incident)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateId(parts ...string) string {
+	return strings.Join(parts, ":")
 }
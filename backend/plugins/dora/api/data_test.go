@@ -0,0 +1,206 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testDeploymentBody = `{
+	"id": "deploy-1",
+	"startedDate": "2024-01-01T12:00:00+00:00",
+	"finishedDate": "2024-01-01T12:30:00+00:00",
+	"deploymentCommits": [
+		{"repoUrl": "https://github.com/apache/incubator-devlake", "commitSha": "015e3d3b480e417aede5a1293bd61de9b0fd051d"}
+	]
+}`
+
+func dataApiInput(projectName string, body string) *plugin.ApiResourceInput {
+	input := &plugin.ApiResourceInput{
+		Params: map[string]string{"projectName": projectName},
+		Body:   map[string]interface{}{},
+	}
+	if err := json.Unmarshal([]byte(body), &input.Body); err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// mockProjectTx mocks a transaction in which the project exists and owns its scopes already
+func mockProjectTx() *mockdal.Transaction {
+	mockTx := new(mockdal.Transaction)
+	mockTx.On("First", mock.Anything, mock.Anything).Return(nil).Once()
+	mockTx.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(nil).Twice()
+	mockTx.On("UnlockTables").Return(nil)
+	Init(unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {
+		mockDal.On("Begin").Return(mockTx).Once()
+	}))
+	return mockTx
+}
+
+func TestPostDeployments(t *testing.T) {
+	mockTx := mockProjectTx()
+	mockTx.On("Delete", mock.AnythingOfType("*devops.CicdDeploymentCommit"), mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*devops.CicdDeploymentCommit"), mock.Anything).Run(func(args mock.Arguments) {
+		commits := args.Get(0).([]*devops.CicdDeploymentCommit)
+		assert.Len(t, commits, 1)
+		assert.Equal(t, "dora:project1:deploy-1", commits[0].CicdDeploymentId)
+		assert.Equal(t, "dora:project1", commits[0].CicdScopeId)
+		assert.Equal(t, devops.RESULT_SUCCESS, commits[0].Result)
+		assert.Equal(t, devops.PRODUCTION, commits[0].Environment)
+		assert.Equal(t, float64(1800), *commits[0].DurationSec)
+	}).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("*devops.CICDDeployment"), mock.Anything).Return(nil).Once()
+	// a successful production deployment takes over the incidents created after it
+	mockTx.On("All", mock.AnythingOfType("*[]*ticket.Incident"), mock.Anything).Return(nil).Once()
+	mockTx.On("Commit").Return(nil).Once()
+
+	output, err := PostDeployments(dataApiInput("project1", testDeploymentBody))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, output.Status)
+	deployment := output.Body.(*devops.CICDDeployment)
+	assert.Equal(t, "dora:project1:deploy-1", deployment.Id)
+	assert.Equal(t, "deploy 015e3d3b480e417aede5a1293bd61de9b0fd051d to PRODUCTION", deployment.Name)
+	assert.Equal(t, devops.RESULT_SUCCESS, deployment.Result)
+	mockTx.AssertExpectations(t)
+}
+
+func TestPostDeploymentsUpsert(t *testing.T) {
+	body := `{
+		"id": "deploy-1",
+		"environment": "STAGING",
+		"startedDate": "2024-01-01T12:00:00+00:00",
+		"finishedDate": "2024-01-01T12:30:00+00:00",
+		"deploymentCommits": [
+			{"repoUrl": "https://github.com/apache/incubator-devlake", "commitSha": "4ab4ab3f7e8f4f2d8e0b0b7a4e7d1f2a2f1b0c3d"},
+			{"repoUrl": "https://github.com/apache/incubator-devlake-helm-chart", "commitSha": "9c1b0e2b3a4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f"}
+		]
+	}`
+	var savedIds []string
+	for i := 0; i < 2; i++ {
+		mockTx := mockProjectTx()
+		// commits of the previous post of the deployment are replaced
+		mockTx.On("Delete", mock.AnythingOfType("*devops.CicdDeploymentCommit"), mock.Anything).Return(nil).Once()
+		mockTx.On("CreateOrUpdate", mock.AnythingOfType("[]*devops.CicdDeploymentCommit"), mock.Anything).Run(func(args mock.Arguments) {
+			commits := args.Get(0).([]*devops.CicdDeploymentCommit)
+			assert.Len(t, commits, 2)
+			assert.NotEqual(t, commits[0].Id, commits[1].Id)
+		}).Return(nil).Once()
+		mockTx.On("CreateOrUpdate", mock.AnythingOfType("*devops.CICDDeployment"), mock.Anything).Run(func(args mock.Arguments) {
+			savedIds = append(savedIds, args.Get(0).(*devops.CICDDeployment).Id)
+		}).Return(nil).Once()
+		mockTx.On("Commit").Return(nil).Once()
+
+		_, err := PostDeployments(dataApiInput("project1", body))
+		assert.Nil(t, err)
+		// incidents are only reconnected to production deployments
+		mockTx.AssertNotCalled(t, "All", mock.Anything, mock.Anything)
+		mockTx.AssertExpectations(t)
+	}
+	assert.Equal(t, []string{"dora:project1:deploy-1", "dora:project1:deploy-1"}, savedIds)
+}
+
+func TestPostDeploymentsInvalid(t *testing.T) {
+	Init(unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {}))
+	for name, body := range map[string]string{
+		"missing id":           `{"startedDate":"2024-01-01T12:00:00+00:00","finishedDate":"2024-01-01T12:30:00+00:00","deploymentCommits":[{"repoUrl":"r","commitSha":"s"}]}`,
+		"missing commits":      `{"id":"deploy-1","startedDate":"2024-01-01T12:00:00+00:00","finishedDate":"2024-01-01T12:30:00+00:00"}`,
+		"missing commit sha":   `{"id":"deploy-1","startedDate":"2024-01-01T12:00:00+00:00","finishedDate":"2024-01-01T12:30:00+00:00","deploymentCommits":[{"repoUrl":"r"}]}`,
+		"missing started date": `{"id":"deploy-1","finishedDate":"2024-01-01T12:30:00+00:00","deploymentCommits":[{"repoUrl":"r","commitSha":"s"}]}`,
+		"unknown environment":  `{"id":"deploy-1","environment":"MOON","startedDate":"2024-01-01T12:00:00+00:00","finishedDate":"2024-01-01T12:30:00+00:00","deploymentCommits":[{"repoUrl":"r","commitSha":"s"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := PostDeployments(dataApiInput("project1", body))
+			assert.NotNil(t, err)
+			assert.Equal(t, errors.BadInput, err.GetType())
+		})
+	}
+}
+
+func TestPostDeploymentsProjectNotFound(t *testing.T) {
+	mockTx := new(mockdal.Transaction)
+	notFound := errors.NotFound.New("record not found")
+	mockTx.On("First", mock.Anything, mock.Anything).Return(notFound).Once()
+	mockTx.On("IsErrorNotFound", notFound).Return(true).Once()
+	mockTx.On("UnlockTables").Return(nil)
+	mockTx.On("Rollback").Return(nil).Once()
+	Init(unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {
+		mockDal.On("Begin").Return(mockTx).Once()
+	}))
+
+	_, err := PostDeployments(dataApiInput("project1", testDeploymentBody))
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.NotFound, err.GetType())
+	mockTx.AssertExpectations(t)
+}
+
+func TestPostIssues(t *testing.T) {
+	mockTx := mockProjectTx()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("*ticket.Issue"), mock.Anything).Run(func(args mock.Arguments) {
+		issue := args.Get(0).(*ticket.Issue)
+		assert.Equal(t, "dora:project1:INC-1", issue.Id)
+		assert.Equal(t, uint(90), *issue.LeadTimeMinutes)
+	}).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("*ticket.BoardIssue"), mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("*ticket.Incident"), mock.Anything).Return(nil).Once()
+	mockTx.On("CreateOrUpdate", mock.AnythingOfType("*ticket.IncidentAssignee"), mock.Anything).Return(nil).Once()
+	// no deployment caused the incident, a stale link to a deployment is removed
+	mockTx.On("All", mock.Anything, mock.Anything).Return(nil).Once()
+	mockTx.On("Delete", mock.AnythingOfType("*crossdomain.ProjectIncidentDeploymentRelationship"), mock.Anything).Return(nil).Once()
+	mockTx.On("Commit").Return(nil).Once()
+
+	output, err := PostIssues(dataApiInput("project1", `{
+		"issueKey": "INC-1",
+		"title": "service is down",
+		"createdDate": "2024-01-01T13:00:00+00:00",
+		"resolutionDate": "2024-01-01T14:30:00+00:00"
+	}`))
+	assert.Nil(t, err)
+	issue := output.Body.(*ticket.Issue)
+	assert.Equal(t, ticket.INCIDENT, issue.Type)
+	assert.Equal(t, ticket.DONE, issue.Status)
+	mockTx.AssertExpectations(t)
+	mockTx.AssertNotCalled(t, "CreateOrUpdate", mock.AnythingOfType("*crossdomain.ProjectIncidentDeploymentRelationship"), mock.Anything)
+}
+
+func TestPostIssuesInvalid(t *testing.T) {
+	Init(unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {}))
+	for name, body := range map[string]string{
+		"missing issue key":    `{"title":"service is down","createdDate":"2024-01-01T13:00:00+00:00"}`,
+		"missing title":        `{"issueKey":"INC-1","createdDate":"2024-01-01T13:00:00+00:00"}`,
+		"missing created date": `{"issueKey":"INC-1","title":"service is down"}`,
+		"unknown status":       `{"issueKey":"INC-1","title":"service is down","status":"WONTFIX","createdDate":"2024-01-01T13:00:00+00:00"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := PostIssues(dataApiInput("project1", body))
+			assert.NotNil(t, err)
+			assert.Equal(t, errors.BadInput, err.GetType())
+		})
+	}
+}
@@ -20,10 +20,12 @@ package impl
 import (
 	"encoding/json"
 
+	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	coreModels "github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/dora/api"
 	"github.com/apache/incubator-devlake/plugins/dora/models/migrationscripts"
 	"github.com/apache/incubator-devlake/plugins/dora/tasks"
 )
@@ -31,7 +33,9 @@ import (
 // make sure interface is implemented
 var _ interface {
 	plugin.PluginMeta
+	plugin.PluginInit
 	plugin.PluginTask
+	plugin.PluginApi
 	plugin.PluginModel
 	plugin.PluginMetric
 	plugin.PluginMigration
@@ -67,6 +71,12 @@ func (p Dora) RequiredDataEntities() (data []map[string]interface{}, err errors.
 	}, nil
 }
 
+func (p Dora) Init(basicRes context.BasicRes) errors.Error {
+	api.Init(basicRes)
+
+	return nil
+}
+
 func (p Dora) GetTablesInfo() []dal.Tabler {
 	return []dal.Tabler{}
 }
@@ -96,6 +106,7 @@ func (p Dora) SubTaskMetas() []plugin.SubTaskMeta {
 		tasks.CalculateChangeLeadTimeMeta,
 		tasks.IssuesToIncidentsMeta,
 		tasks.ConnectIncidentToDeploymentMeta,
+		tasks.CalculateDoraMetricsMeta,
 	}
 }
 
@@ -118,6 +129,20 @@ func (p Dora) MigrationScripts() []plugin.MigrationScript {
 	return migrationscripts.All()
 }
 
+func (p Dora) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
+	return map[string]map[string]plugin.ApiResourceHandler{
+		"projects/:projectName/deployments": {
+			"POST": api.PostDeployments,
+		},
+		"projects/:projectName/issues": {
+			"POST": api.PostIssues,
+		},
+		"projects/:projectName/issues/:issueKey/close": {
+			"POST": api.CloseIssues,
+		},
+	}
+}
+
 func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.RawMessage) (coreModels.PipelinePlan, errors.Error) {
 	op := &tasks.DoraOptions{}
 	if options != nil && string(options) != "\"\"" {
@@ -127,6 +152,13 @@ func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.
 		}
 	}
 
+	metricsOptions := map[string]interface{}{
+		"projectName": projectName,
+	}
+	if op.DoraReport != "" {
+		metricsOptions["doraReport"] = op.DoraReport
+	}
+
 	plan := coreModels.PipelinePlan{
 		{
 			{
@@ -154,14 +186,13 @@ func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.
 		},
 		{
 			{
-				Plugin: "dora",
-				Options: map[string]interface{}{
-					"projectName": projectName,
-				},
+				Plugin:  "dora",
+				Options: metricsOptions,
 				Subtasks: []string{
 					"calculateChangeLeadTime",
 					tasks.IssuesToIncidentsMeta.Name,
 					"ConnectIncidentToDeployment",
+					tasks.CalculateDoraMetricsMeta.Name,
 				},
 			},
 		},
@@ -0,0 +1,495 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// CalculateDoraMetricsMeta contains metadata for the CalculateDoraMetrics subtask.
+var CalculateDoraMetricsMeta = plugin.SubTaskMeta{
+	Name:             "calculateDoraMetrics",
+	EntryPoint:       CalculateDoraMetrics,
+	EnabledByDefault: true,
+	Description:      "Calculate weekly and monthly DORA metrics and grade them against dora_benchmarks",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD, plugin.DOMAIN_TYPE_CODE, plugin.DOMAIN_TYPE_TICKET},
+}
+
+// doraDeployment is a successful production deployment, multiple deployment commits of the same deployment
+// are considered as ONE deployment which finished when the last of them finished
+type doraDeployment struct {
+	DeploymentId string
+	FinishedDate time.Time
+}
+
+// doraChange is the cycle time of a pull request and when it was deployed
+type doraChange struct {
+	PrCycleTime  int64
+	FinishedDate time.Time
+}
+
+// doraFailure is an incident caused by a deployment
+type doraFailure struct {
+	DeploymentId   string
+	ResolutionDate *time.Time
+}
+
+// doraGrader holds the thresholds of a DORA report parsed from its dora_benchmarks rows
+type doraGrader struct {
+	// exclusive upper bounds in minutes of elite, high and medium
+	leadTime [3]int64
+	// inclusive upper bounds of elite, high and medium
+	changeFailureRate [3]float64
+	// exclusive upper bounds in minutes of elite, high and medium
+	recoveryTime [3]int64
+	// the longest interval in minutes between deployments of elite, high and medium, 0 for on-demand
+	deploymentFrequency [3]int64
+}
+
+var doraLevels = [3]string{crossdomain.DORA_LEVEL_ELITE, crossdomain.DORA_LEVEL_HIGH, crossdomain.DORA_LEVEL_MEDIUM}
+
+var (
+	doraDurationPattern = regexp.MustCompile(`\b(?:(one|six|\d+)\s+)?(hour|day|week|month)s?\b`)
+	doraPercentPattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+	doraDurationUnits   = map[string]int64{"hour": 60, "day": 24 * 60, "week": 7 * 24 * 60, "month": 30 * 24 * 60}
+)
+
+// parseDoraDuration returns the last duration mentioned by the benchmark in minutes, i.e. six months of
+// "Between one week and six months(medium)", or 0 if there is none, i.e. "On-demand(elite)"
+func parseDoraDuration(benchmark string) int64 {
+	matches := doraDurationPattern.FindAllStringSubmatch(strings.ToLower(benchmark), -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	match := matches[len(matches)-1]
+	var n int64 = 1
+	switch match[1] {
+	case "", "one":
+	case "six":
+		n = 6
+	default:
+		n, _ = strconv.ParseInt(match[1], 10, 64)
+	}
+	return n * doraDurationUnits[match[2]]
+}
+
+// parseDoraPercent returns the last percentage mentioned by the benchmark, i.e. 0.2 of "16%-20%(high)"
+func parseDoraPercent(benchmark string) (float64, bool) {
+	matches := doraPercentPattern.FindAllStringSubmatch(benchmark, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	return percent / 100, err == nil
+}
+
+// newDoraGrader parses the thresholds of elite, high and medium out of the benchmarks of a DORA report
+func newDoraGrader(benchmarks []*crossdomain.DoraBenchmark) (*doraGrader, errors.Error) {
+	grader := &doraGrader{}
+	found := make(map[string]bool)
+	for _, benchmark := range benchmarks {
+		levels := [3]string{benchmark.Elite, benchmark.High, benchmark.Medium}
+		var durations *[3]int64
+		metric := strings.ToLower(benchmark.Metric)
+		switch metric {
+		case "deployment frequency":
+			durations = &grader.deploymentFrequency
+		case "lead time for changes":
+			durations = &grader.leadTime
+		case "time to restore service", "failed deployment recovery time":
+			metric = "recovery time"
+			durations = &grader.recoveryTime
+		case "change failure rate":
+			for i, level := range levels {
+				rate, ok := parseDoraPercent(level)
+				if !ok {
+					return nil, errors.BadInput.New(fmt.Sprintf("unrecognized %s benchmark %q", benchmark.Metric, level))
+				}
+				grader.changeFailureRate[i] = rate
+			}
+		default:
+			continue
+		}
+		if durations != nil {
+			for i, level := range levels {
+				durations[i] = parseDoraDuration(level)
+				// only deploying on-demand goes without a duration
+				if durations[i] == 0 && (durations != &grader.deploymentFrequency || i > 0) {
+					return nil, errors.BadInput.New(fmt.Sprintf("unrecognized %s benchmark %q", benchmark.Metric, level))
+				}
+			}
+		}
+		found[metric] = true
+	}
+	if len(found) < 4 {
+		return nil, errors.BadInput.New("benchmarks of deployment frequency, lead time for changes, change failure rate and recovery time are required")
+	}
+	return grader, nil
+}
+
+func (g *doraGrader) gradeDuration(thresholds [3]int64, minutes *int64) string {
+	if minutes == nil {
+		return ""
+	}
+	for i, threshold := range thresholds {
+		if *minutes < threshold {
+			return doraLevels[i]
+		}
+	}
+	return crossdomain.DORA_LEVEL_LOW
+}
+
+func (g *doraGrader) gradeChangeFailureRate(rate *float64) string {
+	if rate == nil {
+		return ""
+	}
+	for i, threshold := range g.changeFailureRate {
+		if *rate <= threshold {
+			return doraLevels[i]
+		}
+	}
+	return crossdomain.DORA_LEVEL_LOW
+}
+
+// gradeDeploymentFrequency grades with the number of days with at least one deployment of the week, month and
+// six months, on-demand means deploying on most of the days of a week, the same as the DORA dashboard
+func (g *doraGrader) gradeDeploymentFrequency(weekDays, monthDays, sixMonthDays int) string {
+	for i, interval := range g.deploymentFrequency {
+		var deployed bool
+		switch {
+		case interval <= doraDurationUnits["day"]:
+			deployed = weekDays >= 5
+		case interval <= doraDurationUnits["week"]:
+			deployed = weekDays >= 1
+		case interval <= doraDurationUnits["month"]:
+			deployed = monthDays >= 1
+		default:
+			deployed = sixMonthDays >= 1
+		}
+		if deployed {
+			return doraLevels[i]
+		}
+	}
+	return crossdomain.DORA_LEVEL_LOW
+}
+
+// CalculateDoraMetrics calculates deployment frequency, lead time for changes, change failure rate and
+// failed deployment recovery time of a project for every calendar week and month.
+func CalculateDoraMetrics(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	logger := taskCtx.GetLogger()
+	data := taskCtx.GetData().(*DoraTaskData)
+	projectName := data.Options.ProjectName
+	doraReport := data.Options.DoraReport
+	if doraReport == "" {
+		doraReport = DEFAULT_DORA_REPORT
+	}
+
+	var benchmarks []*crossdomain.DoraBenchmark
+	err := db.All(&benchmarks, dal.Where("dora_report = ?", doraReport), dal.Orderby("id"))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to fetch dora_benchmarks")
+	}
+	if len(benchmarks) == 0 {
+		return errors.BadInput.New(fmt.Sprintf("no DORA benchmarks found for report %s", doraReport))
+	}
+	grader, err := newDoraGrader(benchmarks)
+	if err != nil {
+		return errors.BadInput.Wrap(err, fmt.Sprintf("invalid DORA benchmarks of report %s", doraReport))
+	}
+
+	deployments, err := fetchDoraDeployments(projectName, db)
+	if err != nil {
+		return err
+	}
+	changes, err := fetchDoraChanges(projectName, db)
+	if err != nil {
+		return err
+	}
+	failures, err := fetchDoraFailures(projectName, db)
+	if err != nil {
+		return err
+	}
+	logger.Info("calculating DORA metrics from %d deployments, %d changes and %d failures", len(deployments), len(changes), len(failures))
+
+	// Clear previous results from the project
+	err = db.Delete(&crossdomain.ProjectDoraMetric{}, dal.Where("project_name = ?", projectName))
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting previous project_dora_metrics")
+	}
+	batchSave, err := api.NewBatchSave(taskCtx, reflect.TypeOf(&crossdomain.ProjectDoraMetric{}), 500)
+	if err != nil {
+		return err
+	}
+	defer batchSave.Close()
+	for _, metric := range computeProjectDoraMetrics(projectName, doraReport, grader, deployments, changes, failures) {
+		err = batchSave.Add(metric)
+		if err != nil {
+			return err
+		}
+	}
+	return batchSave.Flush()
+}
+
+func fetchDoraDeployments(projectName string, db dal.Dal) ([]*doraDeployment, errors.Error) {
+	var deployments []*doraDeployment
+	err := db.All(
+		&deployments,
+		dal.Select("cdc.cicd_deployment_id AS deployment_id, MAX(cdc.finished_date) AS finished_date"),
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("INNER JOIN project_mapping pm ON pm.row_id = cdc.cicd_scope_id AND pm.table = 'cicd_scopes'"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date IS NOT NULL",
+			projectName, devops.RESULT_SUCCESS, devops.PRODUCTION,
+		),
+		dal.Groupby("cdc.cicd_deployment_id"),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to fetch production deployments")
+	}
+	return deployments, nil
+}
+
+func fetchDoraChanges(projectName string, db dal.Dal) ([]*doraChange, errors.Error) {
+	var changes []*doraChange
+	err := db.All(
+		&changes,
+		dal.Select("ppm.pr_cycle_time, cdc.finished_date"),
+		dal.From("project_pr_metrics ppm"),
+		dal.Join("INNER JOIN cicd_deployment_commits cdc ON cdc.id = ppm.deployment_commit_id"),
+		dal.Where("ppm.project_name = ? AND ppm.pr_cycle_time IS NOT NULL AND cdc.finished_date IS NOT NULL", projectName),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to fetch pull request cycle times")
+	}
+	return changes, nil
+}
+
+func fetchDoraFailures(projectName string, db dal.Dal) ([]*doraFailure, errors.Error) {
+	var failures []*doraFailure
+	err := db.All(
+		&failures,
+		dal.Select("pidr.deployment_id, i.resolution_date"),
+		dal.From("project_incident_deployment_relationships pidr"),
+		dal.Join("INNER JOIN incidents i ON i.id = pidr.id"),
+		dal.Where("pidr.project_name = ?", projectName),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to fetch incidents caused by deployments")
+	}
+	return failures, nil
+}
+
+type doraPeriod struct {
+	start             time.Time
+	end               time.Time
+	deploymentCount   int
+	deploymentDays    map[time.Time]struct{}
+	leadTimes         []int64
+	failedDeployments map[string]struct{}
+	recoveryTimes     []int64
+}
+
+func newDoraPeriod(start, end time.Time) *doraPeriod {
+	return &doraPeriod{
+		start:             start,
+		end:               end,
+		deploymentDays:    make(map[time.Time]struct{}),
+		failedDeployments: make(map[string]struct{}),
+	}
+}
+
+func doraDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// doraWeek returns the monday of the week
+func doraWeek(t time.Time) time.Time {
+	day := doraDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func doraMonth(t time.Time) time.Time {
+	day := doraDay(t)
+	return day.AddDate(0, 0, 1-day.Day())
+}
+
+// doraMedian picks the median the same way as the DORA dashboard: the largest value whose percent_rank <= 0.5
+func doraMedian(values []int64) *int64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[(len(sorted)-1)/2]
+	return &median
+}
+
+func computeProjectDoraMetrics(
+	projectName string,
+	doraReport string,
+	grader *doraGrader,
+	deployments []*doraDeployment,
+	changes []*doraChange,
+	failures []*doraFailure,
+) []*crossdomain.ProjectDoraMetric {
+	if len(deployments) == 0 && len(changes) == 0 {
+		return nil
+	}
+	// find out the time range covered by the data
+	var first, last time.Time
+	extend := func(t time.Time) {
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if last.IsZero() || t.After(last) {
+			last = t
+		}
+	}
+	for _, d := range deployments {
+		extend(d.FinishedDate)
+	}
+	for _, c := range changes {
+		extend(c.FinishedDate)
+	}
+	// build continuous weeks and months, so periods without any deployment are graded as well
+	weeks := make(map[time.Time]*doraPeriod)
+	var weekStarts []time.Time
+	for w := doraWeek(first); !w.After(doraWeek(last)); w = w.AddDate(0, 0, 7) {
+		weeks[w] = newDoraPeriod(w, w.AddDate(0, 0, 7))
+		weekStarts = append(weekStarts, w)
+	}
+	months := make(map[time.Time]*doraPeriod)
+	var monthStarts []time.Time
+	for m := doraMonth(first); !m.After(doraMonth(last)); m = m.AddDate(0, 1, 0) {
+		months[m] = newDoraPeriod(m, m.AddDate(0, 1, 0))
+		monthStarts = append(monthStarts, m)
+	}
+	periodsOf := func(t time.Time) []*doraPeriod {
+		return []*doraPeriod{weeks[doraWeek(t)], months[doraMonth(t)]}
+	}
+
+	// deployment frequency
+	finishedDates := make(map[string]time.Time, len(deployments))
+	for _, d := range deployments {
+		finishedDates[d.DeploymentId] = d.FinishedDate
+		for _, p := range periodsOf(d.FinishedDate) {
+			p.deploymentCount++
+			p.deploymentDays[doraDay(d.FinishedDate)] = struct{}{}
+		}
+	}
+	// lead time for changes
+	for _, c := range changes {
+		for _, p := range periodsOf(c.FinishedDate) {
+			p.leadTimes = append(p.leadTimes, c.PrCycleTime)
+		}
+	}
+	// change failure rate and failed deployment recovery time, both are attributed to the period
+	// in which the failed deployment finished
+	for _, f := range failures {
+		finishedDate, ok := finishedDates[f.DeploymentId]
+		if !ok {
+			continue
+		}
+		for _, p := range periodsOf(finishedDate) {
+			p.failedDeployments[f.DeploymentId] = struct{}{}
+			if f.ResolutionDate != nil && !f.ResolutionDate.Before(finishedDate) {
+				p.recoveryTimes = append(p.recoveryTimes, int64(f.ResolutionDate.Sub(finishedDate).Minutes()))
+			}
+		}
+	}
+
+	// deployment frequency is graded with the number of deployment days of the surrounding periods
+	sixMonthDays := func(month time.Time) int {
+		days := 0
+		for m := month.AddDate(0, -5, 0); !m.After(month); m = m.AddDate(0, 1, 0) {
+			if p, ok := months[m]; ok {
+				days += len(p.deploymentDays)
+			}
+		}
+		return days
+	}
+	medianWeekDays := func(month *doraPeriod) int {
+		var weekDays []int64
+		for _, w := range weekStarts {
+			week := weeks[w]
+			if week.start.Before(month.end) && week.end.After(month.start) {
+				weekDays = append(weekDays, int64(len(week.deploymentDays)))
+			}
+		}
+		if median := doraMedian(weekDays); median != nil {
+			return int(*median)
+		}
+		return 0
+	}
+
+	metrics := make([]*crossdomain.ProjectDoraMetric, 0, len(weekStarts)+len(monthStarts))
+	newMetric := func(periodType string, p *doraPeriod, frequencyLevel string) *crossdomain.ProjectDoraMetric {
+		metric := &crossdomain.ProjectDoraMetric{
+			ProjectName:               projectName,
+			PeriodType:                periodType,
+			PeriodStart:               p.start,
+			PeriodEnd:                 p.end,
+			DoraReport:                doraReport,
+			DeploymentCount:           p.deploymentCount,
+			DeploymentDays:            len(p.deploymentDays),
+			DeploymentFrequencyLevel:  frequencyLevel,
+			MedianLeadTimeMinutes:     doraMedian(p.leadTimes),
+			FailedDeploymentCount:     len(p.failedDeployments),
+			MedianRecoveryTimeMinutes: doraMedian(p.recoveryTimes),
+		}
+		if p.deploymentCount > 0 {
+			rate := float64(metric.FailedDeploymentCount) / float64(p.deploymentCount)
+			metric.ChangeFailureRate = &rate
+		}
+		metric.LeadTimeLevel = grader.gradeDuration(grader.leadTime, metric.MedianLeadTimeMinutes)
+		metric.ChangeFailureRateLevel = grader.gradeChangeFailureRate(metric.ChangeFailureRate)
+		metric.RecoveryTimeLevel = grader.gradeDuration(grader.recoveryTime, metric.MedianRecoveryTimeMinutes)
+		return metric
+	}
+	for _, w := range weekStarts {
+		week := weeks[w]
+		month := doraMonth(w)
+		monthDays := 0
+		if p, ok := months[month]; ok {
+			monthDays = len(p.deploymentDays)
+		}
+		frequencyLevel := grader.gradeDeploymentFrequency(len(week.deploymentDays), monthDays, sixMonthDays(month))
+		metrics = append(metrics, newMetric(crossdomain.DORA_PERIOD_WEEK, week, frequencyLevel))
+	}
+	for _, m := range monthStarts {
+		month := months[m]
+		frequencyLevel := grader.gradeDeploymentFrequency(medianWeekDays(month), len(month.deploymentDays), sixMonthDays(m))
+		metrics = append(metrics, newMetric(crossdomain.DORA_PERIOD_MONTH, month, frequencyLevel))
+	}
+	return metrics
+}
@@ -0,0 +1,188 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDoraBenchmarks are the same as the rows inserted by the migrations
+var testDoraBenchmarks = map[string][]*crossdomain.DoraBenchmark{
+	"2021": {
+		{Metric: "Deployment frequency", Low: "Fewer than once per six months(low)", Medium: "Between once per month and once every 6 months(medium)", High: "Between once per day and once per month(high)", Elite: "On-demand(elite)"},
+		{Metric: "Lead time for changes", Low: "More than six months(low)", Medium: "Between one week and six months(medium)", High: "Less than one week(high)", Elite: "Less than one hour(elite)"},
+		{Metric: "Change failure rate", Low: "> 30%(low)", Medium: "21%-30%(medium)", High: "16%-20%(high)", Elite: "0-15%(elite)"},
+		{Metric: "Time to restore service", Low: "More than one week(low)", Medium: "Between one day and one week(medium)", High: "Less than one day(high)", Elite: "Less than one hour(elite)"},
+	},
+	"2023": {
+		{Metric: "Deployment frequency", Low: "Fewer than once per month(low)", Medium: "Between once per week and once per month(medium)", High: "Between once per day and once per week(high)", Elite: "On-demand(elite)"},
+		{Metric: "Lead time for changes", Low: "More than one month(low)", Medium: "Between one week and one month(medium)", High: "Between one day and one week(high)", Elite: "Less than one day(elite)"},
+		{Metric: "Change failure rate", Low: "> 15%(low)", Medium: "10%-15%(medium)", High: "5%-10%(high)", Elite: "0-5%(elite)"},
+		{Metric: "Failed deployment recovery time", Low: "More than one week(low)", Medium: "Between one day and one week(medium)", High: "Less than one day(high)", Elite: "Less than one hour(elite)"},
+	},
+}
+
+func testDoraGrader(t *testing.T, doraReport string) *doraGrader {
+	grader, err := newDoraGrader(testDoraBenchmarks[doraReport])
+	assert.Nil(t, err)
+	return grader
+}
+
+func TestNewDoraGrader(t *testing.T) {
+	day := int64(24 * 60)
+	grader := testDoraGrader(t, "2021")
+	assert.Equal(t, [3]int64{60, 7 * day, 180 * day}, grader.leadTime)
+	assert.Equal(t, [3]float64{.15, .20, .30}, grader.changeFailureRate)
+	assert.Equal(t, [3]int64{60, day, 7 * day}, grader.recoveryTime)
+	assert.Equal(t, [3]int64{0, 30 * day, 180 * day}, grader.deploymentFrequency)
+
+	grader = testDoraGrader(t, "2023")
+	assert.Equal(t, [3]int64{day, 7 * day, 30 * day}, grader.leadTime)
+	assert.Equal(t, [3]float64{.05, .10, .15}, grader.changeFailureRate)
+	assert.Equal(t, [3]int64{60, day, 7 * day}, grader.recoveryTime)
+	assert.Equal(t, [3]int64{0, 7 * day, 30 * day}, grader.deploymentFrequency)
+	assert.Equal(t, crossdomain.DORA_LEVEL_ELITE, grader.gradeDeploymentFrequency(5, 20, 100))
+	assert.Equal(t, crossdomain.DORA_LEVEL_HIGH, grader.gradeDeploymentFrequency(1, 4, 20))
+	assert.Equal(t, crossdomain.DORA_LEVEL_MEDIUM, grader.gradeDeploymentFrequency(0, 1, 5))
+	assert.Equal(t, crossdomain.DORA_LEVEL_LOW, grader.gradeDeploymentFrequency(0, 0, 5))
+
+	// a customized benchmark changes the grading
+	custom := make([]*crossdomain.DoraBenchmark, 0)
+	for _, benchmark := range testDoraBenchmarks["2023"] {
+		benchmarkCopy := *benchmark
+		custom = append(custom, &benchmarkCopy)
+	}
+	custom[1].Elite = "Less than 2 hours(elite)"
+	grader, err := newDoraGrader(custom)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(120), grader.leadTime[0])
+
+	_, err = newDoraGrader(testDoraBenchmarks["2023"][:3])
+	assert.NotNil(t, err)
+	custom[2].High = "unknown"
+	_, err = newDoraGrader(custom)
+	assert.NotNil(t, err)
+}
+
+func TestDoraMedian(t *testing.T) {
+	assert.Nil(t, doraMedian(nil))
+	assert.Equal(t, int64(3), *doraMedian([]int64{3}))
+	assert.Equal(t, int64(1), *doraMedian([]int64{2, 1}))
+	assert.Equal(t, int64(2), *doraMedian([]int64{3, 1, 2}))
+	assert.Equal(t, int64(2), *doraMedian([]int64{4, 1, 3, 2}))
+}
+
+func TestDoraWeekAndMonth(t *testing.T) {
+	sunday := time.Date(2024, 1, 7, 23, 59, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), doraWeek(sunday))
+	assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), doraWeek(sunday.Add(time.Minute)))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), doraMonth(sunday))
+}
+
+func TestComputeProjectDoraMetrics(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	resolved := at(2, 12)
+	deployments := []*doraDeployment{
+		{DeploymentId: "d1", FinishedDate: at(1, 10)},
+		{DeploymentId: "d2", FinishedDate: at(2, 10)},
+		{DeploymentId: "d3", FinishedDate: at(2, 15)},
+		{DeploymentId: "d4", FinishedDate: at(17, 10)},
+	}
+	changes := []*doraChange{
+		{PrCycleTime: 30, FinishedDate: at(1, 10)},
+		{PrCycleTime: 120, FinishedDate: at(2, 10)},
+		{PrCycleTime: 3000, FinishedDate: at(17, 10)},
+	}
+	failures := []*doraFailure{
+		{DeploymentId: "d2", ResolutionDate: &resolved},
+		{DeploymentId: "d4"},
+		{DeploymentId: "not-a-production-deployment", ResolutionDate: &resolved},
+	}
+
+	metrics := computeProjectDoraMetrics("p1", "2023", testDoraGrader(t, "2023"), deployments, changes, failures)
+	assert.Len(t, metrics, 4)
+
+	week1, week2, week3, month := metrics[0], metrics[1], metrics[2], metrics[3]
+	assert.Equal(t, crossdomain.DORA_PERIOD_WEEK, week1.PeriodType)
+	assert.Equal(t, at(1, 0), week1.PeriodStart)
+	assert.Equal(t, at(8, 0), week1.PeriodEnd)
+	assert.Equal(t, 3, week1.DeploymentCount)
+	assert.Equal(t, 2, week1.DeploymentDays)
+	assert.Equal(t, crossdomain.DORA_LEVEL_HIGH, week1.DeploymentFrequencyLevel)
+	assert.Equal(t, int64(30), *week1.MedianLeadTimeMinutes)
+	assert.Equal(t, crossdomain.DORA_LEVEL_ELITE, week1.LeadTimeLevel)
+	assert.Equal(t, 1, week1.FailedDeploymentCount)
+	assert.InDelta(t, 1.0/3, *week1.ChangeFailureRate, 1e-9)
+	assert.Equal(t, crossdomain.DORA_LEVEL_LOW, week1.ChangeFailureRateLevel)
+	assert.Equal(t, int64(120), *week1.MedianRecoveryTimeMinutes)
+	assert.Equal(t, crossdomain.DORA_LEVEL_HIGH, week1.RecoveryTimeLevel)
+
+	// a week without deployments still gets graded by the deployments of its month
+	assert.Equal(t, at(8, 0), week2.PeriodStart)
+	assert.Equal(t, 0, week2.DeploymentCount)
+	assert.Equal(t, crossdomain.DORA_LEVEL_MEDIUM, week2.DeploymentFrequencyLevel)
+	assert.Nil(t, week2.MedianLeadTimeMinutes)
+	assert.Nil(t, week2.ChangeFailureRate)
+	assert.Empty(t, week2.LeadTimeLevel)
+	assert.Empty(t, week2.ChangeFailureRateLevel)
+
+	assert.Equal(t, at(15, 0), week3.PeriodStart)
+	assert.Equal(t, crossdomain.DORA_LEVEL_HIGH, week3.LeadTimeLevel)
+	assert.Nil(t, week3.MedianRecoveryTimeMinutes)
+	assert.Empty(t, week3.RecoveryTimeLevel)
+
+	assert.Equal(t, crossdomain.DORA_PERIOD_MONTH, month.PeriodType)
+	assert.Equal(t, at(1, 0), month.PeriodStart)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), month.PeriodEnd)
+	assert.Equal(t, 4, month.DeploymentCount)
+	assert.Equal(t, 3, month.DeploymentDays)
+	assert.Equal(t, crossdomain.DORA_LEVEL_HIGH, month.DeploymentFrequencyLevel)
+	assert.Equal(t, int64(120), *month.MedianLeadTimeMinutes)
+	assert.Equal(t, 2, month.FailedDeploymentCount)
+	assert.InDelta(t, 0.5, *month.ChangeFailureRate, 1e-9)
+	assert.Equal(t, "2023", month.DoraReport)
+}
+
+func TestComputeProjectDoraMetricsWithReport2021(t *testing.T) {
+	deployments := []*doraDeployment{
+		{DeploymentId: "d1", FinishedDate: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{DeploymentId: "d2", FinishedDate: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+	}
+	metrics := computeProjectDoraMetrics("p1", "2021", testDoraGrader(t, "2021"), deployments, nil, nil)
+	var february *crossdomain.ProjectDoraMetric
+	for _, m := range metrics {
+		if m.PeriodType == crossdomain.DORA_PERIOD_MONTH && m.PeriodStart.Month() == time.February {
+			february = m
+		}
+	}
+	assert.NotNil(t, february)
+	// no deployment in february, but there is one within the last six months
+	assert.Equal(t, crossdomain.DORA_LEVEL_MEDIUM, february.DeploymentFrequencyLevel)
+	assert.Equal(t, float64(0), *metrics[0].ChangeFailureRate)
+	assert.Equal(t, crossdomain.DORA_LEVEL_ELITE, metrics[0].ChangeFailureRateLevel)
+}
+
+func TestComputeProjectDoraMetricsWithoutData(t *testing.T) {
+	assert.Empty(t, computeProjectDoraMetrics("p1", "2023", testDoraGrader(t, "2023"), nil, nil, nil))
+}
@@ -99,36 +99,13 @@ func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 				ProjectName: data.Options.ProjectName,
 			}
 			logger.Debug("get incident: %+v", incident.Id)
-			cicdDeploymentCommit := &devops.CicdDeploymentCommit{}
-			cicdDeploymentCommitClauses := []dal.Clause{
-				dal.Select("cicd_deployment_commits.cicd_deployment_id as id, cicd_deployment_commits.finished_date as finished_date"),
-				dal.From(cicdDeploymentCommit),
-				dal.Join("left join project_mapping pm on cicd_deployment_commits.cicd_scope_id = pm.row_id"),
-				dal.Where(
-					`cicd_deployment_commits.finished_date < ?
-					    and cicd_deployment_commits.result = ?
-						and cicd_deployment_commits.environment = ?
-						and pm.table = ?
-						and pm.project_name = ?`,
-					incident.CreatedDate, devops.RESULT_SUCCESS, devops.PRODUCTION, "cicd_scopes", data.Options.ProjectName,
-				),
-				dal.Orderby("finished_date DESC"),
-				dal.Limit(1),
-			}
-
-			scdc := &simpleCicdDeploymentCommit{}
-			err = db.All(scdc, cicdDeploymentCommitClauses...)
+			deploymentId, err := FindIncidentCausingDeployment(db, data.Options.ProjectName, incident)
 			if err != nil {
-				if db.IsErrorNotFound(err) {
-					logger.Warn(err, "deployment commit not found")
-					return nil, nil
-				} else {
-					logger.Error(err, "get all deployment commits")
-					return nil, err
-				}
+				logger.Error(err, "get all deployment commits")
+				return nil, err
 			}
-			if scdc.Id != "" {
-				projectIssueMetric.DeploymentId = scdc.Id
+			if deploymentId != "" {
+				projectIssueMetric.DeploymentId = deploymentId
 				return []interface{}{projectIssueMetric}, nil
 			}
 			logger.Debug("scdc.id is empty, incident will be ignored: %+v", incident.Id)
@@ -141,3 +118,33 @@ func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 
 	return enricher.Execute()
 }
+
+// FindIncidentCausingDeployment returns the id of the last successful production deployment of the project
+// finished before the incident was created, an empty string is returned if there is no such deployment
+func FindIncidentCausingDeployment(db dal.Dal, projectName string, incident *ticket.Incident) (string, errors.Error) {
+	cicdDeploymentCommitClauses := []dal.Clause{
+		dal.Select("cicd_deployment_commits.cicd_deployment_id as id, cicd_deployment_commits.finished_date as finished_date"),
+		dal.From(&devops.CicdDeploymentCommit{}),
+		dal.Join("left join project_mapping pm on cicd_deployment_commits.cicd_scope_id = pm.row_id"),
+		dal.Where(
+			`cicd_deployment_commits.finished_date < ?
+			    and cicd_deployment_commits.result = ?
+				and cicd_deployment_commits.environment = ?
+				and pm.table = ?
+				and pm.project_name = ?`,
+			incident.CreatedDate, devops.RESULT_SUCCESS, devops.PRODUCTION, "cicd_scopes", projectName,
+		),
+		dal.Orderby("finished_date DESC"),
+		dal.Limit(1),
+	}
+
+	scdc := &simpleCicdDeploymentCommit{}
+	err := db.All(scdc, cicdDeploymentCommitClauses...)
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return scdc.Id, nil
+}
@@ -22,6 +22,8 @@ import (
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 )
 
+const DEFAULT_DORA_REPORT = "2023"
+
 type DoraApiParams struct {
 	ProjectName string
 }
@@ -31,6 +33,7 @@ type DoraOptions struct {
 	Since       string
 	ProjectName string  `json:"projectName"`
 	ScopeId     *string `json:"scopeId,omitempty"`
+	DoraReport  string  `json:"doraReport,omitempty"`
 }
 
 type DoraTaskData struct {
@@ -44,6 +47,9 @@ func DecodeAndValidateTaskOptions(options map[string]interface{}) (*DoraOptions,
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "error decoding DORA task options")
 	}
+	if op.DoraReport == "" {
+		op.DoraReport = DEFAULT_DORA_REPORT
+	}
 
 	return &op, nil
 }
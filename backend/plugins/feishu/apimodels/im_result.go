@@ -56,3 +56,10 @@ type FeishuMessageResultItem struct {
 	UpdateTime string `json:"update_time"`
 	Updated    bool   `json:"updated"`
 }
+
+type FeishuChatMemberResultItem struct {
+	MemberId     string `json:"member_id"`
+	MemberIdType string `json:"member_id_type"`
+	Name         string `json:"name"`
+	TenantKey    string `json:"tenant_key"`
+}
@@ -0,0 +1,85 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/feishu/impl"
+	"github.com/apache/incubator-devlake/plugins/feishu/models"
+	"github.com/apache/incubator-devlake/plugins/feishu/tasks"
+)
+
+func TestChatDataFlow(t *testing.T) {
+	var plugin impl.Feishu
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "feishu", plugin)
+
+	taskData := &tasks.FeishuTaskData{
+		Options: &tasks.FeishuOptions{
+			ConnectionId: 1,
+			ProjectName:  "project1",
+		},
+	}
+
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/_tool_feishu_chats.csv", &models.FeishuChatItem{})
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/_tool_feishu_chat_members.csv", &models.FeishuChatMember{})
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/_tool_feishu_messages.csv", &models.FeishuMessage{})
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/issues.csv", &ticket.Issue{})
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/board_issues.csv", &ticket.BoardIssue{})
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.FlushTabler(&code.PullRequest{})
+
+	// verify account conversion
+	dataflowTester.FlushTabler(&crossdomain.Account{})
+	dataflowTester.Subtask(tasks.ConvertAccountMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.Account{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/accounts.csv",
+		TargetFields: []string{"id", "full_name", "user_name"},
+	})
+
+	// verify chat conversion, chats are mapped to the project of the task
+	dataflowTester.FlushTabler(&crossdomain.ChatChannel{})
+	dataflowTester.Subtask(tasks.ConvertChatMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ChatChannel{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/chat_channels.csv",
+		TargetFields: []string{"id", "name", "type", "description", "creator_id"},
+	})
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectMapping{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/project_mapping.csv",
+		TargetFields: []string{"project_name", "table", "row_id"},
+	})
+
+	// verify message conversion, deleted messages are skipped, and issue keys are only linked to issues
+	// of the boards in the project of the chat
+	dataflowTester.FlushTabler(&crossdomain.ChatMessage{})
+	dataflowTester.FlushTabler(&crossdomain.ChatMessageIssue{})
+	dataflowTester.FlushTabler(&crossdomain.ChatMessagePullRequest{})
+	dataflowTester.Subtask(tasks.ConvertMessageMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ChatMessage{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/chat_messages.csv",
+		TargetFields: []string{"id", "channel_id", "parent_message_id", "sender_id", "type", "content", "created_date"},
+	})
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ChatMessageIssue{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/chat_message_issues.csv",
+		TargetFields: []string{"message_id", "issue_id"},
+	})
+}
@@ -56,6 +56,7 @@ func (p Feishu) GetTablesInfo() []dal.Tabler {
 		&models.FeishuMeetingTopUserItem{},
 		&models.FeishuChatItem{},
 		&models.FeishuMessage{},
+		&models.FeishuChatMember{},
 	}
 }
 
@@ -84,9 +85,16 @@ func (p Feishu) SubTaskMetas() []plugin.SubTaskMeta {
 		tasks.CollectChatMeta,
 		tasks.ExtractChatItemMeta,
 
+		tasks.CollectChatMemberMeta,
+		tasks.ExtractChatMemberMeta,
+
 		tasks.CollectMessageMeta,
 		tasks.ExtractMessageMeta,
 
+		tasks.ConvertAccountMeta,
+		tasks.ConvertChatMeta,
+		tasks.ConvertMessageMeta,
+
 		tasks.CollectMeetingTopUserItemMeta,
 		tasks.ExtractMeetingTopUserItemMeta,
 	}
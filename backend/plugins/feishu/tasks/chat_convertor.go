@@ -0,0 +1,101 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/feishu/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertChat
+
+var ConvertChatMeta = plugin.SubTaskMeta{
+	Name:             "convertChat",
+	EntryPoint:       ConvertChat,
+	EnabledByDefault: true,
+	Description:      "Convert tool layer table _tool_feishu_chats into domain layer table chat_channels, and map them to the project",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+	DependencyTables: []string{models.FeishuChatItem{}.TableName()},
+	ProductTables: []string{
+		crossdomain.ChatChannel{}.TableName(),
+		crossdomain.ProjectMapping{}.TableName(),
+	},
+}
+
+func ConvertChat(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*FeishuTaskData)
+
+	cursor, err := db.Cursor(
+		dal.From(&models.FeishuChatItem{}),
+		dal.Where("connection_id = ?", data.Options.ConnectionId),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	chatIdGen := didgen.NewDomainIdGenerator(&models.FeishuChatItem{})
+	accountIdGen := didgen.NewDomainIdGenerator(&models.FeishuChatMember{})
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: FeishuApiParams{
+				ConnectionId: data.Options.ConnectionId,
+			},
+			Table: RAW_CHAT_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.FeishuChatItem{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			chat := inputRow.(*models.FeishuChatItem)
+			// the bot can only list group chats, which are visible to their members only
+			channel := &crossdomain.ChatChannel{
+				DomainEntity: domainlayer.DomainEntity{Id: chatIdGen.Generate(data.Options.ConnectionId, chat.ChatId)},
+				Name:         chat.Name,
+				Type:         crossdomain.CHAT_CHANNEL_PRIVATE,
+				Description:  chat.Description,
+			}
+			if chat.OwnerId != "" && chat.OwnerIdType == "open_id" {
+				channel.CreatorId = accountIdGen.Generate(data.Options.ConnectionId, chat.OwnerId)
+			}
+			// feishu chats are not scopes of blueprints, so they are mapped to the project of the task instead
+			if data.Options.ProjectName != "" {
+				return []interface{}{channel, &crossdomain.ProjectMapping{
+					ProjectName: data.Options.ProjectName,
+					Table:       channel.TableName(),
+					RowId:       channel.Id,
+				}}, nil
+			}
+			return []interface{}{channel}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/feishu/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertMessage
+
+var ConvertMessageMeta = plugin.SubTaskMeta{
+	Name:             "convertMessage",
+	EntryPoint:       ConvertMessage,
+	EnabledByDefault: true,
+	Description:      "Convert messages into chat_messages, and link them to mentioned pull requests and issues",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+	DependencyTables: []string{
+		models.FeishuMessage{}.TableName(),
+		code.PullRequest{}.TableName(),
+		ticket.Issue{}.TableName(),
+		ticket.BoardIssue{}.TableName(),
+		crossdomain.ProjectMapping{}.TableName(),
+	},
+	ProductTables: []string{
+		crossdomain.ChatMessage{}.TableName(),
+		crossdomain.ChatMessagePullRequest{}.TableName(),
+		crossdomain.ChatMessageIssue{}.TableName(),
+	},
+}
+
+func ConvertMessage(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*FeishuTaskData)
+
+	cursor, err := db.Cursor(
+		dal.From(&models.FeishuMessage{}),
+		dal.Where("connection_id = ? AND deleted = ?", data.Options.ConnectionId, false),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	chatIdGen := didgen.NewDomainIdGenerator(&models.FeishuChatItem{})
+	messageIdGen := didgen.NewDomainIdGenerator(&models.FeishuMessage{})
+	accountIdGen := didgen.NewDomainIdGenerator(&models.FeishuChatMember{})
+	// issue keys are looked up among the boards of the projects of each chat
+	linkers := make(map[string]*api.ChatMessageLinker)
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: FeishuApiParams{
+				ConnectionId: data.Options.ConnectionId,
+			},
+			Table: RAW_MESSAGE_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.FeishuMessage{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			message := inputRow.(*models.FeishuMessage)
+			domainMessage := &crossdomain.ChatMessage{
+				DomainEntity: domainlayer.DomainEntity{Id: messageIdGen.Generate(data.Options.ConnectionId, message.MessageId)},
+				ChannelId:    chatIdGen.Generate(data.Options.ConnectionId, message.ChatId),
+				Type:         message.MsgType,
+				Content:      feishuMessageText(message.MsgType, message.Content),
+				CreatedDate:  message.CreateTime,
+			}
+			if message.SenderType == "user" && message.SenderId != "" {
+				domainMessage.SenderId = accountIdGen.Generate(data.Options.ConnectionId, message.SenderId)
+			}
+			if message.RootId != "" && message.RootId != message.MessageId {
+				domainMessage.ParentMessageId = messageIdGen.Generate(data.Options.ConnectionId, message.RootId)
+			}
+			linker, ok := linkers[message.ChatId]
+			if !ok {
+				linker, err = api.NewChatMessageLinker(db, domainMessage.ChannelId)
+				if err != nil {
+					return nil, err
+				}
+				linkers[message.ChatId] = linker
+			}
+			links, err := linker.Link(domainMessage.Id, domainMessage.Content)
+			if err != nil {
+				return nil, err
+			}
+			return append([]interface{}{domainMessage}, links...), nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
+
+// feishuMessageText returns the plain text of text messages, other kinds of messages are kept as they are
+func feishuMessageText(msgType, content string) string {
+	if msgType != "text" {
+		return content
+	}
+	body := struct {
+		Text string `json:"text"`
+	}{}
+	if err := json.Unmarshal([]byte(content), &body); err != nil {
+		return content
+	}
+	return body.Text
+}
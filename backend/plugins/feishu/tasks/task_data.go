@@ -28,6 +28,8 @@ type FeishuApiParams struct {
 type FeishuOptions struct {
 	ConnectionId       uint64  `json:"connectionId"`
 	NumOfDaysToCollect float64 `json:"numOfDaysToCollect"`
+	// ProjectName maps the chats of the connection to the project, so that messages get linked to its issues
+	ProjectName string `json:"projectName"`
 }
 
 type FeishuTaskData struct {
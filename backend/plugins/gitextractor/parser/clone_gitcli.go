@@ -29,8 +29,10 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	giturls "github.com/chainguard-dev/git-urls"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var _ RepoCloner = (*GitcliCloner)(nil)
@@ -163,7 +165,16 @@ func (g *GitcliCloner) IsIncremental() bool {
 	return false
 }
 
-func (g *GitcliCloner) CloneRepo() errors.Error {
+func (g *GitcliCloner) CloneRepo() (err errors.Error) {
+	_, span := tracing.Start(
+		g.ctx.GetContext(),
+		"git clone",
+		attribute.Bool("git.incremental", g.since != nil),
+		attribute.Bool("git.no_shallow_clone", g.taskData.Options.NoShallowClone),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
 	if g.since == nil {
 		// full sync
 		if err := g.fullClone(); err != nil {
@@ -0,0 +1,96 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	coremodels "github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/github/impl"
+	"github.com/apache/incubator-devlake/plugins/github/models"
+	"github.com/apache/incubator-devlake/plugins/github/tasks"
+)
+
+func TestMilestoneCollectorDataFlow(t *testing.T) {
+	var plugin impl.Github
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "github", plugin)
+	// the responses of api.github.com are replayed from ./cassettes/api.github.com.ndjson
+	dataflowTester.UseCassettes("./cassettes", api.API_CASSETTE_MODE_REPLAY)
+
+	connection := &models.GithubConnection{
+		GithubConn: models.GithubConn{
+			RestConnection: api.RestConnection{
+				Endpoint: "https://api.github.com/",
+			},
+			MultiAuth: api.MultiAuth{
+				AuthMethod: models.AccessToken,
+			},
+			GithubAccessToken: models.GithubAccessToken{
+				AccessToken: api.AccessToken{
+					Token: "REDACTED",
+				},
+			},
+		},
+	}
+	connection.ID = 1
+	taskData := &tasks.GithubTaskData{
+		Options: &tasks.GithubOptions{
+			ConnectionId: 1,
+			Name:         "panjf2000/ants",
+			GithubId:     134018330,
+			ScopeConfig:  &models.GithubScopeConfig{},
+		},
+	}
+	apiClient, err := tasks.CreateApiClient(dataflowTester.TaskContext(taskData), connection)
+	if err != nil {
+		panic(err)
+	}
+	taskData.ApiClient = apiClient
+
+	// collect milestones from the cassette
+	dataflowTester.FlushTabler(&coremodels.CollectorCheckpoint{})
+	dataflowTester.FlushTabler(&coremodels.CollectorCheckpointPage{})
+	dataflowTester.FlushRawTable("_raw_" + tasks.RAW_MILESTONE_TABLE)
+	dataflowTester.Subtask(tasks.CollectMilestonesMeta, taskData)
+
+	// verify extraction of the collected milestones
+	dataflowTester.FlushTabler(&models.GithubMilestone{})
+	dataflowTester.Subtask(tasks.ExtractMilestonesMeta, taskData)
+	dataflowTester.VerifyTable(
+		models.GithubMilestone{},
+		"./snapshot_tables/_tool_github_milestones_collected.csv",
+		[]string{
+			"connection_id",
+			"milestone_id",
+			"repo_id",
+			"number",
+			"url",
+			"title",
+			"open_issues",
+			"closed_issues",
+			"state",
+			"closed_at",
+			"_raw_data_params",
+			"_raw_data_table",
+			"_raw_data_id",
+			"_raw_data_remark",
+		},
+	)
+}
@@ -218,6 +218,10 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 		// see https://docs.github.com/en/enterprise-server@3.11/graphql/guides/forming-calls-with-graphql
 		endpoint.Path = "/api/graphql"
 	}
+	httpClient, err = helper.WithCassette(taskCtx.GetConfigReader(), connection.Endpoint, httpClient)
+	if err != nil {
+		return nil, err
+	}
 	client := graphql.NewClient(endpoint.String(), httpClient)
 	graphqlClient, err := helper.CreateAsyncGraphqlClient(taskCtx, client, taskCtx.GetLogger(),
 		func(ctx context.Context, client *graphql.Client, logger log.Logger) (rateRemaining int, resetAt *time.Time, err errors.Error) {
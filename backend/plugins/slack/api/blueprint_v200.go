@@ -20,8 +20,12 @@ package api
 import (
 	"github.com/apache/incubator-devlake/core/errors"
 	coreModels "github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helperapi "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
 	"github.com/apache/incubator-devlake/plugins/slack/tasks"
 )
 
@@ -37,6 +41,8 @@ func MakeDataSourcePipelinePlanV200(
 	}
 	// Build one stage per selected channel
 	plan := make(coreModels.PipelinePlan, len(scopeDetails))
+	scopes := make([]plugin.Scope, 0, len(scopeDetails))
+	channelIdGen := didgen.NewDomainIdGenerator(&models.SlackChannel{})
 	for i, scopeDetail := range scopeDetails {
 		stage := plan[i]
 		if stage == nil {
@@ -56,7 +62,10 @@ func MakeDataSourcePipelinePlanV200(
 		}
 		stage = append(stage, task)
 		plan[i] = stage
+		scopes = append(scopes, &crossdomain.ChatChannel{
+			DomainEntity: domainlayer.DomainEntity{Id: channelIdGen.Generate(connectionId, scope.Id)},
+			Name:         scope.Name,
+		})
 	}
-	// No domain scopes emitted by Slack for now
-	return plan, nil, nil
+	return plan, scopes, nil
 }
@@ -164,3 +164,26 @@ type SlackThreadsApiResult struct {
 		NextCursor string `json:"next_cursor"`
 	} `json:"response_metadata"`
 }
+
+type SlackUserApiResult struct {
+	Ok               bool              `json:"ok"`
+	Members          []json.RawMessage `json:"members"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+type SlackUserResultItem struct {
+	Id       string `json:"id"`
+	TeamId   string `json:"team_id"`
+	Name     string `json:"name"`
+	Deleted  bool   `json:"deleted"`
+	RealName string `json:"real_name"`
+	IsBot    bool   `json:"is_bot"`
+	Profile  struct {
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+		Email       string `json:"email"`
+		Image72     string `json:"image_72"`
+	} `json:"profile"`
+}
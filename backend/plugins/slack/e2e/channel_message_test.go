@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/slack/impl"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
+	"github.com/apache/incubator-devlake/plugins/slack/tasks"
+)
+
+func TestSlackChannelMessageDataFlow(t *testing.T) {
+	var slack impl.Slack
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "slack", slack)
+
+	taskData := &tasks.SlackTaskData{
+		Options: &tasks.SlackOptions{
+			ConnectionId: 1,
+			ChannelId:    "C01",
+		},
+	}
+
+	// import raw data table
+	dataflowTester.ImportCsvIntoRawTable("./raw_tables/_raw_slack_channel_message.csv", "_raw_slack_channel_message")
+
+	// verify extraction
+	dataflowTester.FlushTabler(&models.SlackChannelMessage{})
+	dataflowTester.Subtask(tasks.ExtractChannelMessageMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(models.SlackChannelMessage{}, e2ehelper.TableOptions{
+		CSVRelPath: "./snapshot_tables/_tool_slack_channel_messages.csv",
+		TargetFields: []string{
+			"connection_id", "channel_id", "ts", "client_msg_id", "type", "subtype", "thread_ts",
+			"user", "text", "team", "reply_count", "parent_user_id",
+		},
+	})
+
+	// issue keys are only linked to issues of the boards in the projects of the channel
+	dataflowTester.ImportCsvIntoTabler("./raw_tables/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.ImportCsvIntoTabler("./raw_tables/issues.csv", &ticket.Issue{})
+	dataflowTester.ImportCsvIntoTabler("./raw_tables/board_issues.csv", &ticket.BoardIssue{})
+	dataflowTester.ImportCsvIntoTabler("./raw_tables/pull_requests.csv", &code.PullRequest{})
+
+	// verify conversion
+	dataflowTester.FlushTabler(&crossdomain.ChatMessage{})
+	dataflowTester.FlushTabler(&crossdomain.ChatMessagePullRequest{})
+	dataflowTester.FlushTabler(&crossdomain.ChatMessageIssue{})
+	dataflowTester.Subtask(tasks.ConvertChannelMessageMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(crossdomain.ChatMessage{}, e2ehelper.TableOptions{
+		CSVRelPath: "./snapshot_tables/chat_messages.csv",
+		TargetFields: []string{
+			"id", "channel_id", "parent_message_id", "sender_id", "type", "content", "reply_count", "created_date",
+		},
+	})
+	dataflowTester.VerifyTableWithOptions(crossdomain.ChatMessagePullRequest{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/chat_message_pull_requests.csv",
+		TargetFields: []string{"message_id", "pull_request_id"},
+	})
+	dataflowTester.VerifyTableWithOptions(crossdomain.ChatMessageIssue{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/chat_message_issues.csv",
+		TargetFields: []string{"message_id", "issue_id"},
+	})
+}
@@ -0,0 +1,61 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/slack/impl"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
+	"github.com/apache/incubator-devlake/plugins/slack/tasks"
+)
+
+func TestSlackUserDataFlow(t *testing.T) {
+	var slack impl.Slack
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "slack", slack)
+
+	taskData := &tasks.SlackTaskData{
+		Options: &tasks.SlackOptions{
+			ConnectionId: 1,
+			ChannelId:    "C01",
+		},
+	}
+
+	// import raw data table
+	dataflowTester.ImportCsvIntoRawTable("./raw_tables/_raw_slack_user.csv", "_raw_slack_user")
+
+	// verify extraction
+	dataflowTester.FlushTabler(&models.SlackUser{})
+	dataflowTester.Subtask(tasks.ExtractUserMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(models.SlackUser{}, e2ehelper.TableOptions{
+		CSVRelPath: "./snapshot_tables/_tool_slack_users.csv",
+		TargetFields: []string{
+			"connection_id", "id", "team_id", "name", "real_name", "display_name", "email", "avatar_url", "is_bot", "deleted",
+		},
+	})
+
+	// verify conversion
+	dataflowTester.FlushTabler(&crossdomain.Account{})
+	dataflowTester.Subtask(tasks.ConvertAccountMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(crossdomain.Account{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/accounts.csv",
+		TargetFields: []string{"id", "email", "full_name", "user_name", "avatar_url"},
+	})
+}
@@ -58,6 +58,7 @@ func (p Slack) GetTablesInfo() []dal.Tabler {
 		&models.SlackConnection{},
 		&models.SlackChannelMessage{},
 		&models.SlackChannel{},
+		&models.SlackUser{},
 	}
 }
 
@@ -91,6 +92,13 @@ func (p Slack) SubTaskMetas() []plugin.SubTaskMeta {
 
 		tasks.CollectThreadMeta,
 		tasks.ExtractThreadMeta,
+
+		tasks.CollectUserMeta,
+		tasks.ExtractUserMeta,
+
+		tasks.ConvertAccountMeta,
+		tasks.ConvertChannelMeta,
+		tasks.ConvertChannelMessageMeta,
 	}
 }
 
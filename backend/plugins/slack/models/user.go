@@ -0,0 +1,40 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+type SlackUser struct {
+	common.NoPKModel `json:"-"`
+	ConnectionId     uint64 `gorm:"primaryKey"`
+	Id               string `json:"id" gorm:"primaryKey;type:varchar(255)"`
+	TeamId           string `json:"team_id" gorm:"type:varchar(255)"`
+	Name             string `json:"name" gorm:"type:varchar(255)"`
+	RealName         string `json:"real_name" gorm:"type:varchar(255)"`
+	DisplayName      string `json:"display_name" gorm:"type:varchar(255)"`
+	Email            string `json:"email" gorm:"type:varchar(255)"`
+	AvatarUrl        string `json:"avatar_url" gorm:"type:varchar(255)"`
+	IsBot            bool   `json:"is_bot"`
+	Deleted          bool   `json:"deleted"`
+}
+
+func (SlackUser) TableName() string {
+	return "_tool_slack_users"
+}
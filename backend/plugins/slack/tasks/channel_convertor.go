@@ -0,0 +1,104 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertChannel
+
+var ConvertChannelMeta = plugin.SubTaskMeta{
+	Name:             "convertChannel",
+	EntryPoint:       ConvertChannel,
+	EnabledByDefault: true,
+	Description:      "Convert tool layer table _tool_slack_channels into domain layer table chat_channels",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+	DependencyTables: []string{models.SlackChannel{}.TableName()},
+	ProductTables:    []string{crossdomain.ChatChannel{}.TableName()},
+}
+
+func ConvertChannel(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*SlackTaskData)
+
+	cursor, err := db.Cursor(
+		dal.From(&models.SlackChannel{}),
+		dal.Where("connection_id = ? AND id = ?", data.Options.ConnectionId, data.Options.ChannelId),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	channelIdGen := didgen.NewDomainIdGenerator(&models.SlackChannel{})
+	accountIdGen := didgen.NewDomainIdGenerator(&models.SlackUser{})
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: SlackApiParams{
+				ConnectionId: data.Options.ConnectionId,
+			},
+			Table: RAW_CHANNEL_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.SlackChannel{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			channel := inputRow.(*models.SlackChannel)
+			domainChannel := &crossdomain.ChatChannel{
+				DomainEntity: domainlayer.DomainEntity{Id: channelIdGen.Generate(data.Options.ConnectionId, channel.Id)},
+				Name:         channel.Name,
+				Type:         slackChannelType(channel),
+			}
+			if channel.Creator != "" {
+				domainChannel.CreatorId = accountIdGen.Generate(data.Options.ConnectionId, channel.Creator)
+			}
+			if channel.Created > 0 {
+				created := time.Unix(int64(channel.Created), 0)
+				domainChannel.CreatedDate = &created
+			}
+			return []interface{}{domainChannel}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
+
+func slackChannelType(channel *models.SlackChannel) string {
+	switch {
+	case channel.IsIm || channel.IsMpim:
+		return crossdomain.CHAT_CHANNEL_DIRECT
+	case channel.IsPrivate:
+		return crossdomain.CHAT_CHANNEL_PRIVATE
+	default:
+		return crossdomain.CHAT_CHANNEL_PUBLIC
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertChannelMessage
+
+var ConvertChannelMessageMeta = plugin.SubTaskMeta{
+	Name:             "convertChannelMessage",
+	EntryPoint:       ConvertChannelMessage,
+	EnabledByDefault: true,
+	Description:      "Convert channel messages and thread replies into chat_messages, and link them to mentioned pull requests and issues",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+	DependencyTables: []string{
+		models.SlackChannelMessage{}.TableName(),
+		code.PullRequest{}.TableName(),
+		ticket.Issue{}.TableName(),
+		ticket.BoardIssue{}.TableName(),
+		crossdomain.ProjectMapping{}.TableName(),
+	},
+	ProductTables: []string{
+		crossdomain.ChatMessage{}.TableName(),
+		crossdomain.ChatMessagePullRequest{}.TableName(),
+		crossdomain.ChatMessageIssue{}.TableName(),
+	},
+}
+
+func ConvertChannelMessage(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*SlackTaskData)
+
+	cursor, err := db.Cursor(
+		dal.From(&models.SlackChannelMessage{}),
+		dal.Where("connection_id = ? AND channel_id = ?", data.Options.ConnectionId, data.Options.ChannelId),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	channelIdGen := didgen.NewDomainIdGenerator(&models.SlackChannel{})
+	messageIdGen := didgen.NewDomainIdGenerator(&models.SlackChannelMessage{})
+	accountIdGen := didgen.NewDomainIdGenerator(&models.SlackUser{})
+	linker, err := api.NewChatMessageLinker(db, channelIdGen.Generate(data.Options.ConnectionId, data.Options.ChannelId))
+	if err != nil {
+		return err
+	}
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx:     taskCtx,
+			Options: data.Options,
+			Table:   RAW_CHANNEL_MESSAGE_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.SlackChannelMessage{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			message := inputRow.(*models.SlackChannelMessage)
+			domainMessage := &crossdomain.ChatMessage{
+				DomainEntity: domainlayer.DomainEntity{
+					Id: messageIdGen.Generate(data.Options.ConnectionId, message.ChannelId, message.Ts),
+				},
+				ChannelId:   channelIdGen.Generate(data.Options.ConnectionId, message.ChannelId),
+				Type:        message.Type,
+				Content:     message.Text,
+				ReplyCount:  message.ReplyCount,
+				CreatedDate: parseSlackTs(message.Ts),
+			}
+			if message.Subtype != "" {
+				domainMessage.Type = message.Subtype
+			}
+			if message.User != "" {
+				domainMessage.SenderId = accountIdGen.Generate(data.Options.ConnectionId, message.User)
+			}
+			if message.ThreadTs != "" && message.ThreadTs != message.Ts {
+				domainMessage.ParentMessageId = messageIdGen.Generate(data.Options.ConnectionId, message.ChannelId, message.ThreadTs)
+			}
+			links, err := linker.Link(domainMessage.Id, message.Text)
+			if err != nil {
+				return nil, err
+			}
+			return append([]interface{}{domainMessage}, links...), nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
+
+// parseSlackTs converts slack message timestamps like `1699999999.123456` to time
+func parseSlackTs(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	seconds, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	micros, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+	return time.Unix(seconds, micros*1000).UTC()
+}
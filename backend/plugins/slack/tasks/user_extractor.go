@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/slack/apimodels"
+	"github.com/apache/incubator-devlake/plugins/slack/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ExtractUser
+
+func ExtractUser(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*SlackTaskData)
+	extractor, err := api.NewApiExtractor(api.ApiExtractorArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: SlackApiParams{
+				ConnectionId: data.Options.ConnectionId,
+			},
+			Table: RAW_USER_TABLE,
+		},
+		Extract: func(row *api.RawData) ([]interface{}, errors.Error) {
+			body := &apimodels.SlackUserResultItem{}
+			err := errors.Convert(json.Unmarshal(row.Data, body))
+			if err != nil {
+				return nil, err
+			}
+			user := &models.SlackUser{
+				ConnectionId: data.Options.ConnectionId,
+				Id:           body.Id,
+				TeamId:       body.TeamId,
+				Name:         body.Name,
+				RealName:     body.RealName,
+				DisplayName:  body.Profile.DisplayName,
+				Email:        body.Profile.Email,
+				AvatarUrl:    body.Profile.Image72,
+				IsBot:        body.IsBot,
+				Deleted:      body.Deleted,
+			}
+			if user.RealName == "" {
+				user.RealName = body.Profile.RealName
+			}
+			return []interface{}{user}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return extractor.Execute()
+}
+
+var ExtractUserMeta = plugin.SubTaskMeta{
+	Name:             "extractUser",
+	EntryPoint:       ExtractUser,
+	EnabledByDefault: true,
+	Description:      "Extract raw user data into tool layer table",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+}
@@ -48,6 +48,7 @@ func GetTablesByDomainLayer(domainLayer string) []string {
 		}
 	case "codequality":
 		return []string{
+			"cq_commit_coverages",
 			"cq_file_metrics",
 			"cq_issue_code_blocks",
 			"cq_issues",
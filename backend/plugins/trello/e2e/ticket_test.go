@@ -0,0 +1,108 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/trello/impl"
+	"github.com/apache/incubator-devlake/plugins/trello/models"
+	"github.com/apache/incubator-devlake/plugins/trello/tasks"
+)
+
+func TestTrelloTicketDataFlow(t *testing.T) {
+	var trello impl.Trello
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "trello", trello)
+
+	taskData := &tasks.TrelloTaskData{
+		Options: &tasks.TrelloOptions{
+			ConnectionId: 1,
+			BoardId:      "6402f643d23aa9af56b28f4b",
+			ScopeConfig: &models.TrelloScopeConfig{
+				TypeMappings: map[string]string{
+					"🐞 Bugs": ticket.BUG,
+				},
+				StatusMappings: map[string]string{
+					"📅 Working On":                     ticket.IN_PROGRESS,
+					"🧑🏾‍💻 Testing [Staging Server]":    ticket.IN_PROGRESS,
+					"📆 Sprint - Done [Version: 1.2.0]": ticket.DONE,
+					"🗄 Sprint - Done [Version: 1.1.0]": ticket.DONE,
+				},
+			},
+		},
+	}
+
+	// verify card action extraction
+	dataflowTester.ImportCsvIntoRawTable("./raw_tables/_raw_trello_card_actions.csv", "_raw_trello_card_actions")
+	dataflowTester.FlushTabler(&models.TrelloCardAction{})
+	dataflowTester.Subtask(tasks.ExtractCardActionMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(models.TrelloCardAction{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./snapshot_tables/_tool_trello_card_actions.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+
+	dataflowTester.ImportCsvIntoTabler("./tool_tables/_tool_trello_boards.csv", &models.TrelloBoard{})
+	dataflowTester.ImportCsvIntoTabler("./snapshot_tables/_tool_trello_cards.csv", &models.TrelloCard{})
+	dataflowTester.ImportCsvIntoTabler("./snapshot_tables/_tool_trello_lists.csv", &models.TrelloList{})
+	dataflowTester.ImportCsvIntoTabler("./snapshot_tables/_tool_trello_labels.csv", &models.TrelloLabel{})
+	dataflowTester.FlushTabler(&models.TrelloMember{})
+
+	// verify board conversion
+	dataflowTester.FlushTabler(&ticket.Board{})
+	dataflowTester.Subtask(tasks.ConvertBoardMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&ticket.Board{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/boards.csv",
+		TargetFields: []string{"id", "name", "url", "type"},
+	})
+
+	// verify card conversion, statuses and types come from the list each card sits in
+	dataflowTester.FlushTabler(&ticket.Issue{})
+	dataflowTester.FlushTabler(&ticket.BoardIssue{})
+	dataflowTester.FlushTabler(&ticket.IssueAssignee{})
+	dataflowTester.FlushTabler(&ticket.IssueLabel{})
+	dataflowTester.Subtask(tasks.ConvertCardMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&ticket.Issue{}, e2ehelper.TableOptions{
+		CSVRelPath: "./snapshot_tables/issues.csv",
+		TargetFields: []string{
+			"id", "url", "issue_key", "title", "type", "original_type", "status", "original_status",
+			"resolution_date", "created_date", "updated_date", "lead_time_minutes", "creator_id", "creator_name", "due_date",
+		},
+	})
+	dataflowTester.VerifyTableWithOptions(&ticket.BoardIssue{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/board_issues.csv",
+		TargetFields: []string{"board_id", "issue_id"},
+	})
+	dataflowTester.VerifyTableWithOptions(&ticket.IssueLabel{}, e2ehelper.TableOptions{
+		CSVRelPath:   "./snapshot_tables/issue_labels.csv",
+		TargetFields: []string{"issue_id", "label_name"},
+	})
+
+	// verify list movements are converted into status changelogs
+	dataflowTester.FlushTabler(&ticket.IssueChangelogs{})
+	dataflowTester.Subtask(tasks.ConvertCardChangelogMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&ticket.IssueChangelogs{}, e2ehelper.TableOptions{
+		CSVRelPath: "./snapshot_tables/issue_changelogs.csv",
+		TargetFields: []string{
+			"id", "issue_id", "author_id", "author_name", "field_id", "field_name",
+			"original_from_value", "original_to_value", "from_value", "to_value", "created_date",
+		},
+	})
+}
@@ -61,6 +61,7 @@ func (p Trello) GetTablesInfo() []dal.Tabler {
 		&models.TrelloLabel{},
 		&models.TrelloMember{},
 		&models.TrelloCheckItem{},
+		&models.TrelloCardAction{},
 		&models.TrelloScopeConfig{},
 	}
 }
@@ -89,6 +90,14 @@ func (p Trello) SubTaskMetas() []plugin.SubTaskMeta {
 
 		tasks.CollectMemberMeta,
 		tasks.ExtractMemberMeta,
+
+		tasks.CollectCardActionMeta,
+		tasks.ExtractCardActionMeta,
+
+		tasks.ConvertBoardMeta,
+		tasks.ConvertAccountMeta,
+		tasks.ConvertCardMeta,
+		tasks.ConvertCardChangelogMeta,
 	}
 }
 
@@ -120,6 +129,24 @@ func (p Trello) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]i
 	if err != nil {
 		return nil, err
 	}
+
+	if op.ScopeConfigId == 0 {
+		board := &models.TrelloBoard{}
+		err = taskCtx.GetDal().First(board, dal.Where("connection_id = ? AND board_id = ?", op.ConnectionId, op.BoardId))
+		if err != nil && !taskCtx.GetDal().IsErrorNotFound(err) {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("fail to find board: %s", op.BoardId))
+		}
+		op.ScopeConfigId = board.ScopeConfigId
+	}
+	if op.ScopeConfig == nil && op.ScopeConfigId != 0 {
+		err = taskCtx.GetDal().First(&op.ScopeConfig, dal.Where("id = ?", op.ScopeConfigId))
+		if err != nil && taskCtx.GetDal().IsErrorNotFound(err) {
+			return nil, errors.BadInput.Wrap(err, "fail to get scopeConfig")
+		}
+	}
+	if op.ScopeConfig == nil {
+		op.ScopeConfig = &models.TrelloScopeConfig{}
+	}
 	return &tasks.TrelloTaskData{
 		Options:   &op,
 		ApiClient: apiClient,
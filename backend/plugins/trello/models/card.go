@@ -23,6 +23,7 @@ import (
 )
 
 type TrelloCard struct {
+	ConnectionId     uint64 `gorm:"primaryKey"`
 	ID               string `gorm:"primaryKey;type:varchar(255)"`
 	Name             string `gorm:"type:varchar(255)"`
 	Closed           bool
@@ -36,6 +37,10 @@ type TrelloCard struct {
 	ShortUrl         string `gorm:"type:varchar(255)"`
 	Subscribed       bool
 	Url              string `gorm:"type:varchar(255)"`
+	Desc             string
+	Due              *time.Time
+	IDMembers        []string `gorm:"type:json;serializer:json"`
+	IDLabels         []string `gorm:"type:json;serializer:json"`
 	common.NoPKModel
 }
 
@@ -0,0 +1,45 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// TrelloCardAction is a card creation or a movement of a card between lists
+type TrelloCardAction struct {
+	ConnectionId      uint64 `gorm:"primaryKey"`
+	ID                string `gorm:"primaryKey;type:varchar(255)"`
+	Type              string `gorm:"type:varchar(100)"`
+	Date              time.Time
+	IDBoard           string `gorm:"type:varchar(255)"`
+	IDCard            string `gorm:"index;type:varchar(255)"`
+	IDMemberCreator   string `gorm:"type:varchar(255)"`
+	MemberCreatorName string `gorm:"type:varchar(255)"`
+	ListBeforeId      string `gorm:"type:varchar(255)"`
+	ListBeforeName    string `gorm:"type:varchar(255)"`
+	ListAfterId       string `gorm:"type:varchar(255)"`
+	ListAfterName     string `gorm:"type:varchar(255)"`
+	common.NoPKModel
+}
+
+func (TrelloCardAction) TableName() string {
+	return "_tool_trello_card_actions"
+}
@@ -22,6 +22,7 @@ import (
 )
 
 type TrelloCheckItem struct {
+	ConnectionId  uint64 `gorm:"primaryKey"`
 	ID            string `gorm:"primaryKey;type:varchar(255)"`
 	Name          string `gorm:"type:varchar(255)"`
 	State         string `gorm:"type:varchar(255)"`
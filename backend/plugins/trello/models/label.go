@@ -20,10 +20,11 @@ package models
 import "github.com/apache/incubator-devlake/core/models/common"
 
 type TrelloLabel struct {
-	ID      string `gorm:"primaryKey;type:varchar(255)"`
-	IDBoard string `gorm:"type:varchar(255)"`
-	Name    string `gorm:"type:varchar(255)"`
-	Color   string `gorm:"type:varchar(255)"`
+	ConnectionId uint64 `gorm:"primaryKey"`
+	ID           string `gorm:"primaryKey;type:varchar(255)"`
+	IDBoard      string `gorm:"type:varchar(255)"`
+	Name         string `gorm:"type:varchar(255)"`
+	Color        string `gorm:"type:varchar(255)"`
 	common.NoPKModel
 }
 
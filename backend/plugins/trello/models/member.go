@@ -20,9 +20,10 @@ package models
 import "github.com/apache/incubator-devlake/core/models/common"
 
 type TrelloMember struct {
-	ID       string `gorm:"primaryKey;type:varchar(255)"`
-	FullName string `gorm:"type:varchar(255)"`
-	Username string `gorm:"type:varchar(255)"`
+	ConnectionId uint64 `gorm:"primaryKey"`
+	ID           string `gorm:"primaryKey;type:varchar(255)"`
+	FullName     string `gorm:"type:varchar(255)"`
+	Username     string `gorm:"type:varchar(255)"`
 	common.NoPKModel
 }
 
@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+var _ plugin.MigrationScript = (*addConnectionIdToToolTables)(nil)
+
+type connectionId20261017 struct {
+	ConnectionId uint64
+}
+
+type boardParams20261017 struct {
+	ConnectionId uint64
+	BoardId      string
+}
+
+// addConnectionIdToToolTables adds connection_id to the primary keys of the tool tables, so that connections seeing
+// the same boards don't overwrite each other's rows. Existing rows get the connection of the board they were
+// collected for
+type addConnectionIdToToolTables struct{}
+
+func (*addConnectionIdToToolTables) Up(basicRes context.BasicRes) errors.Error {
+	db := basicRes.GetDal()
+	dbUrl := basicRes.GetConfig("DB_URL")
+	if dbUrl == "" {
+		return errors.BadInput.New("DB_URL is required")
+	}
+	u, err1 := url.Parse(dbUrl)
+	if err1 != nil {
+		return errors.Convert(err1)
+	}
+	var boards []boardParams20261017
+	err := db.All(&boards, dal.Select("connection_id, board_id"), dal.From("_tool_trello_boards"))
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{
+		"_tool_trello_cards",
+		"_tool_trello_card_actions",
+		"_tool_trello_check_items",
+		"_tool_trello_labels",
+		"_tool_trello_lists",
+		"_tool_trello_members",
+	} {
+		err = db.AutoMigrate(&connectionId20261017{}, dal.From(table))
+		if err != nil {
+			return err
+		}
+		for _, board := range boards {
+			err = db.UpdateColumn(
+				table, "connection_id", board.ConnectionId,
+				dal.Where("_raw_data_params = ?", plugin.MarshalScopeParams(board)),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		err = db.UpdateColumn(table, "connection_id", 0, dal.Where("connection_id IS NULL"))
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "mysql":
+			err = db.Exec(fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", table))
+		case "postgresql", "postgres", "pg":
+			err = db.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s_pkey", table, table))
+		}
+		if err != nil {
+			return err
+		}
+		err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (connection_id, id)", table))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*addConnectionIdToToolTables) Version() uint64 {
+	return 20261017000002
+}
+
+func (*addConnectionIdToToolTables) Name() string {
+	return "add connection_id to the primary keys of trello tool tables"
+}
@@ -0,0 +1,94 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	coreArchived "github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/plugins/trello/models/migrationscripts/archived"
+)
+
+var _ plugin.MigrationScript = (*addTicketConversion)(nil)
+
+type card20261017 struct {
+	Desc      string
+	Due       *time.Time
+	IDMembers []string `gorm:"type:json;serializer:json"`
+	IDLabels  []string `gorm:"type:json;serializer:json"`
+}
+
+func (card20261017) TableName() string {
+	return "_tool_trello_cards"
+}
+
+type scopeConfig20261017 struct {
+	TypeMappings   map[string]string `gorm:"serializer:json"`
+	StatusMappings map[string]string `gorm:"serializer:json"`
+}
+
+func (scopeConfig20261017) TableName() string {
+	return "_tool_trello_scope_configs"
+}
+
+// board20261017 adds board_id to the primary key, boards used to be keyed by connection only
+type board20261017 struct {
+	coreArchived.NoPKModel
+	ConnectionId  uint64 `gorm:"primaryKey"`
+	BoardId       string `gorm:"primaryKey;type:varchar(255)"`
+	ScopeConfigId uint64
+	Name          string `gorm:"type:varchar(255)"`
+}
+
+func (board20261017) TableName() string {
+	return "_tool_trello_boards"
+}
+
+type addTicketConversion struct{}
+
+func (script *addTicketConversion) Up(basicRes context.BasicRes) errors.Error {
+	err := migrationhelper.TransformTable(
+		basicRes,
+		script,
+		board20261017{}.TableName(),
+		func(src *board20261017) (*board20261017, errors.Error) {
+			return src, nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&card20261017{},
+		&scopeConfig20261017{},
+		&archived.TrelloCardAction{},
+	)
+}
+
+func (*addTicketConversion) Version() uint64 {
+	return 20261017000001
+}
+
+func (*addTicketConversion) Name() string {
+	return "add card details, card actions and issue mappings for trello"
+}
@@ -23,6 +23,10 @@ import (
 
 type TrelloScopeConfig struct {
 	common.ScopeConfig `mapstructure:",squash" json:",inline" gorm:"embedded"`
+	// TypeMappings maps list names to standard issue types, cards in other lists are TASK
+	TypeMappings map[string]string `mapstructure:"typeMappings,omitempty" json:"typeMappings" gorm:"serializer:json"`
+	// StatusMappings maps list names to TODO, IN_PROGRESS or DONE, cards in other lists are TODO
+	StatusMappings map[string]string `mapstructure:"statusMappings,omitempty" json:"statusMappings" gorm:"serializer:json"`
 }
 
 func (TrelloScopeConfig) TableName() string {
@@ -0,0 +1,90 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/trello/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertAccount
+
+var ConvertAccountMeta = plugin.SubTaskMeta{
+	Name:             "ConvertAccount",
+	EntryPoint:       ConvertAccount,
+	EnabledByDefault: true,
+	Description:      "Convert tool layer table trello_members into domain layer table accounts",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS, plugin.DOMAIN_TYPE_TICKET},
+}
+
+func ConvertAccount(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TrelloTaskData)
+	db := taskCtx.GetDal()
+	params := TrelloApiParams{
+		ConnectionId: data.Options.ConnectionId,
+		BoardId:      data.Options.BoardId,
+	}
+
+	// members are shared across boards, so they are picked by the board they were collected from
+	cursor, err := db.Cursor(
+		dal.From(&models.TrelloMember{}),
+		dal.Where(
+			"connection_id = ? AND _raw_data_table = ? AND _raw_data_params = ?",
+			data.Options.ConnectionId, "_raw_"+RAW_MEMBER_TABLE, plugin.MarshalScopeParams(params),
+		),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	accountIdGen := didgen.NewDomainIdGenerator(&models.TrelloMember{})
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx:    taskCtx,
+			Params: params,
+			Table:  RAW_MEMBER_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.TrelloMember{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			member := inputRow.(*models.TrelloMember)
+			account := &crossdomain.Account{
+				DomainEntity: domainlayer.DomainEntity{
+					Id: accountIdGen.Generate(member.ConnectionId, member.ID),
+				},
+				UserName: member.Username,
+				FullName: member.FullName,
+			}
+			return []interface{}{account}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
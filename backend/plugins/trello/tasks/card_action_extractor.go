@@ -0,0 +1,115 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/trello/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ExtractCardAction
+
+var ExtractCardActionMeta = plugin.SubTaskMeta{
+	Name:             "ExtractCardAction",
+	EntryPoint:       ExtractCardAction,
+	EnabledByDefault: true,
+	Description:      "Extract raw data into tool layer table trello_card_actions",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
+}
+
+type trelloApiListRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type TrelloApiCardAction struct {
+	ID              string    `json:"id"`
+	IDMemberCreator string    `json:"idMemberCreator"`
+	Type            string    `json:"type"`
+	Date            time.Time `json:"date"`
+	Data            struct {
+		Card struct {
+			ID string `json:"id"`
+		} `json:"card"`
+		Board struct {
+			ID string `json:"id"`
+		} `json:"board"`
+		List       *trelloApiListRef `json:"list"`
+		ListBefore *trelloApiListRef `json:"listBefore"`
+		ListAfter  *trelloApiListRef `json:"listAfter"`
+	} `json:"data"`
+	MemberCreator struct {
+		FullName string `json:"fullName"`
+	} `json:"memberCreator"`
+}
+
+func ExtractCardAction(taskCtx plugin.SubTaskContext) errors.Error {
+	taskData := taskCtx.GetData().(*TrelloTaskData)
+
+	extractor, err := api.NewApiExtractor(api.ApiExtractorArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: TrelloApiParams{
+				ConnectionId: taskData.Options.ConnectionId,
+				BoardId:      taskData.Options.BoardId,
+			},
+			Table: RAW_CARD_ACTION_TABLE,
+		},
+		Extract: func(resData *api.RawData) ([]interface{}, errors.Error) {
+			apiAction := &TrelloApiCardAction{}
+			err := errors.Convert(json.Unmarshal(resData.Data, apiAction))
+			if err != nil {
+				return nil, err
+			}
+			action := &models.TrelloCardAction{
+				ConnectionId:      taskData.Options.ConnectionId,
+				ID:                apiAction.ID,
+				Type:              apiAction.Type,
+				Date:              apiAction.Date,
+				IDBoard:           apiAction.Data.Board.ID,
+				IDCard:            apiAction.Data.Card.ID,
+				IDMemberCreator:   apiAction.IDMemberCreator,
+				MemberCreatorName: apiAction.MemberCreator.FullName,
+			}
+			// cards are created into `list`, and moved from `listBefore` to `listAfter`
+			if apiAction.Data.List != nil {
+				action.ListAfterId = apiAction.Data.List.ID
+				action.ListAfterName = apiAction.Data.List.Name
+			}
+			if apiAction.Data.ListBefore != nil {
+				action.ListBeforeId = apiAction.Data.ListBefore.ID
+				action.ListBeforeName = apiAction.Data.ListBefore.Name
+			}
+			if apiAction.Data.ListAfter != nil {
+				action.ListAfterId = apiAction.Data.ListAfter.ID
+				action.ListAfterName = apiAction.Data.ListAfter.Name
+			}
+			return []interface{}{action}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return extractor.Execute()
+}
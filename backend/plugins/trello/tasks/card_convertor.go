@@ -0,0 +1,252 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/trello/models"
+)
+
+var _ plugin.SubTaskEntryPoint = ConvertCard
+
+var ConvertCardMeta = plugin.SubTaskMeta{
+	Name:             "ConvertCard",
+	EntryPoint:       ConvertCard,
+	EnabledByDefault: true,
+	Description:      "Convert tool layer table trello_cards into domain layer table issues, board_issues, issue_assignees and issue_labels",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
+	DependencyTables: []string{
+		models.TrelloCard{}.TableName(),
+		models.TrelloList{}.TableName(),
+		models.TrelloLabel{}.TableName(),
+		models.TrelloMember{}.TableName(),
+		models.TrelloCardAction{}.TableName(),
+	},
+	ProductTables: []string{
+		ticket.Issue{}.TableName(),
+		ticket.BoardIssue{}.TableName(),
+		ticket.IssueAssignee{}.TableName(),
+		ticket.IssueLabel{}.TableName(),
+	},
+}
+
+func ConvertCard(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TrelloTaskData)
+	db := taskCtx.GetDal()
+	params := TrelloApiParams{
+		ConnectionId: data.Options.ConnectionId,
+		BoardId:      data.Options.BoardId,
+	}
+
+	listNames, err := loadTrelloNames(db, &models.TrelloList{}, data.Options.ConnectionId, data.Options.BoardId)
+	if err != nil {
+		return err
+	}
+	labelNames, err := loadTrelloNames(db, &models.TrelloLabel{}, data.Options.ConnectionId, data.Options.BoardId)
+	if err != nil {
+		return err
+	}
+	var members []models.TrelloMember
+	err = db.All(
+		&members,
+		dal.Where(
+			"connection_id = ? AND _raw_data_table = ? AND _raw_data_params = ?",
+			data.Options.ConnectionId, "_raw_"+RAW_MEMBER_TABLE, plugin.MarshalScopeParams(params),
+		),
+	)
+	if err != nil {
+		return err
+	}
+	memberNames := make(map[string]string, len(members))
+	for _, member := range members {
+		memberNames[member.ID] = member.FullName
+	}
+	// actions are sorted by date, so later moves overwrite earlier ones
+	var actions []models.TrelloCardAction
+	err = db.All(&actions, dal.Where("connection_id = ? AND id_board = ?", data.Options.ConnectionId, data.Options.BoardId), dal.Orderby("date"))
+	if err != nil {
+		return err
+	}
+	creators := make(map[string]*models.TrelloCardAction)
+	movedIntoListAt := make(map[string]time.Time)
+	for i, action := range actions {
+		if action.Type == "createCard" {
+			creators[action.IDCard] = &actions[i]
+		}
+		if action.ListAfterId != "" {
+			movedIntoListAt[action.IDCard+":"+action.ListAfterId] = action.Date
+		}
+	}
+
+	cursor, err := db.Cursor(
+		dal.From(&models.TrelloCard{}),
+		dal.Where("connection_id = ? AND id_board = ?", data.Options.ConnectionId, data.Options.BoardId),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	boardId := didgen.NewDomainIdGenerator(&models.TrelloBoard{}).Generate(data.Options.ConnectionId, data.Options.BoardId)
+	cardIdGen := didgen.NewDomainIdGenerator(&models.TrelloCard{})
+	accountIdGen := didgen.NewDomainIdGenerator(&models.TrelloMember{})
+	converter, err := api.NewDataConverter(api.DataConverterArgs{
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx:    taskCtx,
+			Params: params,
+			Table:  RAW_CARD_TABLE,
+		},
+		InputRowType: reflect.TypeOf(models.TrelloCard{}),
+		Input:        cursor,
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			card := inputRow.(*models.TrelloCard)
+			listName := listNames[card.IDList]
+			issue := &ticket.Issue{
+				DomainEntity: domainlayer.DomainEntity{
+					Id: cardIdGen.Generate(card.ConnectionId, card.ID),
+				},
+				Url:            card.Url,
+				IssueKey:       strconv.Itoa(card.IDShort),
+				Title:          card.Name,
+				Description:    card.Desc,
+				OriginalType:   "card",
+				Type:           trelloIssueType(data.Options.ScopeConfig, listName),
+				OriginalStatus: listName,
+				Status:         trelloIssueStatus(data.Options.ScopeConfig, listName),
+				CreatedDate:    trelloIdTime(card.ID),
+				DueDate:        card.Due,
+			}
+			if !card.DateLastActivity.IsZero() {
+				updatedDate := card.DateLastActivity
+				issue.UpdatedDate = &updatedDate
+			}
+			if creator, ok := creators[card.ID]; ok {
+				issue.CreatorId = accountIdGen.Generate(card.ConnectionId, creator.IDMemberCreator)
+				issue.CreatorName = creator.MemberCreatorName
+			}
+			if issue.Status == ticket.DONE {
+				resolutionDate, ok := movedIntoListAt[card.ID+":"+card.IDList]
+				if !ok {
+					resolutionDate = card.DateLastActivity
+				}
+				if !resolutionDate.IsZero() {
+					issue.ResolutionDate = &resolutionDate
+				}
+				if issue.ResolutionDate != nil && issue.CreatedDate != nil && issue.ResolutionDate.After(*issue.CreatedDate) {
+					leadTimeMinutes := uint(issue.ResolutionDate.Sub(*issue.CreatedDate).Minutes())
+					issue.LeadTimeMinutes = &leadTimeMinutes
+				}
+			}
+
+			result := []interface{}{issue}
+			for i, memberId := range card.IDMembers {
+				assignee := &ticket.IssueAssignee{
+					IssueId:      issue.Id,
+					AssigneeId:   accountIdGen.Generate(card.ConnectionId, memberId),
+					AssigneeName: memberNames[memberId],
+				}
+				if i == 0 {
+					issue.AssigneeId = assignee.AssigneeId
+					issue.AssigneeName = assignee.AssigneeName
+				}
+				result = append(result, assignee)
+			}
+			for _, labelId := range card.IDLabels {
+				labelName := labelNames[labelId]
+				if labelName == "" {
+					continue
+				}
+				result = append(result, &ticket.IssueLabel{
+					IssueId:   issue.Id,
+					LabelName: labelName,
+				})
+			}
+			result = append(result, &ticket.BoardIssue{
+				BoardId: boardId,
+				IssueId: issue.Id,
+			})
+			return result, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
+
+// loadTrelloNames returns the names of lists or labels of the board keyed by their ids
+func loadTrelloNames(db dal.Dal, table dal.Tabler, connectionId uint64, boardId string) (map[string]string, errors.Error) {
+	var rows []struct {
+		ID   string
+		Name string
+	}
+	err := db.All(&rows, dal.Select("id, name"), dal.From(table), dal.Where("connection_id = ? AND id_board = ?", connectionId, boardId))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+// trelloIssueType maps the list a card sits in to a standard issue type, TASK by default
+func trelloIssueType(scopeConfig *models.TrelloScopeConfig, listName string) string {
+	if scopeConfig != nil {
+		if issueType, ok := scopeConfig.TypeMappings[listName]; ok && issueType != "" {
+			return strings.ToUpper(issueType)
+		}
+	}
+	return ticket.TASK
+}
+
+// trelloIssueStatus maps the list a card sits in to a standard issue status, TODO by default
+func trelloIssueStatus(scopeConfig *models.TrelloScopeConfig, listName string) string {
+	if scopeConfig != nil {
+		if status, ok := scopeConfig.StatusMappings[listName]; ok && status != "" {
+			return strings.ToUpper(status)
+		}
+	}
+	return ticket.TODO
+}
+
+// trelloIdTime extracts the creation time embedded in the first 8 hex digits of a trello object id
+func trelloIdTime(id string) *time.Time {
+	if len(id) < 8 {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(id[:8], 16, 64)
+	if err != nil {
+		return nil
+	}
+	createdDate := time.Unix(seconds, 0).UTC()
+	return &createdDate
+}
@@ -33,6 +33,7 @@ var ExtractCardMeta = plugin.SubTaskMeta{
 	EntryPoint:       ExtractCard,
 	EnabledByDefault: true,
 	Description:      "Extract raw data into tool layer table trello_cards",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 type TrelloApiCard struct {
@@ -44,7 +45,7 @@ type TrelloApiCard struct {
 	DateLastActivity      time.Time     `json:"dateLastActivity"`
 	Desc                  string        `json:"desc"`
 	DescData              interface{}   `json:"descData"`
-	Due                   interface{}   `json:"due"`
+	Due                   *time.Time    `json:"due"`
 	DueReminder           interface{}   `json:"dueReminder"`
 	Email                 interface{}   `json:"email"`
 	IDBoard               string        `json:"idBoard"`
@@ -89,6 +90,7 @@ func ExtractCard(taskCtx plugin.SubTaskContext) errors.Error {
 			}
 			return []interface{}{
 				&models.TrelloCard{
+					ConnectionId:     taskData.Options.ConnectionId,
 					ID:               apiCard.ID,
 					Name:             apiCard.Name,
 					Closed:           apiCard.Closed,
@@ -102,6 +104,10 @@ func ExtractCard(taskCtx plugin.SubTaskContext) errors.Error {
 					ShortUrl:         apiCard.ShortUrl,
 					Subscribed:       apiCard.Subscribed,
 					Url:              apiCard.Url,
+					Desc:             apiCard.Desc,
+					Due:              apiCard.Due,
+					IDMembers:        apiCard.IDMembers,
+					IDLabels:         apiCard.IDLabels,
 				},
 			}, nil
 		},
@@ -32,6 +32,7 @@ var ExtractCheckItemMeta = plugin.SubTaskMeta{
 	EntryPoint:       ExtractCheckItem,
 	EnabledByDefault: true,
 	Description:      "Extract raw data into tool layer table trello_check_items",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 type TrelloApiChecklist struct {
@@ -76,6 +77,7 @@ func ExtractCheckItem(taskCtx plugin.SubTaskContext) errors.Error {
 			results := make([]interface{}, 0)
 			for _, item := range apiCheckItem.CheckItems {
 				results = append(results, &models.TrelloCheckItem{
+					ConnectionId:  taskData.Options.ConnectionId,
 					ID:            item.ID,
 					Name:          item.Name,
 					State:         item.State,
@@ -32,6 +32,7 @@ var ExtractLabelMeta = plugin.SubTaskMeta{
 	EntryPoint:       ExtractLabel,
 	EnabledByDefault: true,
 	Description:      "Extract raw data into tool layer table trello_labels",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 type TrelloApiLabel struct {
@@ -61,10 +62,11 @@ func ExtractLabel(taskCtx plugin.SubTaskContext) errors.Error {
 			}
 			return []interface{}{
 				&models.TrelloLabel{
-					ID:      apiLabel.ID,
-					IDBoard: apiLabel.IDBoard,
-					Name:    apiLabel.Name,
-					Color:   apiLabel.Color,
+					ConnectionId: taskData.Options.ConnectionId,
+					ID:           apiLabel.ID,
+					IDBoard:      apiLabel.IDBoard,
+					Name:         apiLabel.Name,
+					Color:        apiLabel.Color,
 				},
 			}, nil
 		},
@@ -34,7 +34,7 @@ var CollectListMeta = plugin.SubTaskMeta{
 	EntryPoint:       CollectList,
 	EnabledByDefault: true,
 	Description:      "Collect list data from Trello api",
-	DomainTypes:      []string{},
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 func CollectList(taskCtx plugin.SubTaskContext) errors.Error {
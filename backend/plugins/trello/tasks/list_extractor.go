@@ -32,6 +32,7 @@ var ExtractListMeta = plugin.SubTaskMeta{
 	EntryPoint:       ExtractList,
 	EnabledByDefault: true,
 	Description:      "Extract raw data into tool layer table trello_lists",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 type TrelloApiList struct {
@@ -64,11 +65,12 @@ func ExtractList(taskCtx plugin.SubTaskContext) errors.Error {
 			}
 			return []interface{}{
 				&models.TrelloList{
-					ID:         apiList.ID,
-					Name:       apiList.Name,
-					IDBoard:    apiList.IDBoard,
-					Subscribed: apiList.Subscribed,
-					Pos:        apiList.Pos,
+					ConnectionId: taskData.Options.ConnectionId,
+					ID:           apiList.ID,
+					Name:         apiList.Name,
+					IDBoard:      apiList.IDBoard,
+					Subscribed:   apiList.Subscribed,
+					Pos:          apiList.Pos,
 				},
 			}, nil
 		},
@@ -34,7 +34,7 @@ var CollectMemberMeta = plugin.SubTaskMeta{
 	EntryPoint:       CollectMember,
 	EnabledByDefault: true,
 	Description:      "Collect member data from Trello api",
-	DomainTypes:      []string{},
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 func CollectMember(taskCtx plugin.SubTaskContext) errors.Error {
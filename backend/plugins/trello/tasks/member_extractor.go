@@ -32,6 +32,7 @@ var ExtractMemberMeta = plugin.SubTaskMeta{
 	EntryPoint:       ExtractMember,
 	EnabledByDefault: true,
 	Description:      "Extract raw data into tool layer table trello_members",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_TICKET},
 }
 
 type TrelloApiMember struct {
@@ -60,9 +61,10 @@ func ExtractMember(taskCtx plugin.SubTaskContext) errors.Error {
 			}
 			return []interface{}{
 				&models.TrelloMember{
-					ID:       apiMember.ID,
-					FullName: apiMember.FullName,
-					Username: apiMember.Username,
+					ConnectionId: taskData.Options.ConnectionId,
+					ID:           apiMember.ID,
+					FullName:     apiMember.FullName,
+					Username:     apiMember.Username,
 				},
 			}, nil
 		},
@@ -24,6 +24,7 @@ import (
 	coreModels "github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/models/domainlayer"
 	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/codequality"
 	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
 	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
 	"github.com/apache/incubator-devlake/core/plugin"
@@ -63,5 +64,13 @@ func MakeDataSourcePipelinePlanV200(connectionId uint64) (coreModels.PipelinePla
 		Name: connection.Name,
 	})
 
+	// add cq_project to scopes, coverage reports are saved into it
+	scopes = append(scopes, &codequality.CqProject{
+		DomainEntityExtended: domainlayer.DomainEntityExtended{
+			Id: fmt.Sprintf("%s:%d", "webhook", connection.ID),
+		},
+		Name: connection.Name,
+	})
+
 	return nil, scopes, nil
 }
@@ -185,6 +185,7 @@ type WebhookConnectionResponse struct {
 	PostIssuesEndpoint             string             `json:"postIssuesEndpoint"`
 	CloseIssuesEndpoint            string             `json:"closeIssuesEndpoint"`
 	PostPullRequestsEndpoint       string             `json:"postPullRequestsEndpoint"`
+	PostCoverageEndpoint           string             `json:"postCoverageEndpoint"`
 	PostPipelineTaskEndpoint       string             `json:"postPipelineTaskEndpoint"`
 	PostPipelineDeployTaskEndpoint string             `json:"postPipelineDeployTaskEndpoint"`
 	ClosePipelineEndpoint          string             `json:"closePipelineEndpoint"`
@@ -258,6 +259,7 @@ func formatConnection(connection *models.WebhookConnection, withApiKeyInfo bool)
 	response.PostIssuesEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/issues`, connection.ID)
 	response.CloseIssuesEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/issue/:issueKey/close`, connection.ID)
 	response.PostPullRequestsEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/pull_requests`, connection.ID)
+	response.PostCoverageEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/coverage`, connection.ID)
 	response.PostPipelineTaskEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/cicd_tasks`, connection.ID)
 	response.PostPipelineDeployTaskEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/deployments`, connection.ID)
 	response.ClosePipelineEndpoint = fmt.Sprintf(`/rest/plugins/webhook/connections/%d/cicd_pipeline/:pipelineName/finish`, connection.ID)
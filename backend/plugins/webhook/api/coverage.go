@@ -0,0 +1,280 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/codequality"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/webhook/models"
+	"github.com/go-playground/validator/v10"
+)
+
+type WebhookCoverageReq struct {
+	// either RepoId or RepoUrl identifies the repo
+	RepoId      string     `mapstructure:"repoId"`
+	RepoUrl     string     `mapstructure:"repoUrl"`
+	CommitSha   string     `mapstructure:"commitSha" validate:"required"`
+	Format      string     `mapstructure:"format" validate:"omitempty,oneof=cobertura lcov gocover"`
+	Report      string     `mapstructure:"report" validate:"required"`
+	CreatedDate *time.Time `mapstructure:"createdDate"`
+}
+
+type WebhookCoverageResp struct {
+	ProjectKey      string  `json:"projectKey"`
+	CommitSha       string  `json:"commitSha"`
+	Format          string  `json:"format"`
+	FileCount       int     `json:"fileCount"`
+	LinesToCover    int     `json:"linesToCover"`
+	CoveredLines    int     `json:"coveredLines"`
+	BranchesToCover int     `json:"branchesToCover"`
+	CoveredBranches int     `json:"coveredBranches"`
+	LineCoverage    float64 `json:"lineCoverage"`
+	BranchCoverage  float64 `json:"branchCoverage"`
+	Coverage        float64 `json:"coverage"`
+}
+
+// PostCoverage
+// @Summary upload a coverage report by webhook
+// @Description Upload a Cobertura XML, LCOV or Go coverprofile report of a commit.<br/>
+// @Description Accepts either a json body with the report content in `report`, or a multipart form with the report in `file`.<br/>
+// @Description example: {"repoUrl":"https://github.com/apache/incubator-devlake","commitSha":"015e3d3b480e417aede5a1293bd61de9b0fd051d","format":"lcov","report":"SF:main.go\nDA:1,1\nend_of_record"}<br/>
+// @Description Per-file coverage is saved into cq_file_metrics and the commit totals into cq_commit_coverages
+// @Tags plugins/webhook
+// @Param body body WebhookCoverageReq true "json body"
+// @Success 200  {object} WebhookCoverageResp
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 403  {string} errcode.Error "Forbidden"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /plugins/webhook/connections/:connectionId/coverage [POST]
+func PostCoverage(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	connection := &models.WebhookConnection{}
+	err := connectionHelper.First(connection, input.Params)
+
+	return postCoverage(input, connection, err)
+}
+
+// PostCoverageByName
+// @Summary upload a coverage report by webhook name
+// @Description Upload a Cobertura XML, LCOV or Go coverprofile report of a commit.<br/>
+// @Description Accepts either a json body with the report content in `report`, or a multipart form with the report in `file`.<br/>
+// @Description Per-file coverage is saved into cq_file_metrics and the commit totals into cq_commit_coverages
+// @Tags plugins/webhook
+// @Param body body WebhookCoverageReq true "json body"
+// @Success 200  {object} WebhookCoverageResp
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 403  {string} errcode.Error "Forbidden"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /plugins/webhook/connections/by-name/:connectionName/coverage [POST]
+func PostCoverageByName(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	connection := &models.WebhookConnection{}
+	err := connectionHelper.FirstByName(connection, input.Params)
+
+	return postCoverage(input, connection, err)
+}
+
+func postCoverage(input *plugin.ApiResourceInput, connection *models.WebhookConnection, err errors.Error) (*plugin.ApiResourceOutput, errors.Error) {
+	if err != nil {
+		return nil, err
+	}
+	request := &WebhookCoverageReq{}
+	if input.Request != nil {
+		err = readCoverageForm(input, request)
+	} else {
+		err = api.DecodeMapStruct(input.Body, request, true)
+	}
+	if err != nil {
+		return &plugin.ApiResourceOutput{Body: err.Error(), Status: http.StatusBadRequest}, nil
+	}
+	// validate
+	vld = validator.New()
+	err = errors.Convert(vld.Struct(request))
+	if err != nil {
+		return nil, errors.BadInput.Wrap(vld.Struct(request), `input json error`)
+	}
+	if request.RepoId == "" && request.RepoUrl == "" {
+		return nil, errors.BadInput.New("either repoId or repoUrl is required")
+	}
+	files, err := ParseCoverageReport(request.Format, []byte(request.Report))
+	if err != nil {
+		return nil, err
+	}
+	if request.Format == "" {
+		request.Format, _ = DetectCoverageFormat([]byte(request.Report))
+	}
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	resp, err := SaveCoverage(connection, request, files, tx)
+	if err != nil {
+		logger.Error(err, "save coverage")
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: resp, Status: http.StatusOK}, nil
+}
+
+// readCoverageForm fills the request from a multipart form upload
+func readCoverageForm(input *plugin.ApiResourceInput, request *WebhookCoverageReq) errors.Error {
+	if input.Request.MultipartForm == nil {
+		if err := input.Request.ParseMultipartForm(32 << 20); err != nil {
+			return errors.BadInput.Wrap(err, "failed to parse multipart form")
+		}
+	}
+	request.RepoId = strings.TrimSpace(input.Request.FormValue("repoId"))
+	request.RepoUrl = strings.TrimSpace(input.Request.FormValue("repoUrl"))
+	request.CommitSha = strings.TrimSpace(input.Request.FormValue("commitSha"))
+	request.Format = strings.TrimSpace(input.Request.FormValue("format"))
+	if createdDate := strings.TrimSpace(input.Request.FormValue("createdDate")); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return errors.BadInput.Wrap(err, "createdDate should be in RFC3339 format")
+		}
+		request.CreatedDate = &t
+	}
+	file, _, err := input.Request.FormFile("file")
+	if err != nil {
+		return errors.BadInput.Wrap(err, "missing file")
+	}
+	// nolint
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return errors.BadInput.Wrap(err, "failed to read file")
+	}
+	request.Report = string(content)
+	return nil
+}
+
+// SaveCoverage replaces the file metrics of the repo with the given coverage and records the totals of the commit
+func SaveCoverage(connection *models.WebhookConnection, request *WebhookCoverageReq, files []*FileCoverage, tx dal.Transaction) (*WebhookCoverageResp, errors.Error) {
+	createdDate := time.Now()
+	if request.CreatedDate != nil {
+		createdDate = *request.CreatedDate
+	}
+	projectKey := fmt.Sprintf("%s:%d", "webhook", connection.ID)
+	repoKey := request.RepoId
+	if repoKey == "" {
+		repoKey = fmt.Sprintf("%x", md5.Sum([]byte(request.RepoUrl)))[:16]
+	}
+	filePrefix := fmt.Sprintf("%s:%s:", projectKey, repoKey)
+
+	resp := &WebhookCoverageResp{
+		ProjectKey: projectKey,
+		CommitSha:  request.CommitSha,
+		Format:     request.Format,
+		FileCount:  len(files),
+	}
+	fileMetrics := make([]*codequality.CqFileMetrics, 0, len(files))
+	for _, file := range files {
+		linesToCover, coveredLines := file.LinesToCover(), file.CoveredLines()
+		resp.LinesToCover += linesToCover
+		resp.CoveredLines += coveredLines
+		resp.BranchesToCover += file.BranchesToCover
+		resp.CoveredBranches += file.CoveredBranches
+		fileMetrics = append(fileMetrics, &codequality.CqFileMetrics{
+			DomainEntity: domainlayer.DomainEntity{
+				Id: GenerateCoverageFileId(filePrefix, file.Path),
+			},
+			ProjectKey:        projectKey,
+			FileName:          path.Base(file.Path),
+			FilePath:          file.Path,
+			UncoveredLines:    linesToCover - coveredLines,
+			LinesToCover:      linesToCover,
+			BranchesToCover:   file.BranchesToCover,
+			UncoveredBranches: file.BranchesToCover - file.CoveredBranches,
+			LineCoverage:      coverageRate(coveredLines, linesToCover),
+			BranchCoverage:    coverageRate(file.CoveredBranches, file.BranchesToCover),
+			Coverage:          coverageRate(coveredLines+file.CoveredBranches, linesToCover+file.BranchesToCover),
+		})
+	}
+	resp.LineCoverage = coverageRate(resp.CoveredLines, resp.LinesToCover)
+	resp.BranchCoverage = coverageRate(resp.CoveredBranches, resp.BranchesToCover)
+	resp.Coverage = coverageRate(resp.CoveredLines+resp.CoveredBranches, resp.LinesToCover+resp.BranchesToCover)
+
+	err := tx.CreateOrUpdate(&codequality.CqProject{
+		DomainEntityExtended: domainlayer.DomainEntityExtended{
+			Id: projectKey,
+		},
+		Name:             connection.Name,
+		LastAnalysisDate: &common.Iso8601Time{Time: createdDate},
+		CommitSha:        request.CommitSha,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// cq_file_metrics holds the latest coverage of each file of the repo
+	err = tx.Delete(&codequality.CqFileMetrics{}, dal.Where("project_key = ? AND id LIKE ?", projectKey, filePrefix+"%"))
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(fileMetrics); start += 500 {
+		end := start + 500
+		if end > len(fileMetrics) {
+			end = len(fileMetrics)
+		}
+		err = tx.CreateOrUpdate(fileMetrics[start:end])
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = tx.CreateOrUpdate(&codequality.CqCommitCoverage{
+		DomainEntity: domainlayer.DomainEntity{
+			Id: fmt.Sprintf("%s%s", filePrefix, request.CommitSha),
+		},
+		ProjectKey:      projectKey,
+		RepoId:          request.RepoId,
+		RepoUrl:         request.RepoUrl,
+		CommitSha:       request.CommitSha,
+		Format:          request.Format,
+		FileCount:       resp.FileCount,
+		LinesToCover:    resp.LinesToCover,
+		CoveredLines:    resp.CoveredLines,
+		BranchesToCover: resp.BranchesToCover,
+		CoveredBranches: resp.CoveredBranches,
+		LineCoverage:    resp.LineCoverage,
+		BranchCoverage:  resp.BranchCoverage,
+		Coverage:        resp.Coverage,
+		CreatedDate:     createdDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GenerateCoverageFileId generates the cq_file_metrics id of a file, long paths are hashed to fit the id column
+func GenerateCoverageFileId(prefix, filePath string) string {
+	id := prefix + filePath
+	if len(id) <= 255 {
+		return id
+	}
+	return fmt.Sprintf("%s%x", prefix, md5.Sum([]byte(filePath)))
+}
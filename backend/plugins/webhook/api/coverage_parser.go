@@ -0,0 +1,296 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// supported coverage report formats
+const (
+	COVERAGE_COBERTURA = "cobertura"
+	COVERAGE_LCOV      = "lcov"
+	COVERAGE_GOCOVER   = "gocover"
+)
+
+// FileCoverage is the coverage of a single source file
+type FileCoverage struct {
+	Path string
+	// Lines maps the instrumented line numbers to their hit counts
+	Lines           map[int]int
+	BranchesToCover int
+	CoveredBranches int
+}
+
+func newFileCoverage(path string) *FileCoverage {
+	return &FileCoverage{Path: path, Lines: make(map[int]int)}
+}
+
+func (f *FileCoverage) hit(line, hits int) {
+	if current, ok := f.Lines[line]; !ok || hits > current {
+		f.Lines[line] = hits
+	}
+}
+
+// LinesToCover returns the number of instrumented lines
+func (f *FileCoverage) LinesToCover() int {
+	return len(f.Lines)
+}
+
+// CoveredLines returns the number of lines hit at least once
+func (f *FileCoverage) CoveredLines() int {
+	covered := 0
+	for _, hits := range f.Lines {
+		if hits > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// coverageRate returns the percentage of covered items, 0 when there is nothing to cover
+func coverageRate(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// DetectCoverageFormat tells the format of a coverage report by its content
+func DetectCoverageFormat(content []byte) (string, errors.Error) {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return COVERAGE_GOCOVER, nil
+	case bytes.HasPrefix(trimmed, []byte("<")) && bytes.Contains(trimmed, []byte("<coverage")):
+		return COVERAGE_COBERTURA, nil
+	case bytes.HasPrefix(trimmed, []byte("TN:")) || bytes.HasPrefix(trimmed, []byte("SF:")):
+		return COVERAGE_LCOV, nil
+	}
+	return "", errors.BadInput.New("unable to detect the coverage report format, cobertura, lcov and gocover are supported")
+}
+
+// ParseCoverageReport parses a Cobertura XML, LCOV or Go coverprofile report into per-file coverage
+// sorted by path, the format is detected when empty
+func ParseCoverageReport(format string, content []byte) ([]*FileCoverage, errors.Error) {
+	var err errors.Error
+	if format == "" {
+		format, err = DetectCoverageFormat(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var files map[string]*FileCoverage
+	switch format {
+	case COVERAGE_COBERTURA:
+		files, err = parseCobertura(content)
+	case COVERAGE_LCOV:
+		files, err = parseLcov(content)
+	case COVERAGE_GOCOVER:
+		files, err = parseGoCover(content)
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported coverage format %s", format))
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*FileCoverage, 0, len(files))
+	for _, file := range files {
+		result = append(result, file)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+type coberturaReport struct {
+	Classes []struct {
+		Filename string `xml:"filename,attr"`
+		Lines    []struct {
+			Number            int    `xml:"number,attr"`
+			Hits              int    `xml:"hits,attr"`
+			Branch            bool   `xml:"branch,attr"`
+			ConditionCoverage string `xml:"condition-coverage,attr"`
+		} `xml:"lines>line"`
+	} `xml:"packages>package>classes>class"`
+}
+
+// conditionCoverageRegex matches cobertura condition coverages like "50% (1/2)"
+var conditionCoverageRegex = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+func parseCobertura(content []byte) (map[string]*FileCoverage, errors.Error) {
+	var report coberturaReport
+	if err := xml.Unmarshal(content, &report); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse cobertura report")
+	}
+	files := make(map[string]*FileCoverage)
+	// a file may contain several classes, branches are counted once per line
+	branches := make(map[string]map[int][2]int)
+	for _, class := range report.Classes {
+		file, ok := files[class.Filename]
+		if !ok {
+			file = newFileCoverage(class.Filename)
+			files[class.Filename] = file
+			branches[class.Filename] = make(map[int][2]int)
+		}
+		for _, line := range class.Lines {
+			file.hit(line.Number, line.Hits)
+			if !line.Branch {
+				continue
+			}
+			if m := conditionCoverageRegex.FindStringSubmatch(line.ConditionCoverage); m != nil {
+				covered, _ := strconv.Atoi(m[1])
+				total, _ := strconv.Atoi(m[2])
+				if current := branches[class.Filename][line.Number]; covered > current[0] || total > current[1] {
+					branches[class.Filename][line.Number] = [2]int{covered, total}
+				}
+			}
+		}
+	}
+	for filename, lines := range branches {
+		for _, branch := range lines {
+			files[filename].CoveredBranches += branch[0]
+			files[filename].BranchesToCover += branch[1]
+		}
+	}
+	return files, nil
+}
+
+func parseLcov(content []byte) (map[string]*FileCoverage, errors.Error) {
+	files := make(map[string]*FileCoverage)
+	// branches are keyed by line,block,branch, as a file may appear in several records
+	branches := make(map[string]map[string]bool)
+	var file *FileCoverage
+	var hasBrda bool
+	var brf, brh int
+	flush := func() {
+		// BRF/BRH summaries are only used when the record has no BRDA details
+		if file != nil && !hasBrda {
+			file.BranchesToCover += brf
+			file.CoveredBranches += brh
+		}
+		file = nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "SF":
+			flush()
+			var ok bool
+			if file, ok = files[value]; !ok {
+				file = newFileCoverage(value)
+				files[value] = file
+				branches[value] = make(map[string]bool)
+			}
+			hasBrda, brf, brh = false, 0, 0
+		case "DA", "BRDA", "BRF", "BRH":
+			if file == nil {
+				return nil, errors.BadInput.New(fmt.Sprintf("lcov line %d: %s outside of a source file record", lineNo, key))
+			}
+			fields := strings.Split(value, ",")
+			switch key {
+			case "DA":
+				if len(fields) < 2 {
+					return nil, errors.BadInput.New(fmt.Sprintf("lcov line %d: invalid DA record", lineNo))
+				}
+				number, err1 := strconv.Atoi(fields[0])
+				hits, err2 := strconv.Atoi(fields[1])
+				if err1 != nil || err2 != nil {
+					return nil, errors.BadInput.New(fmt.Sprintf("lcov line %d: invalid DA record", lineNo))
+				}
+				file.hit(number, hits)
+			case "BRDA":
+				if len(fields) != 4 {
+					return nil, errors.BadInput.New(fmt.Sprintf("lcov line %d: invalid BRDA record", lineNo))
+				}
+				hasBrda = true
+				branchKey := strings.Join(fields[:3], ",")
+				taken := fields[3] != "-" && fields[3] != "0"
+				branches[file.Path][branchKey] = branches[file.Path][branchKey] || taken
+			case "BRF":
+				brf, _ = strconv.Atoi(value)
+			case "BRH":
+				brh, _ = strconv.Atoi(value)
+			}
+		case "end_of_record":
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to read lcov report")
+	}
+	flush()
+	for filename, fileBranches := range branches {
+		for _, taken := range fileBranches {
+			files[filename].BranchesToCover++
+			if taken {
+				files[filename].CoveredBranches++
+			}
+		}
+	}
+	return files, nil
+}
+
+// goCoverBlockRegex matches coverprofile blocks like "example.com/pkg/file.go:10.2,12.3 2 1"
+var goCoverBlockRegex = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ (\d+) (\d+)$`)
+
+func parseGoCover(content []byte) (map[string]*FileCoverage, errors.Error) {
+	files := make(map[string]*FileCoverage)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		m := goCoverBlockRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, errors.BadInput.New(fmt.Sprintf("coverprofile line %d: invalid block", lineNo))
+		}
+		startLine, _ := strconv.Atoi(m[2])
+		endLine, _ := strconv.Atoi(m[3])
+		count, _ := strconv.Atoi(m[5])
+		file, ok := files[m[1]]
+		if !ok {
+			file = newFileCoverage(path.Clean(m[1]))
+			files[m[1]] = file
+		}
+		// coverprofile counts statements, a line is covered when any block spanning it was executed
+		for number := startLine; number <= endLine; number++ {
+			file.hit(number, count)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to read coverprofile")
+	}
+	return files, nil
+}
@@ -0,0 +1,134 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCobertura(t *testing.T) {
+	report := `<?xml version="1.0" ?>
+<coverage line-rate="0.5" branch-rate="0.5" version="1.9">
+  <packages>
+    <package name="calc">
+      <classes>
+        <class name="Calc" filename="src/calc.py">
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+            <line number="3" hits="2" branch="true" condition-coverage="50% (1/2)"/>
+          </lines>
+        </class>
+        <class name="Calc$Inner" filename="src/calc.py">
+          <lines>
+            <line number="2" hits="3"/>
+            <line number="4" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`
+	format, err := DetectCoverageFormat([]byte(report))
+	assert.Nil(t, err)
+	assert.Equal(t, COVERAGE_COBERTURA, format)
+	files, err := ParseCoverageReport("", []byte(report))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "src/calc.py", files[0].Path)
+	assert.Equal(t, 4, files[0].LinesToCover())
+	assert.Equal(t, 3, files[0].CoveredLines())
+	assert.Equal(t, 2, files[0].BranchesToCover)
+	assert.Equal(t, 1, files[0].CoveredBranches)
+}
+
+func TestParseLcov(t *testing.T) {
+	report := `TN:
+SF:src/b.js
+DA:1,1
+DA:2,0
+BRDA:1,0,0,1
+BRDA:1,0,1,-
+BRF:2
+BRH:1
+LF:2
+LH:1
+end_of_record
+SF:src/a.js
+DA:1,5
+BRF:4
+BRH:3
+end_of_record
+SF:src/b.js
+DA:2,1
+BRDA:1,0,1,2
+end_of_record
+`
+	format, err := DetectCoverageFormat([]byte(report))
+	assert.Nil(t, err)
+	assert.Equal(t, COVERAGE_LCOV, format)
+	files, err := ParseCoverageReport(COVERAGE_LCOV, []byte(report))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	assert.Equal(t, "src/a.js", files[0].Path)
+	assert.Equal(t, 1, files[0].CoveredLines())
+	assert.Equal(t, 4, files[0].BranchesToCover)
+	assert.Equal(t, 3, files[0].CoveredBranches)
+
+	// records of the same file are merged
+	assert.Equal(t, "src/b.js", files[1].Path)
+	assert.Equal(t, 2, files[1].LinesToCover())
+	assert.Equal(t, 2, files[1].CoveredLines())
+	assert.Equal(t, 2, files[1].BranchesToCover)
+	assert.Equal(t, 2, files[1].CoveredBranches)
+
+	_, err = ParseCoverageReport(COVERAGE_LCOV, []byte("DA:1,1\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseGoCover(t *testing.T) {
+	report := `mode: count
+example.com/calc/calc.go:3.20,5.2 1 4
+example.com/calc/calc.go:7.20,9.16 2 0
+example.com/calc/calc.go:9.16,11.3 1 0
+example.com/calc/util.go:1.1,1.10 1 1
+`
+	format, err := DetectCoverageFormat([]byte(report))
+	assert.Nil(t, err)
+	assert.Equal(t, COVERAGE_GOCOVER, format)
+	files, err := ParseCoverageReport("", []byte(report))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "example.com/calc/calc.go", files[0].Path)
+	assert.Equal(t, 8, files[0].LinesToCover())
+	assert.Equal(t, 3, files[0].CoveredLines())
+	assert.Equal(t, 0, files[0].BranchesToCover)
+	assert.Equal(t, 1, files[1].LinesToCover())
+
+	_, err = ParseCoverageReport(COVERAGE_GOCOVER, []byte("mode: set\nbroken line\n"))
+	assert.NotNil(t, err)
+}
+
+func TestGenerateCoverageFileId(t *testing.T) {
+	assert.Equal(t, "webhook:1:repo:src/a.go", GenerateCoverageFileId("webhook:1:repo:", "src/a.go"))
+	longPath := string(make([]byte, 300))
+	assert.Len(t, GenerateCoverageFileId("webhook:1:repo:", longPath), len("webhook:1:repo:")+32)
+}
@@ -90,6 +90,9 @@ func (p Webhook) ApiResources() map[string]map[string]plugin.ApiResourceHandler
 		"connections/:connectionId/deployments": {
 			"POST": api.PostDeployments,
 		},
+		"connections/:connectionId/coverage": {
+			"POST": api.PostCoverage,
+		},
 		"connections/:connectionId/pull_requests": {
 			"POST": api.PostPullRequests,
 		},
@@ -119,6 +122,9 @@ func (p Webhook) ApiResources() map[string]map[string]plugin.ApiResourceHandler
 		"connections/by-name/:connectionName/deployments": {
 			"POST": api.PostDeploymentsByName,
 		},
+		"connections/by-name/:connectionName/coverage": {
+			"POST": api.PostCoverageByName,
+		},
 		"connections/by-name/:connectionName/pull_requests": {
 			"POST": api.PostPullRequestsByName,
 		},
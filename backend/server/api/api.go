@@ -18,12 +18,17 @@ limitations under the License.
 package api
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	// "github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -33,6 +38,7 @@ import (
 
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/metrics"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 	_ "github.com/apache/incubator-devlake/server/api/docs"
@@ -103,6 +109,7 @@ func CreateApiServer() *gin.Engine {
 	router.GET("/ready", ping.Ready)
 	router.GET("/health", ping.Health)
 	router.GET("/version", version.Get)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Api keys
 	router.Use(RestAuthentication(router, basicRes))
@@ -172,10 +179,26 @@ func RunApiServer(router *gin.Engine) {
 	}
 
 	// Start the server
-	err = router.Run(fmt.Sprintf(":%d", portNum))
-	if err != nil {
-		panic(err)
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", portNum),
+		Handler: router,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	// Wait for a stop signal, then drain in-flight requests and release resources
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	<-sigc
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		basicRes.GetLogger().Error(err, "failed to shutdown api server")
 	}
+	services.Shutdown()
 }
 
 func registerExtraOpenApiSpecs(router *gin.Engine) {
@@ -198,6 +198,41 @@ func Trigger(c *gin.Context) {
 	shared.ApiOutputSuccess(c, pipeline, http.StatusOK)
 }
 
+// @Summary dry-run blueprint
+// @Description preview the plan a blueprint would generate, validate the options of its tasks and estimate the tables to be rewritten, without running any subtask. Validating the options prepares the task data of the plugins, which may call the data sources and save missing scopes
+// @Tags framework/blueprints
+// @Accept application/json
+// @Param blueprintId path string true "blueprintId"
+// @Param skipCollectors body models.TriggerSyncPolicy false "json"
+// @Param fullSync body models.TriggerSyncPolicy false "json"
+// @Success 200 {object} services.BlueprintDryRun
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /blueprints/{blueprintId}/dry-run [Post]
+func DryRun(c *gin.Context) {
+	blueprintId := c.Param("blueprintId")
+	id, err := strconv.ParseUint(blueprintId, 10, 64)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "bad blueprintID format supplied"))
+		return
+	}
+
+	triggerSyncPolicy := &models.TriggerSyncPolicy{}
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(triggerSyncPolicy)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, "error binding request body"))
+			return
+		}
+	}
+	dryRun, err := services.DryRunBlueprint(c.Request.Context(), id, triggerSyncPolicy)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error dry-running blueprint"))
+		return
+	}
+	shared.ApiOutputSuccess(c, dryRun, http.StatusOK)
+}
+
 // @Summary get pipelines by blueprint id
 // @Description get pipelines by blueprint id
 // @Tags framework/blueprints
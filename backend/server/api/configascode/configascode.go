@@ -0,0 +1,111 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configascode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary export configuration
+// @Description export projects, connections, scopes, scope configs and blueprints as a configuration bundle, secrets are replaced with references to environment variables
+// @Tags framework/config-as-code
+// @Produce application/yaml
+// @Param format query string false "yaml or json, yaml by default"
+// @Success 200 {object} services.ConfigBundle
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /config-as-code [get]
+func Export(c *gin.Context) {
+	format := c.DefaultQuery("format", services.CONFIG_FORMAT_YAML)
+	bundle, err := services.ExportConfigBundle()
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error exporting configuration"))
+		return
+	}
+	data, err := services.MarshalConfigBundle(bundle, format)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	contentType := "application/yaml"
+	if format == services.CONFIG_FORMAT_JSON {
+		contentType = "application/json"
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// @Summary diff configuration
+// @Description compare a configuration bundle in yaml or json with the current configuration without changing anything
+// @Tags framework/config-as-code
+// @Accept application/yaml
+// @Param bundle body services.ConfigBundle true "yaml or json"
+// @Success 200 {array} services.ConfigChange
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /config-as-code/diff [post]
+func Diff(c *gin.Context) {
+	bundle, err := readConfigBundle(c)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	changes, err := services.DiffConfigBundle(bundle)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error comparing configuration"))
+		return
+	}
+	shared.ApiOutputSuccess(c, changes, http.StatusOK)
+}
+
+// @Summary apply configuration
+// @Description create or update the entities of a configuration bundle in yaml or json which differ from the current configuration, applying the same bundle again changes nothing
+// @Tags framework/config-as-code
+// @Accept application/yaml
+// @Param bundle body services.ConfigBundle true "yaml or json"
+// @Success 200 {array} services.ConfigChange
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /config-as-code/apply [post]
+func Apply(c *gin.Context) {
+	bundle, err := readConfigBundle(c)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	changes, err := services.ApplyConfigBundle(bundle)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error applying configuration"))
+		return
+	}
+	shared.ApiOutputSuccess(c, changes, http.StatusOK)
+}
+
+func readConfigBundle(c *gin.Context) (*services.ConfigBundle, errors.Error) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, shared.BadRequestBody)
+	}
+	return services.ParseConfigBundle(data)
+}
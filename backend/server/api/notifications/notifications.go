@@ -0,0 +1,247 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PaginatedNotificationSubscriptions struct {
+	Subscriptions []*models.NotificationSubscription `json:"subscriptions"`
+	Count         int64                              `json:"count"`
+}
+
+type PaginatedNotifications struct {
+	Notifications []*models.Notification `json:"notifications"`
+	Count         int64                  `json:"count"`
+}
+
+// @Summary Get list of notification subscriptions
+// @Description GET /notifications/subscriptions?projectName=xxx&page=1&pageSize=10
+// @Tags framework/notifications
+// @Param projectName query string false "query"
+// @Param page query int false "query"
+// @Param pageSize query int false "query"
+// @Success 200  {object} PaginatedNotificationSubscriptions
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/subscriptions [get]
+func GetSubscriptions(c *gin.Context) {
+	var query services.NotificationSubscriptionQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	subscriptions, count, err := services.GetNotificationSubscriptions(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting notification subscriptions"))
+		return
+	}
+	shared.ApiOutputSuccess(c, PaginatedNotificationSubscriptions{
+		Subscriptions: subscriptions,
+		Count:         count,
+	}, http.StatusOK)
+}
+
+// @Summary Get a notification subscription
+// @Description Get a notification subscription
+// @Tags framework/notifications
+// @Success 200  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/subscriptions/{subscriptionId} [get]
+func GetSubscription(c *gin.Context) {
+	id, err := parseId(c, "subscriptionId")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	subscription, err := services.GetNotificationSubscription(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusOK)
+}
+
+// @Summary Create a notification subscription
+// @Description Create a notification subscription, channel could be webhook(default), slack or feishu.
+// @Description Empty projectName/blueprintId/statuses match every pipeline, chat channels only receive finished pipelines by default
+// @Tags framework/notifications
+// @Accept application/json
+// @Param subscription body models.ApiInputNotificationSubscription true "json"
+// @Success 201  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/subscriptions [post]
+func PostSubscription(c *gin.Context) {
+	input := &models.ApiInputNotificationSubscription{}
+	err := c.ShouldBind(input)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	subscription, err := services.CreateNotificationSubscription(input)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error creating notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusCreated)
+}
+
+// @Summary Update a notification subscription
+// @Description Update a notification subscription, the secret is kept if left empty
+// @Tags framework/notifications
+// @Accept application/json
+// @Param subscription body models.ApiInputNotificationSubscription true "json"
+// @Success 200  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/subscriptions/{subscriptionId} [patch]
+func PatchSubscription(c *gin.Context) {
+	id, err := parseId(c, "subscriptionId")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	input := &models.ApiInputNotificationSubscription{}
+	err = errors.Convert(c.ShouldBind(input))
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	subscription, err := services.PatchNotificationSubscription(id, input)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error updating notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusOK)
+}
+
+// @Summary Delete a notification subscription
+// @Description Delete a notification subscription along with its deliveries
+// @Tags framework/notifications
+// @Success 200
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/subscriptions/{subscriptionId} [delete]
+func DeleteSubscription(c *gin.Context) {
+	id, err := parseId(c, "subscriptionId")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	err = services.DeleteNotificationSubscription(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error deleting notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, nil, http.StatusOK)
+}
+
+// @Summary Get the notification delivery log
+// @Description GET /notifications?subscriptionId=1&status=FAILED&page=1&pageSize=10
+// @Tags framework/notifications
+// @Param subscriptionId query int false "query"
+// @Param status query string false "PENDING, RETRYING, DELIVERED or FAILED"
+// @Param page query int false "query"
+// @Param pageSize query int false "query"
+// @Success 200  {object} PaginatedNotifications
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications [get]
+func GetNotifications(c *gin.Context) {
+	var query services.NotificationQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	notifications, count, err := services.GetNotifications(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting notifications"))
+		return
+	}
+	shared.ApiOutputSuccess(c, PaginatedNotifications{
+		Notifications: notifications,
+		Count:         count,
+	}, http.StatusOK)
+}
+
+// @Summary Get a notification with its delivery attempts
+// @Description Get a notification with its delivery attempts
+// @Tags framework/notifications
+// @Success 200  {object} services.NotificationDetail
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/{notificationId} [get]
+func GetNotification(c *gin.Context) {
+	id, err := parseId(c, "notificationId")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	notification, err := services.GetNotification(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting notification"))
+		return
+	}
+	shared.ApiOutputSuccess(c, notification, http.StatusOK)
+}
+
+// @Summary Redeliver a notification
+// @Description Send the notification once more, the attempt is recorded in the delivery log
+// @Tags framework/notifications
+// @Success 200  {object} services.NotificationDetail
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notifications/{notificationId}/redeliver [post]
+func PostRedeliver(c *gin.Context) {
+	id, err := parseId(c, "notificationId")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	notification, err := services.RedeliverNotification(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error redelivering notification"))
+		return
+	}
+	shared.ApiOutputSuccess(c, notification, http.StatusOK)
+}
+
+func parseId(c *gin.Context, name string) (uint64, errors.Error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, errors.BadInput.Wrap(err, "bad "+name+" format supplied")
+	}
+	return id, nil
+}
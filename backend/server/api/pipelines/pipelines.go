@@ -22,15 +22,19 @@ import (
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/services"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 )
 
+const eventsKeepAliveInterval = 15 * time.Second
+
 // @Summary Create and run a new pipeline
 // @Description Create and run a new pipeline
 // @Tags framework/pipelines
@@ -168,6 +172,53 @@ func DownloadLogs(c *gin.Context) {
 	c.FileAttachment(archive, filepath.Base(archive))
 }
 
+// @Summary stream the events of a running pipeline
+// @Description GET /pipelines/:pipelineId/events
+// @Description Server-Sent Events of the pipeline: `pipeline` (sent first and once the pipeline finished), `task`, `subtask`, `progress` and `log`.
+// @Description The stream ends once the pipeline finished. Only pipelines running in the instance serving the request produce events, the stream of the others only ends with the `pipeline` event.
+// @Tags framework/pipelines
+// @Produce text/event-stream
+// @Param pipelineId path int true "pipelineId"
+// @Success 200  {object} services.PipelineEvent
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Pipeline not found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /pipelines/{pipelineId}/events [get]
+func GetEvents(c *gin.Context) {
+	pipelineId := c.Param("pipelineId")
+	id, err := strconv.ParseUint(pipelineId, 10, 64)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "bad pipeline ID format supplied"))
+		return
+	}
+	events, unsubscribe, err := services.SubscribePipelineEvents(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting pipeline"))
+		return
+	}
+	defer unsubscribe()
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-keepAlive.C:
+			// a comment line, keeps proxies from closing the idle connection
+			_, e := io.WriteString(w, ": keep-alive\n\n")
+			return e == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
 // RerunPipeline rerun all failed tasks of the specified pipeline
 // @Summary rerun tasks
 // @Tags framework/pipelines
@@ -76,6 +76,35 @@ func GetProjectCheck(c *gin.Context) {
 	shared.ApiOutputSuccess(c, projectOutputCheck, http.StatusOK) // //shared.ApiOutputSuccess(c, projectOutputCheck, http.StatusOK)
 }
 
+// @Summary Get DORA metrics of a project
+// @Description Get the weekly or monthly DORA metrics calculated by the dora plugin, graded against dora_benchmarks
+// @Tags framework/projects
+// @Accept application/json
+// @Param projectName path string true "project name"
+// @Param periodType query string false "WEEK or MONTH, defaults to MONTH"
+// @Param startDate query string false "start date, i.e. 2024-01-01"
+// @Param endDate query string false "end date, i.e. 2024-12-31"
+// @Success 200  {object} services.ProjectDora
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /projects/{projectName}/dora [get]
+func GetProjectDora(c *gin.Context) {
+	projectName := c.Param("projectName")
+
+	var query services.ProjectDoraQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	output, err := services.GetProjectDora(projectName, &query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting DORA metrics"))
+		return
+	}
+	shared.ApiOutputSuccess(c, output, http.StatusOK)
+}
+
 // @Summary Get list of projects
 // @Description GET /projects?page=1&pageSize=10
 // @Tags framework/projects
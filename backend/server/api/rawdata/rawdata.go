@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rawdata
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary export raw data of a scope
+// @Description export the rows of the raw tables collected for a scope as a tar.gz bundle, which can be imported into another instance
+// @Tags framework/raw-data
+// @Produce application/gzip
+// @Param plugin query string true "plugin name"
+// @Param connectionId query int true "connection id"
+// @Param scopeId query string true "scope id"
+// @Success 200
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 404 {object} shared.ApiBody "Scope or raw data not found"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /raw-data/export [get]
+func Export(c *gin.Context) {
+	pluginName := c.Query("plugin")
+	scopeId := c.Query("scopeId")
+	connectionId, err := strconv.ParseUint(c.Query("connectionId"), 10, 64)
+	if err != nil || pluginName == "" || scopeId == "" {
+		shared.ApiOutputError(c, errors.BadInput.New("plugin, connectionId and scopeId are required"))
+		return
+	}
+	bundle, exportErr := services.ExportRawDataBundle(pluginName, connectionId, scopeId)
+	if exportErr != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(exportErr, "error exporting raw data"))
+		return
+	}
+	defer os.Remove(bundle)
+	c.FileAttachment(bundle, fmt.Sprintf("raw-data-%s-%d.tar.gz", pluginName, connectionId))
+}
+
+// @Summary import raw data of a scope
+// @Description import a bundle exported by /raw-data/export, replacing the raw data of its scope, then run the blueprint of the scope with `skipCollectors` to extract and convert it
+// @Tags framework/raw-data
+// @Accept multipart/form-data
+// @Param file formData file true "raw data bundle"
+// @Param connectionId query int false "import for this connection instead of the one of the bundle"
+// @Success 200 {object} services.RawDataBundleManifest
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /raw-data/import [post]
+func Import(c *gin.Context) {
+	var connectionId uint64
+	if s := c.Query("connectionId"); s != "" {
+		var err error
+		connectionId, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, "invalid connectionId"))
+			return
+		}
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "the raw data bundle is required as the file field"))
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error reading the raw data bundle"))
+		return
+	}
+	defer reader.Close()
+	manifest, importErr := services.ImportRawDataBundle(reader, connectionId)
+	if importErr != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(importErr, "error importing raw data"))
+		return
+	}
+	shared.ApiOutputSuccess(c, manifest, http.StatusOK)
+}
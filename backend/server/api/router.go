@@ -30,11 +30,14 @@ import (
 
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/server/api/blueprints"
+	"github.com/apache/incubator-devlake/server/api/configascode"
 	"github.com/apache/incubator-devlake/server/api/domainlayer"
+	"github.com/apache/incubator-devlake/server/api/notifications"
 	"github.com/apache/incubator-devlake/server/api/pipelines"
 	"github.com/apache/incubator-devlake/server/api/plugininfo"
 	"github.com/apache/incubator-devlake/server/api/project"
 	"github.com/apache/incubator-devlake/server/api/push"
+	"github.com/apache/incubator-devlake/server/api/rawdata"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/api/task"
 	"github.com/apache/incubator-devlake/server/services"
@@ -51,6 +54,7 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.GET("/pipelines/:pipelineId/subtasks", task.GetSubtaskByPipeline)
 	r.POST("/pipelines/:pipelineId/rerun", pipelines.PostRerun)
 	r.GET("/pipelines/:pipelineId/logging.tar.gz", pipelines.DownloadLogs)
+	r.GET("/pipelines/:pipelineId/events", pipelines.GetEvents)
 
 	r.GET("/blueprints", blueprints.Index)
 	r.POST("/blueprints", blueprints.Post)
@@ -58,6 +62,7 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.DELETE("/blueprints/:blueprintId", blueprints.Delete)
 	r.GET("/blueprints/:blueprintId", blueprints.Get)
 	r.POST("/blueprints/:blueprintId/trigger", blueprints.Trigger)
+	r.POST("/blueprints/:blueprintId/dry-run", blueprints.DryRun)
 	r.GET("/blueprints/:blueprintId/pipelines", blueprints.GetBlueprintPipelines)
 
 	r.POST("/tasks/:taskId/rerun", task.PostRerun)
@@ -72,6 +77,7 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	// project api
 	r.GET("/projects/:projectName", project.GetProject)
 	r.GET("/projects/:projectName/check", project.GetProjectCheck)
+	r.GET("/projects/:projectName/dora", project.GetProjectDora)
 	r.PATCH("/projects/:projectName", project.PatchProject)
 	r.DELETE("/projects/:projectName", project.DeleteProject)
 	r.POST("/projects", project.PostProject)
@@ -86,6 +92,24 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.PUT("/api-keys/:apiKeyId", apikeys.PutApiKey)
 	r.DELETE("/api-keys/:apiKeyId", apikeys.DeleteApiKey)
 
+	// config as code api
+	r.GET("/config-as-code", configascode.Export)
+	r.POST("/config-as-code/diff", configascode.Diff)
+	r.POST("/config-as-code/apply", configascode.Apply)
+
+	// raw data bundles api
+	r.GET("/raw-data/export", rawdata.Export)
+	r.POST("/raw-data/import", rawdata.Import)
+
+	r.GET("/notifications/subscriptions", notifications.GetSubscriptions)
+	r.POST("/notifications/subscriptions", notifications.PostSubscription)
+	r.GET("/notifications/subscriptions/:subscriptionId", notifications.GetSubscription)
+	r.PATCH("/notifications/subscriptions/:subscriptionId", notifications.PatchSubscription)
+	r.DELETE("/notifications/subscriptions/:subscriptionId", notifications.DeleteSubscription)
+	r.GET("/notifications", notifications.GetNotifications)
+	r.GET("/notifications/:notificationId", notifications.GetNotification)
+	r.POST("/notifications/:notificationId/redeliver", notifications.PostRedeliver)
+
 	// mount all api resources for all plugins
 	resources, err := services.GetPluginsApiResources()
 	if err != nil {
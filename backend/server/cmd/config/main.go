@@ -0,0 +1,141 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// configChange mirrors services.ConfigChange, the server packages are not imported to keep the client lightweight
+type configChange struct {
+	Kind   string   `json:"kind"`
+	Plugin string   `json:"plugin"`
+	Key    string   `json:"key"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields"`
+}
+
+// config manages the configuration of a DevLake server with bundles through its REST api, e.g.
+//
+//	go run server/cmd/config/main.go diff -f devlake.yaml --endpoint http://localhost:8080
+func main() {
+	cmd := &cobra.Command{Use: "config", Short: "export, diff and apply DevLake configuration bundles"}
+	endpoint := cmd.PersistentFlags().StringP("endpoint", "e", "http://localhost:8080", "base url of the DevLake api")
+	token := cmd.PersistentFlags().StringP("token", "t", os.Getenv("DEVLAKE_API_TOKEN"), "api key, DEVLAKE_API_TOKEN by default")
+
+	exportCmd := &cobra.Command{Use: "export", Short: "export the current configuration"}
+	format := exportCmd.Flags().String("format", "yaml", "yaml or json")
+	output := exportCmd.Flags().StringP("output", "o", "", "file to write the bundle to, stdout by default")
+	exportCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		data, err := call(http.MethodGet, *endpoint+"/config-as-code?format="+*format, *token, nil)
+		if err != nil {
+			return err
+		}
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*output, data, 0600)
+	}
+
+	diffCmd := &cobra.Command{Use: "diff", Short: "compare a bundle with the current configuration"}
+	diffFile := diffCmd.Flags().StringP("file", "f", "", "bundle in yaml or json")
+	_ = diffCmd.MarkFlagRequired("file")
+	diffCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return postBundle(*endpoint+"/config-as-code/diff", *token, *diffFile)
+	}
+
+	applyCmd := &cobra.Command{Use: "apply", Short: "create or update the entities of a bundle"}
+	applyFile := applyCmd.Flags().StringP("file", "f", "", "bundle in yaml or json")
+	_ = applyCmd.MarkFlagRequired("file")
+	applyCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return postBundle(*endpoint+"/config-as-code/apply", *token, *applyFile)
+	}
+
+	cmd.AddCommand(exportCmd, diffCmd, applyCmd)
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func postBundle(url, token, file string) error {
+	bundle, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	data, err := call(http.MethodPost, url, token, bundle)
+	if err != nil {
+		return err
+	}
+	changes := make([]*configChange, 0)
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Println(formatChange(change))
+	}
+	return nil
+}
+
+func formatChange(change *configChange) string {
+	symbol := map[string]string{
+		"create":    "+",
+		"update":    "~",
+		"unchanged": "=",
+	}[change.Action]
+	line := fmt.Sprintf("%s %s %s", symbol, change.Kind, change.Key)
+	if change.Plugin != "" {
+		line = fmt.Sprintf("%s %s %s/%s", symbol, change.Kind, change.Plugin, change.Key)
+	}
+	if len(change.Fields) > 0 {
+		line += fmt.Sprintf(" (%s)", strings.Join(change.Fields, ", "))
+	}
+	return line
+}
+
+func call(method, url, token string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s %s", method, url, res.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
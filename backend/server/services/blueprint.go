@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
 
@@ -54,6 +55,17 @@ type BlueprintJob struct {
 
 func (bj BlueprintJob) Run() {
 	blueprint := bj.Blueprint
+	if clusterMode {
+		// every instance schedules the cronjobs, only the first one to lease the tick triggers the blueprint
+		acquired, err := acquireLease(blueprintTriggerLeaseName(blueprint.ID, time.Now()), time.Hour)
+		if err != nil {
+			blueprintLog.Error(err, fmt.Sprintf("failed to lease cron job of blueprint:[%d][%s]", blueprint.ID, blueprint.Name))
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
 	pipeline, err := createPipelineByBlueprint(blueprint, &blueprint.SyncPolicy)
 	if err == ErrEmptyPlan {
 		blueprintLog.Info("Empty plan, blueprint id:[%d] blueprint name:[%s]", blueprint.ID, blueprint.Name)
@@ -131,6 +143,22 @@ func GetBlueprintByProjectName(projectName string) (*models.Blueprint, errors.Er
 }
 
 func validateBlueprintAndMakePlan(blueprint *models.Blueprint) errors.Error {
+	err := validateBlueprint(db, blueprint)
+	if err != nil {
+		return err
+	}
+	if blueprint.Mode == models.BLUEPRINT_MODE_NORMAL {
+		blueprint.Plan, err = MakePlanForBlueprint(blueprint, &blueprint.SyncPolicy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBlueprint validates the blueprint against the projects and blueprints of tx, the plan of a NORMAL
+// blueprint is left to be made
+func validateBlueprint(tx dal.Dal, blueprint *models.Blueprint) errors.Error {
 	// validation
 	err := vld.Struct(blueprint)
 	if err != nil {
@@ -139,13 +167,13 @@ func validateBlueprintAndMakePlan(blueprint *models.Blueprint) errors.Error {
 
 	// checking if the project exist
 	if blueprint.ProjectName != "" {
-		_, err := GetProject(blueprint.ProjectName)
+		_, err := getProjectByName(tx, blueprint.ProjectName)
 		if err != nil {
 			return errors.Default.Wrap(err, fmt.Sprintf("invalid projectName: [%s] for the blueprint [%s]", blueprint.ProjectName, blueprint.Name))
 		}
 
-		bp, err := GetBlueprintByProjectName(blueprint.ProjectName)
-		if err != nil {
+		bp, err := services.NewBlueprintManager(tx).GetDbBlueprintByProjectName(blueprint.ProjectName)
+		if err != nil && !tx.IsErrorNotFound(err) {
 			return err
 		}
 		if bp != nil {
@@ -168,11 +196,8 @@ func validateBlueprintAndMakePlan(blueprint *models.Blueprint) errors.Error {
 		if len(blueprint.Plan) == 0 {
 			return errors.BadInput.New("invalid plan")
 		}
-	} else if blueprint.Mode == models.BLUEPRINT_MODE_NORMAL {
-		var e errors.Error
-		blueprint.Plan, e = MakePlanForBlueprint(blueprint, &blueprint.SyncPolicy)
-		if e != nil {
-			return e
+		if err := blueprint.Plan.ValidateDag(); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -269,6 +294,12 @@ func ReloadBlueprints() (err errors.Error) {
 	if err != nil {
 		return err
 	}
+	if clusterMode {
+		blueprintsVersion, err = getBlueprintsVersion()
+		if err != nil {
+			return err
+		}
+	}
 	for _, e := range cronManager.Entries() {
 		cronManager.Remove(e.ID)
 	}
@@ -384,6 +415,7 @@ func MakePlanForBlueprint(blueprint *models.Blueprint, syncPolicy *models.SyncPo
 // ParallelizePipelinePlans merges multiple pipelines into one unified plan
 // by assuming they can be executed in parallel
 func ParallelizePipelinePlans(plans ...models.PipelinePlan) models.PipelinePlan {
+	plans = ToDagPipelinePlans(plans...)
 	merged := make(models.PipelinePlan, 0)
 	// iterate all pipelineTasks and try to merge them into `merged`
 	for _, plan := range plans {
@@ -402,14 +434,103 @@ func ParallelizePipelinePlans(plans ...models.PipelinePlan) models.PipelinePlan
 // SequentializePipelinePlans merges multiple pipelines into one unified plan
 // by assuming they must be executed in sequential order
 func SequentializePipelinePlans(plans ...models.PipelinePlan) models.PipelinePlan {
+	plans = ToDagPipelinePlans(plans...)
 	merged := make(models.PipelinePlan, 0)
 	// iterate all pipelineTasks and try to merge them into `merged`
 	for _, plan := range plans {
+		if plan.IsDag() {
+			// tasks without dependencies of a DAG-based plan have to wait for the leaves of the merged plan
+			leaves := dagLeafTaskIds(merged)
+			for _, stage := range plan {
+				for _, task := range stage {
+					if len(task.DependsOn) == 0 && len(leaves) > 0 {
+						task.DependsOn = append([]string{}, leaves...)
+					}
+				}
+			}
+		}
 		merged = append(merged, plan...)
 	}
 	return merged
 }
 
+// ToDagPipelinePlans converts legacy plans to DAG-based ones if any of the plans is DAG-based, or if
+// DAG_PIPELINE_PLANS is enabled, so they can be merged together. Tasks of a converted plan depend on all tasks of
+// the previous non-empty stage, and get a generated id unique among all the plans. Tasks are copied so the
+// original plans remain intact.
+func ToDagPipelinePlans(plans ...models.PipelinePlan) []models.PipelinePlan {
+	isDag := cfg != nil && cfg.GetBool("DAG_PIPELINE_PLANS")
+	usedIds := make(map[string]bool)
+	for _, plan := range plans {
+		if plan.IsDag() {
+			isDag = true
+			for _, stage := range plan {
+				for _, task := range stage {
+					usedIds[task.Id] = true
+				}
+			}
+		}
+	}
+	if !isDag {
+		return plans
+	}
+	converted := make([]models.PipelinePlan, len(plans))
+	for i, plan := range plans {
+		wasDag := plan.IsDag()
+		converted[i] = make(models.PipelinePlan, len(plan))
+		previous := []string{}
+		for j, stage := range plan {
+			converted[i][j] = make(models.PipelineStage, len(stage))
+			current := make([]string, 0, len(stage))
+			for k, task := range stage {
+				newTask := *task
+				if !wasDag {
+					newTask.Id = generateDagTaskId(task.Plugin, usedIds)
+					newTask.DependsOn = previous
+				}
+				newTask.DependsOn = append([]string{}, newTask.DependsOn...)
+				converted[i][j][k] = &newTask
+				current = append(current, newTask.Id)
+			}
+			if len(current) > 0 {
+				previous = current
+			}
+		}
+	}
+	return converted
+}
+
+func generateDagTaskId(pluginName string, usedIds map[string]bool) string {
+	for n := 1; ; n++ {
+		id := fmt.Sprintf("%s-%d", pluginName, n)
+		if !usedIds[id] {
+			usedIds[id] = true
+			return id
+		}
+	}
+}
+
+// dagLeafTaskIds returns ids of the tasks no other task depends on
+func dagLeafTaskIds(plan models.PipelinePlan) []string {
+	dependedOn := make(map[string]bool)
+	for _, stage := range plan {
+		for _, task := range stage {
+			for _, dep := range task.DependsOn {
+				dependedOn[dep] = true
+			}
+		}
+	}
+	leaves := make([]string, 0)
+	for _, stage := range plan {
+		for _, task := range stage {
+			if !dependedOn[task.Id] {
+				leaves = append(leaves, task.Id)
+			}
+		}
+	}
+	return leaves
+}
+
 // TriggerBlueprint triggers blueprint immediately
 func TriggerBlueprint(id uint64, triggerSyncPolicy *models.TriggerSyncPolicy, shouldSanitize bool) (*models.Pipeline, errors.Error) {
 	// load record from db
@@ -0,0 +1,155 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	gocontext "context"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/core/runner"
+)
+
+// BlueprintDryRunTask is the outcome of validating a task of the plan without running it
+type BlueprintDryRunTask struct {
+	Stage    int      `json:"stage"`
+	Plugin   string   `json:"plugin"`
+	Subtasks []string `json:"subtasks"`
+	// UntrackedSubtasks are the subtasks which don't declare their product tables, the tables they write to are
+	// missing from the estimation
+	UntrackedSubtasks []string `json:"untrackedSubtasks,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// BlueprintDryRun previews what triggering a blueprint would do. RawTables are only rewritten as a whole when
+// FullSync is set, collectors supporting incremental collection append to them otherwise.
+type BlueprintDryRun struct {
+	Plan         models.PipelinePlan    `json:"plan"`
+	SyncPolicy   models.SyncPolicy      `json:"syncPolicy"`
+	Valid        bool                   `json:"valid"`
+	Tasks        []*BlueprintDryRunTask `json:"tasks"`
+	RawTables    []string               `json:"rawTables"`
+	ToolTables   []string               `json:"toolTables"`
+	DomainTables []string               `json:"domainTables"`
+}
+
+// DryRunBlueprint generates the plan of the blueprint the same way triggering it does, validates the options of
+// every task of the plan, and estimates the tables to be rewritten, without creating any pipeline
+func DryRunBlueprint(ctx gocontext.Context, id uint64, triggerSyncPolicy *models.TriggerSyncPolicy) (*BlueprintDryRun, errors.Error) {
+	blueprint, err := GetBlueprint(id, false)
+	if err != nil {
+		return nil, err
+	}
+	blueprint.SkipCollectors = triggerSyncPolicy.SkipCollectors
+	blueprint.FullSync = triggerSyncPolicy.FullSync
+	syncPolicy := blueprint.SyncPolicy
+	var plan models.PipelinePlan
+	if blueprint.Mode == models.BLUEPRINT_MODE_NORMAL {
+		plan, err = MakePlanForBlueprint(blueprint, &syncPolicy)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		plan = blueprint.Plan
+	}
+
+	dryRun := &BlueprintDryRun{
+		Plan:       plan,
+		SyncPolicy: syncPolicy,
+		Valid:      true,
+		Tasks:      make([]*BlueprintDryRunTask, 0),
+	}
+	productTables := make([]string, 0)
+	for stageIdx, stage := range plan {
+		for _, pipelineTask := range stage {
+			dryRunTask := &BlueprintDryRunTask{
+				Stage:    stageIdx + 1,
+				Plugin:   pipelineTask.Plugin,
+				Subtasks: make([]string, 0),
+			}
+			subtaskMetas, err := runner.DryRunPluginTask(ctx, basicRes, &models.Task{
+				Plugin:   pipelineTask.Plugin,
+				Subtasks: pipelineTask.Subtasks,
+				Options:  pipelineTask.Options,
+			}, &syncPolicy)
+			if err != nil {
+				dryRun.Valid = false
+				dryRunTask.Error = err.Messages().Format()
+			}
+			for _, subtaskMeta := range subtaskMetas {
+				dryRunTask.Subtasks = append(dryRunTask.Subtasks, subtaskMeta.Name)
+				if len(subtaskMeta.ProductTables) == 0 {
+					dryRunTask.UntrackedSubtasks = append(dryRunTask.UntrackedSubtasks, subtaskMeta.Name)
+				}
+				productTables = append(productTables, subtaskMeta.ProductTables...)
+			}
+			dryRun.Tasks = append(dryRun.Tasks, dryRunTask)
+		}
+	}
+	dryRun.RawTables, dryRun.ToolTables, dryRun.DomainTables = classifyTables(productTables)
+
+	for _, stage := range plan {
+		for _, pipelineTask := range stage {
+			if _, err := SanitizeTask(pipelineTask); err != nil {
+				return nil, errors.Convert(err)
+			}
+		}
+	}
+	return dryRun, nil
+}
+
+// classifyTables splits the tables into raw, tool and domain layer ones, each of them deduplicated and sorted.
+// Subtasks declare raw tables the way they pass them to RawDataSubTaskArgs, i.e. without the _raw_ prefix, so the
+// tables which are neither tool nor known domain layer tables are taken as raw ones and reported with the prefix
+func classifyTables(tables []string) (rawTables []string, toolTables []string, domainTables []string) {
+	rawTables, toolTables, domainTables = make([]string, 0), make([]string, 0), make([]string, 0)
+	knownDomainTables := make(map[string]bool)
+	for _, tabler := range domaininfo.GetDomainTablesInfo() {
+		knownDomainTables[tabler.TableName()] = true
+	}
+	seen := make(map[string]bool)
+	for _, table := range tables {
+		if table == "" {
+			continue
+		}
+		var layer *[]string
+		switch {
+		case strings.HasPrefix(table, "_raw_"):
+			layer = &rawTables
+		case strings.HasPrefix(table, "_tool_"):
+			layer = &toolTables
+		case knownDomainTables[table] || strings.HasPrefix(table, "_"):
+			layer = &domainTables
+		default:
+			layer = &rawTables
+			table = "_raw_" + table
+		}
+		if seen[table] {
+			continue
+		}
+		seen[table] = true
+		*layer = append(*layer, table)
+	}
+	sort.Strings(rawTables)
+	sort.Strings(toolTables)
+	sort.Strings(domainTables)
+	return
+}
@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/plugin"
+	githubTasks "github.com/apache/incubator-devlake/plugins/github/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyTables(t *testing.T) {
+	rawTables, toolTables, domainTables := classifyTables([]string{
+		"_raw_github_api_issues",
+		"issues",
+		"_tool_github_issues",
+		"",
+		"boards",
+		"_raw_github_api_issues",
+		"issues",
+	})
+	assert.Equal(t, []string{"_raw_github_api_issues"}, rawTables)
+	assert.Equal(t, []string{"_tool_github_issues"}, toolTables)
+	assert.Equal(t, []string{"boards", "issues"}, domainTables)
+}
+
+func TestClassifyTablesOfPluginMetas(t *testing.T) {
+	tables := make([]string, 0)
+	for _, meta := range []plugin.SubTaskMeta{
+		githubTasks.CollectApiIssuesMeta,
+		githubTasks.ExtractApiIssuesMeta,
+		githubTasks.ConvertIssuesMeta,
+	} {
+		tables = append(tables, meta.ProductTables...)
+	}
+	rawTables, toolTables, domainTables := classifyTables(tables)
+	// collectors declare their raw tables without the prefix
+	assert.Equal(t, []string{"_raw_" + githubTasks.RAW_ISSUE_TABLE}, rawTables)
+	assert.Equal(t, []string{
+		"_tool_github_issue_assignees", "_tool_github_issue_labels", "_tool_github_issues", "_tool_github_repo_accounts",
+	}, toolTables)
+	assert.Equal(t, []string{"board_issues", "issues"}, domainTables)
+}
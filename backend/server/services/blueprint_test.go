@@ -93,6 +93,43 @@ func TestParallelizePipelineTasks(t *testing.T) {
 	)
 }
 
+func TestSequentializeDagPipelinePlans(t *testing.T) {
+	legacy := coreModels.PipelinePlan{
+		{
+			{Plugin: "org"},
+		},
+	}
+	dag := coreModels.PipelinePlan{
+		{
+			{Plugin: "github", Id: "github"},
+			{Plugin: "jira", Id: "jira"},
+		},
+		{
+			{Plugin: "gitextractor", Id: "gitextractor", DependsOn: []string{"github"}},
+		},
+	}
+
+	assert.Equal(
+		t,
+		coreModels.PipelinePlan{
+			{
+				{Plugin: "org", Id: "org-1", DependsOn: []string{}},
+			},
+			{
+				{Plugin: "github", Id: "github", DependsOn: []string{"org-1"}},
+				{Plugin: "jira", Id: "jira", DependsOn: []string{"org-1"}},
+			},
+			{
+				{Plugin: "gitextractor", Id: "gitextractor", DependsOn: []string{"github"}},
+			},
+		},
+		SequentializePipelinePlans(legacy, dag),
+	)
+	// the original plans must remain intact
+	assert.Empty(t, legacy[0][0].Id)
+	assert.Empty(t, dag[0][0].DependsOn)
+}
+
 func TestRemoveCollectorTasks(t *testing.T) {
 	plan1 := coreModels.PipelinePlan{
 		{
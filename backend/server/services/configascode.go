@@ -0,0 +1,565 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
+	"gopkg.in/yaml.v3"
+)
+
+// CONFIG_BUNDLE_VERSION is the version of the configuration bundle format
+const CONFIG_BUNDLE_VERSION = 1
+
+// CONFIG_SECRET_ENV_PREFIX is the prefix of the environment variables which secrets of a configuration bundle may
+// reference, so the bundle can't be used to read any other variable of the server
+const CONFIG_SECRET_ENV_PREFIX = "DEVLAKE_SECRET_"
+
+const (
+	CONFIG_FORMAT_YAML = "yaml"
+	CONFIG_FORMAT_JSON = "json"
+)
+
+const (
+	CONFIG_KIND_CONNECTION   = "connection"
+	CONFIG_KIND_SCOPE_CONFIG = "scopeConfig"
+	CONFIG_KIND_SCOPE        = "scope"
+	CONFIG_KIND_PROJECT      = "project"
+	CONFIG_KIND_BLUEPRINT    = "blueprint"
+)
+
+const (
+	CONFIG_ACTION_CREATE    = "create"
+	CONFIG_ACTION_UPDATE    = "update"
+	CONFIG_ACTION_UNCHANGED = "unchanged"
+)
+
+// ConfigBundle declares projects, connections, scopes, scope configs and blueprints. Entities refer to each other by
+// name instead of id, so the same bundle can be applied to different environments.
+type ConfigBundle struct {
+	Version      int                  `json:"version"`
+	Connections  []*ConfigConnection  `json:"connections,omitempty"`
+	ScopeConfigs []*ConfigScopeConfig `json:"scopeConfigs,omitempty"`
+	Scopes       []*ConfigScope       `json:"scopes,omitempty"`
+	Projects     []*ConfigProject     `json:"projects,omitempty"`
+	Blueprints   []*ConfigBlueprint   `json:"blueprints,omitempty"`
+}
+
+// ConfigConnection declares a connection of a plugin, identified by its name
+type ConfigConnection struct {
+	Plugin     string                 `json:"plugin"`
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	// Secrets maps the secret fields of the connection to environment variables of the server, e.g. ${DEVLAKE_SECRET_TOKEN}
+	Secrets map[string]string `json:"secrets,omitempty"`
+}
+
+// ConfigScopeConfig declares a scope config of a connection, identified by its name
+type ConfigScopeConfig struct {
+	Plugin     string                 `json:"plugin"`
+	Connection string                 `json:"connection"`
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ConfigScope declares a scope of a connection, the attributes must contain the fields the scope id is made of
+type ConfigScope struct {
+	Plugin      string                 `json:"plugin"`
+	Connection  string                 `json:"connection"`
+	ScopeId     string                 `json:"scopeId"`
+	ScopeConfig string                 `json:"scopeConfig,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ConfigProject declares a project along with its metric settings
+type ConfigProject struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Metrics     []*models.BaseMetric `json:"metrics,omitempty"`
+}
+
+// ConfigBlueprint declares a blueprint, identified by its project if any, by its name otherwise. The plan is only
+// declared by ADVANCED blueprints, it is generated from the connections for NORMAL ones.
+type ConfigBlueprint struct {
+	Name        string                       `json:"name"`
+	ProjectName string                       `json:"projectName,omitempty"`
+	Mode        string                       `json:"mode"`
+	Enable      bool                         `json:"enable"`
+	CronConfig  string                       `json:"cronConfig,omitempty"`
+	IsManual    bool                         `json:"isManual"`
+	Priority    int                          `json:"priority,omitempty"`
+	Labels      []string                     `json:"labels,omitempty"`
+	SyncPolicy  models.SyncPolicy            `json:"syncPolicy"`
+	Plan        models.PipelinePlan          `json:"plan,omitempty"`
+	BeforePlan  models.PipelinePlan          `json:"beforePlan,omitempty"`
+	AfterPlan   models.PipelinePlan          `json:"afterPlan,omitempty"`
+	Connections []*ConfigBlueprintConnection `json:"connections,omitempty"`
+}
+
+// ConfigBlueprintConnection declares the scopes of a connection a blueprint collects
+type ConfigBlueprintConnection struct {
+	Plugin     string   `json:"plugin"`
+	Connection string   `json:"connection"`
+	Scopes     []string `json:"scopes,omitempty"`
+}
+
+// ConfigChange describes how an entity of a bundle differs from the current configuration
+type ConfigChange struct {
+	Kind   string `json:"kind"`
+	Plugin string `json:"plugin,omitempty"`
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	// Fields are the top-level fields to be updated
+	Fields []string `json:"fields,omitempty"`
+}
+
+// bookkeeping fields are maintained by DevLake, or expressed by references between the entities of a bundle
+var configBookkeepingFields = []string{
+	"createdAt", "updatedAt", "connectionId", "scopeConfigId",
+	"_raw_data_params", "_raw_data_table", "_raw_data_id", "_raw_data_remark",
+}
+
+var secretRefPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// secretEnvNameSanitizer matches the characters not allowed in the names of environment variables
+var secretEnvNameSanitizer = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// ParseConfigBundle parses a bundle in either YAML or JSON
+func ParseConfigBundle(data []byte) (*ConfigBundle, errors.Error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse the configuration bundle")
+	}
+	// convert to json so the bundle is decoded by the json tags of the models
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse the configuration bundle")
+	}
+	bundle := &ConfigBundle{}
+	if err := json.Unmarshal(jsonData, bundle); err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to decode the configuration bundle")
+	}
+	if bundle.Version != CONFIG_BUNDLE_VERSION {
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported configuration bundle version %d, expected %d", bundle.Version, CONFIG_BUNDLE_VERSION))
+	}
+	return bundle, nil
+}
+
+// MarshalConfigBundle renders the bundle in the given format, YAML by default
+func MarshalConfigBundle(bundle *ConfigBundle, format string) ([]byte, errors.Error) {
+	jsonData, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to marshal the configuration bundle")
+	}
+	switch format {
+	case CONFIG_FORMAT_JSON:
+		return jsonData, nil
+	case "", CONFIG_FORMAT_YAML:
+		// json is a subset of yaml, parsing it into nodes keeps the order of the fields
+		node := &yaml.Node{}
+		if err := yaml.Unmarshal(jsonData, node); err != nil {
+			return nil, errors.Default.Wrap(err, "failed to convert the configuration bundle to yaml")
+		}
+		resetYamlStyle(node)
+		buf := &bytes.Buffer{}
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(node); err != nil {
+			return nil, errors.Default.Wrap(err, "failed to convert the configuration bundle to yaml")
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported format %s", format))
+	}
+}
+
+// resetYamlStyle drops the flow style and quotes inherited from json, the encoder quotes strings when needed
+func resetYamlStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYamlStyle(child)
+	}
+}
+
+// ExportConfigBundle exports the current configuration as a bundle, secrets are replaced with references to
+// environment variables which have to be set on the server applying the bundle
+func ExportConfigBundle() (*ConfigBundle, errors.Error) {
+	bundle := &ConfigBundle{Version: CONFIG_BUNDLE_VERSION}
+	names := newConfigNames(db)
+	for _, pluginName := range configPluginNames() {
+		src, _ := configPluginSource(pluginName)
+		if err := exportPluginConfig(bundle, names, pluginName, src); err != nil {
+			return nil, err
+		}
+	}
+
+	projects := make([]*models.Project, 0)
+	if err := db.All(&projects, dal.Orderby("name")); err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		configProject, err := projectToConfig(db, project)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Projects = append(bundle.Projects, configProject)
+	}
+
+	blueprints, _, err := bpManager.GetDbBlueprints(&services.GetBlueprintQuery{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(blueprints, func(i, j int) bool {
+		if blueprints[i].ProjectName != blueprints[j].ProjectName {
+			return blueprints[i].ProjectName < blueprints[j].ProjectName
+		}
+		return blueprints[i].Name < blueprints[j].Name
+	})
+	for _, blueprint := range blueprints {
+		configBlueprint, err := blueprintToConfig(blueprint, names)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Blueprints = append(bundle.Blueprints, configBlueprint)
+	}
+	return bundle, nil
+}
+
+func exportPluginConfig(bundle *ConfigBundle, names *configNames, pluginName string, src plugin.PluginSource) errors.Error {
+	connections, err := loadConfigModels(db, src.Connection(), dal.Orderby("id"))
+	if err != nil {
+		return err
+	}
+	for _, connection := range connections {
+		attributes, err := toConfigMap(connection)
+		if err != nil {
+			return err
+		}
+		name, _ := attributes["name"].(string)
+		names.setConnection(pluginName, connection.(plugin.ToolLayerConnection).ConnectionId(), name)
+		configConnection := &ConfigConnection{Plugin: pluginName, Name: name}
+		for _, field := range secretFields(reflect.TypeOf(connection)) {
+			if value, ok := attributes[field].(string); ok && value != "" {
+				if configConnection.Secrets == nil {
+					configConnection.Secrets = make(map[string]string)
+				}
+				configConnection.Secrets[field] = fmt.Sprintf("${%s}", secretEnvName(pluginName, name, field))
+			}
+			delete(attributes, field)
+		}
+		delete(attributes, "id")
+		delete(attributes, "name")
+		configConnection.Attributes = dropBookkeepingFields(attributes)
+		bundle.Connections = append(bundle.Connections, configConnection)
+	}
+
+	if configModelIsNil(src.ScopeConfig()) {
+		return nil
+	}
+	scopeConfigs, err := loadConfigModels(db, src.ScopeConfig(), dal.Orderby("id"))
+	if err != nil {
+		return err
+	}
+	for _, scopeConfig := range scopeConfigs {
+		attributes, err := toConfigMap(scopeConfig)
+		if err != nil {
+			return err
+		}
+		sc := scopeConfig.(plugin.ToolLayerScopeConfig)
+		name, _ := attributes["name"].(string)
+		names.setScopeConfig(pluginName, sc.ScopeConfigId(), name)
+		delete(attributes, "id")
+		delete(attributes, "name")
+		bundle.ScopeConfigs = append(bundle.ScopeConfigs, &ConfigScopeConfig{
+			Plugin:     pluginName,
+			Connection: names.connection(pluginName, sc.ScopeConfigConnectionId()),
+			Name:       name,
+			Attributes: dropBookkeepingFields(attributes),
+		})
+	}
+
+	if configModelIsNil(src.Scope()) {
+		return nil
+	}
+	scopes, err := loadConfigModels(db, src.Scope(), dal.Orderby("connection_id"))
+	if err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		attributes, err := toConfigMap(scope)
+		if err != nil {
+			return err
+		}
+		s := scope.(plugin.ToolLayerScope)
+		bundle.Scopes = append(bundle.Scopes, &ConfigScope{
+			Plugin:      pluginName,
+			Connection:  names.connection(pluginName, s.ScopeConnectionId()),
+			ScopeId:     s.ScopeId(),
+			ScopeConfig: names.scopeConfig(pluginName, s.ScopeScopeConfigId()),
+			Attributes:  dropBookkeepingFields(attributes),
+		})
+	}
+	return nil
+}
+
+func projectToConfig(tx dal.Dal, project *models.Project) (*ConfigProject, errors.Error) {
+	metrics := make([]*models.ProjectMetricSetting, 0)
+	if err := tx.All(&metrics, dal.Where("project_name = ?", project.Name), dal.Orderby("plugin_name")); err != nil {
+		return nil, err
+	}
+	configProject := &ConfigProject{
+		Name:        project.Name,
+		Description: project.Description,
+	}
+	for _, metric := range metrics {
+		baseMetric := metric.BaseMetric
+		configProject.Metrics = append(configProject.Metrics, &baseMetric)
+	}
+	return configProject, nil
+}
+
+func blueprintToConfig(blueprint *models.Blueprint, names *configNames) (*ConfigBlueprint, errors.Error) {
+	configBlueprint := &ConfigBlueprint{
+		Name:        blueprint.Name,
+		ProjectName: blueprint.ProjectName,
+		Mode:        blueprint.Mode,
+		Enable:      blueprint.Enable,
+		CronConfig:  blueprint.CronConfig,
+		IsManual:    blueprint.IsManual,
+		Priority:    blueprint.Priority,
+		Labels:      blueprint.Labels,
+		SyncPolicy:  blueprint.SyncPolicy,
+		BeforePlan:  blueprint.BeforePlan,
+		AfterPlan:   blueprint.AfterPlan,
+	}
+	if blueprint.Mode == models.BLUEPRINT_MODE_ADVANCED {
+		configBlueprint.Plan = blueprint.Plan
+	}
+	for _, connection := range blueprint.Connections {
+		if err := names.loadConnection(connection.PluginName, connection.ConnectionId); err != nil {
+			return nil, err
+		}
+		configConnection := &ConfigBlueprintConnection{
+			Plugin:     connection.PluginName,
+			Connection: names.connection(connection.PluginName, connection.ConnectionId),
+		}
+		for _, scope := range connection.Scopes {
+			configConnection.Scopes = append(configConnection.Scopes, scope.ScopeId)
+		}
+		configBlueprint.Connections = append(configBlueprint.Connections, configConnection)
+	}
+	return configBlueprint, nil
+}
+
+// configNames maps the ids of connections and scope configs to their names
+type configNames struct {
+	db           dal.Dal
+	connections  map[string]string
+	scopeConfigs map[string]string
+}
+
+func newConfigNames(db dal.Dal) *configNames {
+	return &configNames{
+		db:           db,
+		connections:  make(map[string]string),
+		scopeConfigs: make(map[string]string),
+	}
+}
+
+func (n *configNames) setConnection(pluginName string, id uint64, name string) {
+	n.connections[fmt.Sprintf("%s#%d", pluginName, id)] = name
+}
+
+// connection returns the name of the connection, or plugin#id if the connection doesn't exist
+func (n *configNames) connection(pluginName string, id uint64) string {
+	if name, ok := n.connections[fmt.Sprintf("%s#%d", pluginName, id)]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s#%d", pluginName, id)
+}
+
+// loadConnection loads the name of the connection unless it was loaded before
+func (n *configNames) loadConnection(pluginName string, id uint64) errors.Error {
+	if _, ok := n.connections[fmt.Sprintf("%s#%d", pluginName, id)]; ok {
+		return nil
+	}
+	src, err := configPluginSource(pluginName)
+	if err != nil {
+		return err
+	}
+	connection := newConfigModel(src.Connection())
+	if err := n.db.First(connection, dal.Where("id = ?", id)); err != nil {
+		if n.db.IsErrorNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	attributes, err := toConfigMap(connection)
+	if err != nil {
+		return err
+	}
+	name, _ := attributes["name"].(string)
+	n.setConnection(pluginName, id, name)
+	return nil
+}
+
+func (n *configNames) setScopeConfig(pluginName string, id uint64, name string) {
+	n.scopeConfigs[fmt.Sprintf("%s#%d", pluginName, id)] = name
+}
+
+// scopeConfig returns the name of the scope config, or an empty string if there is none
+func (n *configNames) scopeConfig(pluginName string, id uint64) string {
+	return n.scopeConfigs[fmt.Sprintf("%s#%d", pluginName, id)]
+}
+
+// configPluginNames returns the sorted names of the plugins with connections
+func configPluginNames() []string {
+	pluginNames := make([]string, 0)
+	for pluginName := range plugin.AllPlugins() {
+		if _, err := configPluginSource(pluginName); err == nil {
+			pluginNames = append(pluginNames, pluginName)
+		}
+	}
+	sort.Strings(pluginNames)
+	return pluginNames
+}
+
+func configPluginSource(pluginName string) (plugin.PluginSource, errors.Error) {
+	pluginMeta, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("unknown plugin %s", pluginName))
+	}
+	src, ok := pluginMeta.(plugin.PluginSource)
+	if !ok || configModelIsNil(src.Connection()) {
+		return nil, errors.BadInput.New(fmt.Sprintf("plugin %s has no connections", pluginName))
+	}
+	return src, nil
+}
+
+func configModelIsNil(model interface{}) bool {
+	if model == nil {
+		return true
+	}
+	v := reflect.ValueOf(model)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// newConfigModel returns a pointer to a new model of the same type as the given prototype
+func newConfigModel(prototype interface{}) interface{} {
+	return reflect.New(reflect.Indirect(reflect.ValueOf(prototype)).Type()).Interface()
+}
+
+// loadConfigModels loads all records of the prototype's table as pointers to models
+func loadConfigModels(tx dal.Dal, prototype interface{}, clauses ...dal.Clause) ([]interface{}, errors.Error) {
+	modelType := reflect.Indirect(reflect.ValueOf(prototype)).Type()
+	records := reflect.New(reflect.SliceOf(reflect.PtrTo(modelType)))
+	if err := tx.All(records.Interface(), clauses...); err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, records.Elem().Len())
+	for i := range result {
+		result[i] = records.Elem().Index(i).Interface()
+	}
+	return result, nil
+}
+
+func toConfigMap(model interface{}) (map[string]interface{}, errors.Error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to marshal the model")
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to unmarshal the model")
+	}
+	return m, nil
+}
+
+func dropBookkeepingFields(attributes map[string]interface{}) map[string]interface{} {
+	for _, field := range configBookkeepingFields {
+		delete(attributes, field)
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// jsonFields returns the top-level json fields of the struct type, including the ones of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for embeddedName, embeddedField := range jsonFields(field.Type) {
+				if _, ok := fields[embeddedName]; !ok {
+					fields[embeddedName] = embeddedField
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// secretFields returns the sorted json names of the fields encrypted in the database
+func secretFields(t reflect.Type) []string {
+	secrets := make([]string, 0)
+	for name, field := range jsonFields(t) {
+		if strings.Contains(field.Tag.Get("gorm"), "serializer:encdec") {
+			secrets = append(secrets, name)
+		}
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// secretEnvName generates the name of the environment variable holding a secret of a connection
+func secretEnvName(pluginName, connectionName, field string) string {
+	name := strings.ToUpper(fmt.Sprintf("%s%s_%s_%s", CONFIG_SECRET_ENV_PREFIX, pluginName, connectionName, field))
+	return secretEnvNameSanitizer.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,661 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
+)
+
+// DiffConfigBundle compares the bundle with the current configuration without changing anything
+func DiffConfigBundle(bundle *ConfigBundle) ([]*ConfigChange, errors.Error) {
+	return newConfigReconciler(db, nil).reconcile(bundle)
+}
+
+// ApplyConfigBundle creates or updates the entities of the bundle which differ from the current configuration.
+// Entities missing from the bundle are left untouched, and applying the same bundle again changes nothing.
+// The bundle is applied as a whole, nothing is changed if any of its entities fails.
+func ApplyConfigBundle(bundle *ConfigBundle) ([]*ConfigChange, errors.Error) {
+	var err errors.Error
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Error(rollbackErr, "ApplyConfigBundle: failed to rollback")
+			}
+			if r != nil {
+				panic(r)
+			}
+		}
+	}()
+	reconciler := newConfigReconciler(tx, tx)
+	changes, err := reconciler.reconcile(bundle)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to commit the config bundle")
+	}
+
+	// plugins make the plans with their own connections to the database, which see the applied entities
+	// only after the commit, and plans of NORMAL blueprints are made again on every run anyway
+	for _, blueprint := range reconciler.blueprints {
+		if blueprint.Mode == models.BLUEPRINT_MODE_NORMAL {
+			plan, planErr := MakePlanForBlueprint(blueprint, &blueprint.SyncPolicy)
+			if planErr != nil {
+				return nil, errors.Default.Wrap(planErr, fmt.Sprintf("the bundle was applied but the plan of blueprint %s could not be made", blueprint.Name))
+			}
+			blueprint.Plan = plan
+			if planErr = db.Update(blueprint); planErr != nil {
+				return nil, planErr
+			}
+		}
+		if reloadErr := reloadBlueprint(blueprint); reloadErr != nil {
+			return nil, reloadErr
+		}
+	}
+	return changes, nil
+}
+
+type configReconciler struct {
+	// db reads the current configuration, it is the transaction applying the bundle if tx is set
+	db dal.Dal
+	// tx writes the changes, nil when the bundle is only compared with the current configuration
+	tx      dal.Transaction
+	apply   bool
+	changes []*ConfigChange
+	// ids of connections and scope configs by plugin and name, 0 for the ones yet to be created
+	connectionIds  map[string]uint64
+	scopeConfigIds map[string]uint64
+	names          *configNames
+	// blueprints written by the transaction, their schedules get reloaded once it is committed
+	blueprints []*models.Blueprint
+}
+
+func newConfigReconciler(db dal.Dal, tx dal.Transaction) *configReconciler {
+	return &configReconciler{
+		db:             db,
+		tx:             tx,
+		apply:          tx != nil,
+		changes:        make([]*ConfigChange, 0),
+		connectionIds:  make(map[string]uint64),
+		scopeConfigIds: make(map[string]uint64),
+		names:          newConfigNames(db),
+	}
+}
+
+func (r *configReconciler) reconcile(bundle *ConfigBundle) ([]*ConfigChange, errors.Error) {
+	// entities are reconciled in order of their dependencies
+	for _, connection := range bundle.Connections {
+		if err := r.reconcileConnection(connection); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to reconcile connection %s/%s", connection.Plugin, connection.Name))
+		}
+	}
+	for _, scopeConfig := range bundle.ScopeConfigs {
+		if err := r.reconcileScopeConfig(scopeConfig); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to reconcile scope config %s/%s", scopeConfig.Plugin, scopeConfig.Name))
+		}
+	}
+	for _, scope := range bundle.Scopes {
+		if err := r.reconcileScope(scope); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to reconcile scope %s/%s", scope.Plugin, scope.ScopeId))
+		}
+	}
+	for _, project := range bundle.Projects {
+		if err := r.reconcileProject(project); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to reconcile project %s", project.Name))
+		}
+	}
+	for _, blueprint := range bundle.Blueprints {
+		if err := r.reconcileBlueprint(blueprint); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to reconcile blueprint %s", blueprint.Name))
+		}
+	}
+	return r.changes, nil
+}
+
+// record adds the change of the entity, and returns whether it has to be written
+func (r *configReconciler) record(change *ConfigChange, exists bool) bool {
+	switch {
+	case !exists:
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = nil
+	case len(change.Fields) > 0:
+		change.Action = CONFIG_ACTION_UPDATE
+	default:
+		change.Action = CONFIG_ACTION_UNCHANGED
+	}
+	r.changes = append(r.changes, change)
+	return r.apply && change.Action != CONFIG_ACTION_UNCHANGED
+}
+
+func (r *configReconciler) reconcileConnection(spec *ConfigConnection) errors.Error {
+	src, err := configPluginSource(spec.Plugin)
+	if err != nil {
+		return err
+	}
+	if spec.Name == "" {
+		return errors.BadInput.New("name is required")
+	}
+	connection := newConfigModel(src.Connection())
+	desired, err := configAttributes(connection, spec.Attributes, "id", "name")
+	if err != nil {
+		return err
+	}
+	secrets := secretFields(reflect.TypeOf(connection))
+	for field, ref := range spec.Secrets {
+		if !stringSliceContains(secrets, field) {
+			return errors.BadInput.New(fmt.Sprintf("%s is not a secret field", field))
+		}
+		value, err := resolveSecretRef(ref)
+		if err != nil {
+			return err
+		}
+		desired[field] = value
+	}
+	desired["name"] = spec.Name
+
+	exists, err := r.findModel(connection, dal.Where("name = ?", spec.Name))
+	if err != nil {
+		return err
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_CONNECTION, Plugin: spec.Plugin, Key: spec.Name}
+	if exists {
+		if change.Fields, err = changedConfigFields(connection, desired); err != nil {
+			return err
+		}
+	}
+	if r.record(change, exists) {
+		if err := r.saveModel(connection, desired, exists); err != nil {
+			return err
+		}
+	}
+	var id uint64
+	if exists || r.apply {
+		id = connection.(plugin.ToolLayerConnection).ConnectionId()
+	}
+	r.connectionIds[spec.Plugin+"/"+spec.Name] = id
+	r.names.setConnection(spec.Plugin, id, spec.Name)
+	return nil
+}
+
+func (r *configReconciler) reconcileScopeConfig(spec *ConfigScopeConfig) errors.Error {
+	src, err := configPluginSource(spec.Plugin)
+	if err != nil {
+		return err
+	}
+	if configModelIsNil(src.ScopeConfig()) {
+		return errors.BadInput.New(fmt.Sprintf("plugin %s has no scope configs", spec.Plugin))
+	}
+	if spec.Name == "" {
+		return errors.BadInput.New("name is required")
+	}
+	connectionId, err := r.connectionId(spec.Plugin, spec.Connection)
+	if err != nil {
+		return err
+	}
+	scopeConfig := newConfigModel(src.ScopeConfig())
+	desired, err := configAttributes(scopeConfig, spec.Attributes, "id", "name")
+	if err != nil {
+		return err
+	}
+	desired["name"] = spec.Name
+
+	exists := false
+	if connectionId != 0 {
+		exists, err = r.findModel(scopeConfig, dal.Where("connection_id = ? AND name = ?", connectionId, spec.Name))
+		if err != nil {
+			return err
+		}
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_SCOPE_CONFIG, Plugin: spec.Plugin, Key: spec.Connection + "/" + spec.Name}
+	if exists {
+		if change.Fields, err = changedConfigFields(scopeConfig, desired); err != nil {
+			return err
+		}
+	}
+	if r.record(change, exists) {
+		desired["connectionId"] = connectionId
+		if err := r.saveModel(scopeConfig, desired, exists); err != nil {
+			return err
+		}
+	}
+	var id uint64
+	if exists || r.apply {
+		id = scopeConfig.(plugin.ToolLayerScopeConfig).ScopeConfigId()
+	}
+	r.scopeConfigIds[spec.Plugin+"/"+spec.Connection+"/"+spec.Name] = id
+	return nil
+}
+
+func (r *configReconciler) reconcileScope(spec *ConfigScope) errors.Error {
+	src, err := configPluginSource(spec.Plugin)
+	if err != nil {
+		return err
+	}
+	if configModelIsNil(src.Scope()) {
+		return errors.BadInput.New(fmt.Sprintf("plugin %s has no scopes", spec.Plugin))
+	}
+	connectionId, err := r.connectionId(spec.Plugin, spec.Connection)
+	if err != nil {
+		return err
+	}
+	var scopeConfigId uint64
+	if spec.ScopeConfig != "" {
+		id, ok := r.scopeConfigIds[spec.Plugin+"/"+spec.Connection+"/"+spec.ScopeConfig]
+		if !ok {
+			return errors.BadInput.New(fmt.Sprintf("scope config %s is not declared by the bundle", spec.ScopeConfig))
+		}
+		scopeConfigId = id
+	}
+	desired, err := configAttributes(newConfigModel(src.Scope()), spec.Attributes)
+	if err != nil {
+		return err
+	}
+
+	var scope plugin.ToolLayerScope
+	if connectionId != 0 {
+		scopes, err := loadConfigModels(r.db, src.Scope(), dal.Where("connection_id = ?", connectionId))
+		if err != nil {
+			return err
+		}
+		for _, s := range scopes {
+			if s.(plugin.ToolLayerScope).ScopeId() == spec.ScopeId {
+				scope = s.(plugin.ToolLayerScope)
+				break
+			}
+		}
+	}
+	exists := scope != nil
+	change := &ConfigChange{Kind: CONFIG_KIND_SCOPE, Plugin: spec.Plugin, Key: spec.Connection + "/" + spec.ScopeId}
+	if exists {
+		if change.Fields, err = changedConfigFields(scope, desired); err != nil {
+			return err
+		}
+		if scope.ScopeScopeConfigId() != scopeConfigId {
+			change.Fields = append(change.Fields, "scopeConfig")
+		}
+	} else {
+		scope = newConfigModel(src.Scope()).(plugin.ToolLayerScope)
+	}
+	if r.record(change, exists) {
+		desired["connectionId"] = connectionId
+		desired["scopeConfigId"] = scopeConfigId
+		if err := decodeConfigModel(desired, scope); err != nil {
+			return err
+		}
+		if scope.ScopeId() != spec.ScopeId {
+			return errors.BadInput.New(fmt.Sprintf("the attributes make up scope id %s instead of %s", scope.ScopeId(), spec.ScopeId))
+		}
+		if err := r.saveModel(scope, nil, exists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *configReconciler) reconcileProject(spec *ConfigProject) errors.Error {
+	if spec.Name == "" {
+		return errors.BadInput.New("name is required")
+	}
+	project, err := getProjectByName(r.db, spec.Name)
+	if err != nil && err.GetType() != errors.NotFound {
+		return err
+	}
+	exists := project != nil
+	change := &ConfigChange{Kind: CONFIG_KIND_PROJECT, Key: spec.Name}
+	if exists {
+		current, err := projectToConfig(r.db, project)
+		if err != nil {
+			return err
+		}
+		if change.Fields, err = changedConfigFieldsOf(current, spec); err != nil {
+			return err
+		}
+	}
+	if !r.record(change, exists) {
+		return nil
+	}
+	projectInput := &models.ApiInputProject{
+		BaseProject: models.BaseProject{Name: spec.Name, Description: spec.Description},
+		Metrics:     spec.Metrics,
+	}
+	if !exists {
+		if err := VerifyStruct(projectInput); err != nil {
+			return err
+		}
+		_, blueprint, err := createProject(r.tx, projectInput)
+		if err != nil {
+			return err
+		}
+		r.addBlueprint(blueprint)
+		return nil
+	}
+
+	project.BaseProject = projectInput.BaseProject
+	err = r.tx.Update(project)
+	if err != nil {
+		return err
+	}
+	return refreshProjectMetrics(r.tx, projectInput)
+}
+
+func (r *configReconciler) reconcileBlueprint(spec *ConfigBlueprint) errors.Error {
+	if spec.Mode == models.BLUEPRINT_MODE_NORMAL && len(spec.Plan) > 0 {
+		return errors.BadInput.New("the plan of a NORMAL blueprint is generated from its connections")
+	}
+	connections := make([]*models.BlueprintConnection, 0, len(spec.Connections))
+	for _, connection := range spec.Connections {
+		connectionId, err := r.connectionId(connection.Plugin, connection.Connection)
+		if err != nil {
+			return err
+		}
+		blueprintConnection := &models.BlueprintConnection{
+			PluginName:   connection.Plugin,
+			ConnectionId: connectionId,
+		}
+		for _, scopeId := range connection.Scopes {
+			blueprintConnection.Scopes = append(blueprintConnection.Scopes, &models.BlueprintScope{ScopeId: scopeId})
+		}
+		connections = append(connections, blueprintConnection)
+	}
+
+	blueprint, err := r.findBlueprint(spec)
+	if err != nil {
+		return err
+	}
+	exists := blueprint != nil
+	key := spec.Name
+	if spec.ProjectName != "" {
+		key = spec.ProjectName + "/" + spec.Name
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_BLUEPRINT, Key: key}
+	if exists {
+		current, err := blueprintToConfig(blueprint, r.names)
+		if err != nil {
+			return err
+		}
+		if change.Fields, err = changedConfigFieldsOf(current, spec); err != nil {
+			return err
+		}
+	} else {
+		blueprint = &models.Blueprint{}
+	}
+	if !r.record(change, exists) {
+		return nil
+	}
+	blueprint.Name = spec.Name
+	blueprint.ProjectName = spec.ProjectName
+	blueprint.Mode = spec.Mode
+	blueprint.Enable = spec.Enable
+	blueprint.CronConfig = spec.CronConfig
+	blueprint.IsManual = spec.IsManual
+	blueprint.Priority = spec.Priority
+	blueprint.Labels = spec.Labels
+	blueprint.SyncPolicy = spec.SyncPolicy
+	blueprint.Plan = spec.Plan
+	blueprint.BeforePlan = spec.BeforePlan
+	blueprint.AfterPlan = spec.AfterPlan
+	blueprint.Connections = connections
+	err = validateBlueprint(r.tx, blueprint)
+	if err != nil {
+		return errors.BadInput.WrapRaw(err)
+	}
+	err = services.NewBlueprintManager(r.tx).SaveDbBlueprint(blueprint)
+	if err != nil {
+		return err
+	}
+	r.addBlueprint(blueprint)
+	return nil
+}
+
+// connectionId returns the id of a connection declared by the bundle, 0 if it is yet to be created
+func (r *configReconciler) connectionId(pluginName, name string) (uint64, errors.Error) {
+	id, ok := r.connectionIds[pluginName+"/"+name]
+	if !ok {
+		return 0, errors.BadInput.New(fmt.Sprintf("connection %s/%s is not declared by the bundle", pluginName, name))
+	}
+	return id, nil
+}
+
+// addBlueprint records the blueprint written by the transaction, replacing the earlier record of the same blueprint
+func (r *configReconciler) addBlueprint(blueprint *models.Blueprint) {
+	for i, b := range r.blueprints {
+		if b.ID == blueprint.ID {
+			r.blueprints[i] = blueprint
+			return
+		}
+	}
+	r.blueprints = append(r.blueprints, blueprint)
+}
+
+// findBlueprint finds the blueprint of the project, or the blueprint with the name if it belongs to no project
+func (r *configReconciler) findBlueprint(spec *ConfigBlueprint) (*models.Blueprint, errors.Error) {
+	blueprintManager := services.NewBlueprintManager(r.db)
+	if spec.ProjectName != "" {
+		blueprint, err := blueprintManager.GetDbBlueprintByProjectName(spec.ProjectName)
+		if err != nil && r.db.IsErrorNotFound(err) {
+			return nil, nil
+		}
+		return blueprint, err
+	}
+	blueprints := make([]*models.Blueprint, 0)
+	err := r.db.All(&blueprints, dal.Where("name = ? AND (project_name = '' OR project_name IS NULL)", spec.Name))
+	if err != nil {
+		return nil, err
+	}
+	switch len(blueprints) {
+	case 0:
+		return nil, nil
+	case 1:
+		return blueprintManager.GetDbBlueprint(blueprints[0].ID)
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("there are %d blueprints named %s without project", len(blueprints), spec.Name))
+	}
+}
+
+// configAttributes validates the attributes against the json fields of the model, and returns a copy of them
+func configAttributes(model interface{}, attributes map[string]interface{}, managedFields ...string) (map[string]interface{}, errors.Error) {
+	fields := jsonFields(reflect.TypeOf(model))
+	managedFields = append(managedFields, configBookkeepingFields...)
+	result := make(map[string]interface{}, len(attributes))
+	for name, value := range attributes {
+		if stringSliceContains(managedFields, name) {
+			return nil, errors.BadInput.New(fmt.Sprintf("attribute %s is managed by the bundle", name))
+		}
+		if _, ok := fields[name]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown attribute %s", name))
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// resolveSecretRef resolves a ${NAME} reference with the environment variable of the server
+func resolveSecretRef(ref string) (string, errors.Error) {
+	matches := secretRefPattern.FindStringSubmatch(ref)
+	if matches == nil {
+		return "", errors.BadInput.New(fmt.Sprintf("secrets must reference an environment variable like ${%sNAME} instead of being inlined", CONFIG_SECRET_ENV_PREFIX))
+	}
+	name := matches[1]
+	if !strings.HasPrefix(name, CONFIG_SECRET_ENV_PREFIX) {
+		return "", errors.BadInput.New(fmt.Sprintf("secret %s must be prefixed with %s", name, CONFIG_SECRET_ENV_PREFIX))
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.BadInput.New(fmt.Sprintf("environment variable %s is not set", name))
+	}
+	return value, nil
+}
+
+// findModel loads the first matching record into the model, and returns whether it exists
+func (r *configReconciler) findModel(model interface{}, clauses ...dal.Clause) (bool, errors.Error) {
+	err := r.db.First(model, clauses...)
+	if err != nil {
+		if r.db.IsErrorNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// saveModel decodes the desired attributes into the model and writes it within the transaction
+func (r *configReconciler) saveModel(model interface{}, desired map[string]interface{}, exists bool) errors.Error {
+	if desired != nil {
+		if err := decodeConfigModel(desired, model); err != nil {
+			return err
+		}
+	}
+	if exists {
+		return r.tx.Update(model)
+	}
+	return r.tx.Create(model)
+}
+
+// decodeConfigModel overrides the fields of the model with the attributes, leaving the other fields untouched
+func decodeConfigModel(attributes map[string]interface{}, model interface{}) errors.Error {
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return errors.BadInput.Wrap(err, "failed to marshal the attributes")
+	}
+	if err := json.Unmarshal(data, model); err != nil {
+		return errors.BadInput.Wrap(err, "failed to decode the attributes")
+	}
+	return nil
+}
+
+// changedConfigFields returns the sorted desired attributes whose values differ from the model
+func changedConfigFields(model interface{}, desired map[string]interface{}) ([]string, errors.Error) {
+	current, err := toConfigMap(model)
+	if err != nil {
+		return nil, err
+	}
+	changed := make([]string, 0)
+	for name, value := range desired {
+		equal, err := configValuesEqual(current[name], value)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// changedConfigFieldsOf returns the sorted top-level fields which differ between the two declarations
+func changedConfigFieldsOf(current, desired interface{}) ([]string, errors.Error) {
+	currentMap, err := toConfigMap(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredMap, err := toConfigMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	for name := range currentMap {
+		if _, ok := desiredMap[name]; !ok {
+			desiredMap[name] = nil
+		}
+	}
+	changed := make([]string, 0)
+	for name, value := range desiredMap {
+		equal, err := configValuesEqual(currentMap[name], value)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// configValuesEqual compares the json representations of the values, timestamps are compared by the instant they
+// represent
+func configValuesEqual(a, b interface{}) (bool, errors.Error) {
+	na, err := normalizeConfigValue(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalizeConfigValue(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
+
+func normalizeConfigValue(value interface{}) (interface{}, errors.Error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to marshal the value")
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to unmarshal the value")
+	}
+	return normalizeConfigTimes(normalized), nil
+}
+
+func normalizeConfigTimes(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeConfigTimes(item)
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeConfigTimes(item)
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+func stringSliceContains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
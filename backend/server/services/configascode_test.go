@@ -0,0 +1,198 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testConfigConnection struct {
+	helper.BaseConnection `mapstructure:",squash"`
+	helper.RestConnection `mapstructure:",squash"`
+	helper.AccessToken    `mapstructure:",squash"`
+	Internal              string `json:"-"`
+}
+
+func (testConfigConnection) TableName() string {
+	return "_tool_test_connections"
+}
+
+type testConfigScope struct {
+	common.Scope `mapstructure:",squash"`
+	RepoId       int    `json:"repoId" gorm:"primaryKey"`
+	Name         string `json:"name"`
+}
+
+func TestParseAndMarshalConfigBundle(t *testing.T) {
+	bundle, err := ParseConfigBundle([]byte(`
+version: 1
+connections:
+  - plugin: github
+    name: github-cloud
+    attributes:
+      endpoint: https://api.github.com/
+      rateLimitPerHour: 4500
+    secrets:
+      token: ${DEVLAKE_SECRET_GITHUB_TOKEN}
+scopes:
+  - plugin: github
+    connection: github-cloud
+    scopeId: "123"
+    attributes:
+      githubId: 123
+blueprints:
+  - name: bp
+    projectName: p
+    mode: NORMAL
+    enable: true
+    cronConfig: 0 0 * * *
+    syncPolicy:
+      timeAfter: 2023-01-01T00:00:00Z
+      skipCollectors: true
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://api.github.com/", bundle.Connections[0].Attributes["endpoint"])
+	assert.Equal(t, "${DEVLAKE_SECRET_GITHUB_TOKEN}", bundle.Connections[0].Secrets["token"])
+	assert.Equal(t, "123", bundle.Scopes[0].ScopeId)
+	assert.True(t, bundle.Blueprints[0].SyncPolicy.SkipCollectors)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), bundle.Blueprints[0].SyncPolicy.TimeAfter.UTC())
+
+	for _, format := range []string{CONFIG_FORMAT_YAML, CONFIG_FORMAT_JSON} {
+		data, err := MarshalConfigBundle(bundle, format)
+		assert.Nil(t, err)
+		parsed, err := ParseConfigBundle(data)
+		assert.Nil(t, err)
+		assert.Equal(t, bundle, parsed)
+	}
+
+	_, err = ParseConfigBundle([]byte(`version: 2`))
+	assert.NotNil(t, err)
+	_, err = MarshalConfigBundle(bundle, "xml")
+	assert.NotNil(t, err)
+}
+
+func TestConfigModelFields(t *testing.T) {
+	fields := jsonFields(reflect.TypeOf(&testConfigConnection{}))
+	assert.Contains(t, fields, "name")
+	assert.Contains(t, fields, "endpoint")
+	assert.Contains(t, fields, "token")
+	assert.Contains(t, fields, "id")
+	assert.NotContains(t, fields, "Internal")
+	assert.Equal(t, []string{"token"}, secretFields(reflect.TypeOf(&testConfigConnection{})))
+
+	attributes, err := configAttributes(&testConfigConnection{}, map[string]interface{}{"endpoint": "https://example.com"}, "id", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"endpoint": "https://example.com"}, attributes)
+	_, err = configAttributes(&testConfigConnection{}, map[string]interface{}{"endpoints": "https://example.com"})
+	assert.NotNil(t, err)
+	_, err = configAttributes(&testConfigConnection{}, map[string]interface{}{"name": "conn"}, "id", "name")
+	assert.NotNil(t, err)
+	_, err = configAttributes(&testConfigScope{}, map[string]interface{}{"connectionId": 1})
+	assert.NotNil(t, err)
+}
+
+func TestConfigSecrets(t *testing.T) {
+	assert.Equal(t, "DEVLAKE_SECRET_GITHUB_MY_CONN_TOKEN", secretEnvName("github", "my-conn", "token"))
+
+	t.Setenv("DEVLAKE_SECRET_TEST_TOKEN", "secret")
+	t.Setenv("TEST_TOKEN", "secret")
+	value, err := resolveSecretRef("${DEVLAKE_SECRET_TEST_TOKEN}")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", value)
+	_, err = resolveSecretRef("secret")
+	assert.NotNil(t, err)
+	_, err = resolveSecretRef("${TEST_TOKEN}")
+	assert.NotNil(t, err)
+	_, err = resolveSecretRef("${DEVLAKE_SECRET_MISSING}")
+	assert.NotNil(t, err)
+}
+
+func TestChangedConfigFields(t *testing.T) {
+	connection := &testConfigConnection{}
+	connection.Name = "conn"
+	connection.Endpoint = "https://example.com/"
+	connection.RateLimitPerHour = 100
+	connection.Token = "secret"
+	fields, err := changedConfigFields(connection, map[string]interface{}{
+		"name":             "conn",
+		"endpoint":         "https://example.com/",
+		"rateLimitPerHour": 100,
+		"token":            "new secret",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"token"}, fields)
+
+	assert.Nil(t, decodeConfigModel(map[string]interface{}{"token": "new secret"}, connection))
+	assert.Equal(t, "new secret", connection.Token)
+	assert.Equal(t, "https://example.com/", connection.Endpoint)
+
+	timeAfter := time.Date(2023, 1, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	utcTimeAfter := timeAfter.UTC()
+	current := &ConfigBlueprint{Name: "bp", Mode: models.BLUEPRINT_MODE_NORMAL, SyncPolicy: models.SyncPolicy{TimeAfter: &timeAfter}}
+	desired := &ConfigBlueprint{Name: "bp", Mode: models.BLUEPRINT_MODE_NORMAL, SyncPolicy: models.SyncPolicy{TimeAfter: &utcTimeAfter}}
+	fields, err = changedConfigFieldsOf(current, desired)
+	assert.Nil(t, err)
+	assert.Empty(t, fields)
+
+	desired.Labels = []string{"nightly"}
+	desired.Enable = true
+	fields, err = changedConfigFieldsOf(current, desired)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"enable", "labels"}, fields)
+}
+
+func TestApplyConfigBundleRollsBack(t *testing.T) {
+	defer func(d dal.Dal) { db = d }(db)
+	if vld == nil {
+		vld = validator.New()
+	}
+
+	mockTx := new(mockdal.Transaction)
+	mockTx.On("First", mock.Anything, mock.Anything).Return(errors.NotFound.New("not found"))
+	mockTx.On("IsErrorNotFound", mock.Anything).Return(true)
+	mockTx.On("IsDuplicationError", mock.Anything).Return(false)
+	mockTx.On("Create", mock.AnythingOfType("*models.Project"), mock.Anything).Return(nil).Once()
+	mockTx.On("Create", mock.AnythingOfType("*models.Blueprint"), mock.Anything).Return(nil).Once()
+	// the second project fails after the first one was created
+	mockTx.On("Create", mock.AnythingOfType("*models.Project"), mock.Anything).Return(errors.Default.New("lost connection")).Once()
+	mockTx.On("Rollback").Return(nil).Once()
+	mockDal := new(mockdal.Dal)
+	mockDal.On("Begin").Return(mockTx).Once()
+	db = mockDal
+
+	changes, err := ApplyConfigBundle(&ConfigBundle{
+		Projects: []*ConfigProject{{Name: "a"}, {Name: "b"}},
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, changes)
+	mockDal.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+	mockTx.AssertNotCalled(t, "Commit")
+}
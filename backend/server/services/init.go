@@ -18,6 +18,7 @@ limitations under the License.
 package services
 
 import (
+	gocontext "context"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/apache/incubator-devlake/core/models/migrationscripts"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
 	"github.com/go-playground/validator/v10"
 	"github.com/robfig/cron/v3"
@@ -44,6 +46,7 @@ var migrator plugin.Migrator
 var cronManager *cron.Cron
 var vld *validator.Validate
 var serviceStatus string
+var shutdownTracing tracing.ShutdownFunc
 
 const (
 	SERVICE_STATUS_INIT         = "initializing"
@@ -64,6 +67,10 @@ func InitResources() {
 	cfg = basicRes.GetConfigReader()
 	logger = basicRes.GetLogger()
 	db = basicRes.GetDal()
+	shutdownTracing, err = tracing.Init(cfg)
+	if err != nil {
+		panic(err)
+	}
 	bpManager = services.NewBlueprintManager(db)
 	// initialize db migrator
 	migrator, err = runner.InitMigrator(basicRes)
@@ -74,6 +81,16 @@ func InitResources() {
 	migrator.Register(migrationscripts.All(), "Framework")
 }
 
+// Shutdown releases resources created by InitResources, flushing pending traces
+func Shutdown() {
+	if shutdownTracing == nil {
+		return
+	}
+	if err := shutdownTracing(gocontext.Background()); err != nil {
+		logger.Error(err, "failed to shutdown tracing")
+	}
+}
+
 // GetBasicRes returns the context.BasicRes instance used by services module
 func GetBasicRes() context.BasicRes {
 	return basicRes
@@ -116,8 +133,11 @@ func InitExecuteMigration() {
 func Init() {
 	InitResources()
 
-	// lock the database to avoid multiple devlake instances from sharing the same one
-	lockDatabase()
+	// lock the database to avoid multiple devlake instances from sharing the same one, unless they are
+	// running in cluster mode and lease pipelines from the shared queue
+	if !cfg.GetBool("CLUSTER_MODE") {
+		lockDatabase()
+	}
 
 	// now, load the plugins
 	errors.Must(runner.LoadPlugins(basicRes))
@@ -151,8 +171,15 @@ func ExecuteMigration() errors.Error {
 	}
 	serviceStatus = SERVICE_STATUS_MIGRATING
 	statusLock.Unlock() // unlock to allow other API requests to check the status
-	// apply all pending migration scripts
-	err := migrator.Execute()
+	// apply all pending migration scripts, one instance at a time in cluster mode
+	var err errors.Error
+	if cfg.GetBool("CLUSTER_MODE") {
+		unlock := lockMigration()
+		err = migrator.Execute()
+		unlock()
+	} else {
+		err = migrator.Execute()
+	}
 	if err != nil {
 		logger.Error(err, "failed to execute migration")
 		return err
@@ -164,6 +191,8 @@ func ExecuteMigration() errors.Error {
 
 	// initialize pipeline server, mainly to start the pipeline consuming process
 	pipelineServiceInit()
+	// scheduled retention and compression of the raw tables
+	rawDataMaintenanceInit()
 	statusLock.Lock()
 	serviceStatus = SERVICE_STATUS_READY
 	statusLock.Unlock()
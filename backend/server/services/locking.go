@@ -64,3 +64,19 @@ func lockDatabase() {
 		panic(fmt.Errorf("locking _devlake_locking_stub timeout, the database might be locked by another devlake instance"))
 	}
 }
+
+// lockMigration serializes the migrations of the instances sharing the database in cluster mode, which don't lock
+// the database for good. The returned func releases the lock
+func lockMigration() func() {
+	db := basicRes.GetDal()
+	// instances starting at the same time may race to create the table, the loser finds it created
+	if err := db.AutoMigrate(&models.LockingStub{}); err != nil {
+		errors.Must(db.AutoMigrate(&models.LockingStub{}))
+	}
+	tx := db.Begin()
+	errors.Must(tx.LockTables(dal.LockTables{{Table: models.LockingStub{}.TableName(), Exclusive: true}}))
+	return func() {
+		_ = tx.UnlockTables()
+		_ = tx.Rollback()
+	}
+}
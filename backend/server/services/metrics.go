@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/metrics"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pipelinesDesc = prometheus.NewDesc(
+		"devlake_pipelines",
+		"Number of pipelines by status.",
+		[]string{"status"}, nil,
+	)
+	tasksDesc = prometheus.NewDesc(
+		"devlake_tasks",
+		"Number of tasks by status.",
+		[]string{"status"}, nil,
+	)
+	pipelineQueueDepthDesc = prometheus.NewDesc(
+		"devlake_pipeline_queue_depth",
+		"Number of pipelines waiting in the queue to be executed.",
+		nil, nil,
+	)
+)
+
+// statusCollector reads the pipeline and task counts from the database on every scrape
+type statusCollector struct{}
+
+func (c statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pipelinesDesc
+	ch <- tasksDesc
+	ch <- pipelineQueueDepthDesc
+}
+
+func (c statusCollector) Collect(ch chan<- prometheus.Metric) {
+	// the tables might not exist until the migration is done
+	if serviceStatus != SERVICE_STATUS_READY {
+		return
+	}
+	queueDepth := 0.0
+	err := collectStatusCounts(&models.Pipeline{}, func(status string, count float64) {
+		ch <- prometheus.MustNewConstMetric(pipelinesDesc, prometheus.GaugeValue, count, status)
+		switch status {
+		case models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME:
+			queueDepth += count
+		}
+	})
+	if err != nil {
+		logger.Error(err, "failed to collect pipeline metrics")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(pipelineQueueDepthDesc, prometheus.GaugeValue, queueDepth)
+	err = collectStatusCounts(&models.Task{}, func(status string, count float64) {
+		ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, count, status)
+	})
+	if err != nil {
+		logger.Error(err, "failed to collect task metrics")
+	}
+}
+
+func collectStatusCounts(table dal.Tabler, collect func(status string, count float64)) errors.Error {
+	var counts []struct {
+		Status string
+		Count  int64
+	}
+	err := db.All(
+		&counts,
+		dal.Select("status, COUNT(*) AS count"),
+		dal.From(table),
+		dal.Groupby("status"),
+	)
+	if err != nil {
+		return err
+	}
+	for _, c := range counts {
+		collect(c.Status, float64(c.Count))
+	}
+	return nil
+}
+
+func init() {
+	metrics.Register(statusCollector{})
+}
@@ -0,0 +1,239 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+)
+
+// NotificationQuery is a query for GetNotifications
+type NotificationQuery struct {
+	Pagination
+	SubscriptionId *uint64 `form:"subscriptionId"`
+	Status         string  `form:"status"`
+}
+
+// NotificationSubscriptionQuery is a query for GetNotificationSubscriptions
+type NotificationSubscriptionQuery struct {
+	Pagination
+	ProjectName string `form:"projectName"`
+}
+
+// NotificationDetail is a notification along with all of its delivery attempts
+type NotificationDetail struct {
+	models.Notification
+	DeliveryAttempts []*models.NotificationAttempt `json:"deliveryAttempts"`
+}
+
+// GetNotificationSubscriptions returns a paginated list of subscriptions
+func GetNotificationSubscriptions(query *NotificationSubscriptionQuery) ([]*models.NotificationSubscription, int64, errors.Error) {
+	clauses := []dal.Clause{
+		dal.From(&models.NotificationSubscription{}),
+	}
+	if query.ProjectName != "" {
+		clauses = append(clauses, dal.Where("project_name = ?", query.ProjectName))
+	}
+	count, err := db.Count(clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error getting DB count of notification subscriptions")
+	}
+	clauses = append(clauses,
+		dal.Orderby("id DESC"),
+		dal.Offset(query.GetSkip()),
+		dal.Limit(query.GetPageSize()),
+	)
+	subscriptions := make([]*models.NotificationSubscription, 0)
+	err = db.All(&subscriptions, clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error finding DB notification subscriptions")
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, count, nil
+}
+
+// GetNotificationSubscription returns the subscription with the given id
+func GetNotificationSubscription(id uint64) (*models.NotificationSubscription, errors.Error) {
+	subscription := &models.NotificationSubscription{}
+	err := db.First(subscription, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.New(fmt.Sprintf("notification subscription #%d not found", id))
+		}
+		return nil, errors.Default.Wrap(err, "error finding notification subscription")
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// CreateNotificationSubscription accepts a subscription input and insert it to database
+func CreateNotificationSubscription(input *models.ApiInputNotificationSubscription) (*models.NotificationSubscription, errors.Error) {
+	if err := VerifyStruct(input); err != nil {
+		return nil, err
+	}
+	subscription := &models.NotificationSubscription{
+		Name:        input.Name,
+		Channel:     notificationChannel(input.Channel),
+		Endpoint:    input.Endpoint,
+		Secret:      input.Secret,
+		ProjectName: input.ProjectName,
+		BlueprintId: input.BlueprintId,
+		Statuses:    input.Statuses,
+		Enabled:     input.Enabled == nil || *input.Enabled,
+	}
+	err := db.Create(subscription)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error saving notification subscription")
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// PatchNotificationSubscription updates the subscription, an empty secret keeps the current one
+func PatchNotificationSubscription(id uint64, input *models.ApiInputNotificationSubscription) (*models.NotificationSubscription, errors.Error) {
+	if err := VerifyStruct(input); err != nil {
+		return nil, err
+	}
+	subscription := &models.NotificationSubscription{}
+	err := db.First(subscription, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.New(fmt.Sprintf("notification subscription #%d not found", id))
+		}
+		return nil, errors.Default.Wrap(err, "error finding notification subscription")
+	}
+	subscription.Name = input.Name
+	subscription.Channel = notificationChannel(input.Channel)
+	subscription.Endpoint = input.Endpoint
+	if input.Secret != "" {
+		subscription.Secret = input.Secret
+	}
+	subscription.ProjectName = input.ProjectName
+	subscription.BlueprintId = input.BlueprintId
+	subscription.Statuses = input.Statuses
+	if input.Enabled != nil {
+		subscription.Enabled = *input.Enabled
+	}
+	err = db.Update(subscription)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error updating notification subscription")
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// DeleteNotificationSubscription deletes the subscription along with its deliveries and their attempts
+func DeleteNotificationSubscription(id uint64) (err errors.Error) {
+	if _, err = GetNotificationSubscription(id); err != nil {
+		return err
+	}
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	err = tx.Delete(
+		&models.NotificationAttempt{},
+		dal.Where("notification_id IN (SELECT id FROM _devlake_notifications WHERE subscription_id = ?)", id),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting notification attempts")
+	}
+	err = tx.Delete(&models.Notification{}, dal.Where("subscription_id = ?", id))
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting notifications")
+	}
+	err = tx.Delete(&models.NotificationSubscription{}, dal.Where("id = ?", id))
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting notification subscription")
+	}
+	return nil
+}
+
+func notificationChannel(channel string) string {
+	if channel == "" {
+		return models.NOTIFICATION_CHANNEL_WEBHOOK
+	}
+	return channel
+}
+
+// GetNotifications returns the paginated delivery log
+func GetNotifications(query *NotificationQuery) ([]*models.Notification, int64, errors.Error) {
+	clauses := []dal.Clause{
+		dal.From(&models.Notification{}),
+	}
+	if query.SubscriptionId != nil {
+		clauses = append(clauses, dal.Where("subscription_id = ?", *query.SubscriptionId))
+	}
+	if query.Status != "" {
+		clauses = append(clauses, dal.Where("status = ?", query.Status))
+	}
+	count, err := db.Count(clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error getting DB count of notifications")
+	}
+	clauses = append(clauses,
+		dal.Orderby("id DESC"),
+		dal.Offset(query.GetSkip()),
+		dal.Limit(query.GetPageSize()),
+	)
+	notifications := make([]*models.Notification, 0)
+	err = db.All(&notifications, clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error finding DB notifications")
+	}
+	return notifications, count, nil
+}
+
+// GetNotification returns the notification with all its delivery attempts
+func GetNotification(id uint64) (*NotificationDetail, errors.Error) {
+	detail := &NotificationDetail{}
+	err := db.First(&detail.Notification, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.New(fmt.Sprintf("notification #%d not found", id))
+		}
+		return nil, errors.Default.Wrap(err, "error finding notification")
+	}
+	detail.DeliveryAttempts = make([]*models.NotificationAttempt, 0)
+	err = db.All(&detail.DeliveryAttempts, dal.Where("notification_id = ?", id), dal.Orderby("attempt"))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error finding notification attempts")
+	}
+	return detail, nil
+}
+
+// RedeliverNotification sends the notification once more and returns the updated delivery log
+func RedeliverNotification(id uint64) (*NotificationDetail, errors.Error) {
+	if defaultNotificationService == nil {
+		return nil, errors.Default.New("notification service is not initialized")
+	}
+	detail, err := GetNotification(id)
+	if err != nil {
+		return nil, err
+	}
+	err = defaultNotificationService.Redeliver(&detail.Notification)
+	if err != nil {
+		return nil, err
+	}
+	return GetNotification(id)
+}
@@ -20,6 +20,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -35,6 +36,7 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
 	"github.com/apache/incubator-devlake/core/utils"
 	"github.com/apache/incubator-devlake/helpers/dbhelper"
 	"github.com/apache/incubator-devlake/impls/logruslog"
@@ -82,15 +84,37 @@ func pipelineServiceInit() {
 	// initialize plugin
 	plugin.InitPlugins(basicRes)
 
+	// stream the logs of tasks to the clients watching their pipelines
+	runner.TaskLogTap = func(task *models.Task) io.Writer {
+		return newPipelineLogTap(task.PipelineId, task.ID)
+	}
+
 	// notification
 	var notificationEndpoint = cfg.GetString("NOTIFICATION_ENDPOINT")
 	var notificationSecret = cfg.GetString("NOTIFICATION_SECRET")
-	if strings.TrimSpace(notificationEndpoint) != "" {
-		defaultNotificationService = NewDefaultPipelineNotificationService(notificationEndpoint, notificationSecret)
-	}
-
-	// standalone mode: reset pipeline status
-	if cfg.GetBool("RESUME_PIPELINES") {
+	defaultNotificationService = NewDefaultPipelineNotificationService(strings.TrimSpace(notificationEndpoint), notificationSecret)
+	defaultNotificationService.LinkBaseUrl = cfg.GetString("NOTIFICATION_LINK_BASE_URL")
+	if cfg.IsSet("NOTIFICATION_MAX_ATTEMPTS") {
+		defaultNotificationService.MaxAttempts = cfg.GetInt("NOTIFICATION_MAX_ATTEMPTS")
+	}
+	if cfg.IsSet("NOTIFICATION_RETRY_INTERVAL") {
+		defaultNotificationService.RetryInterval = cfg.GetDuration("NOTIFICATION_RETRY_INTERVAL")
+	}
+	if defaultNotificationService.MaxAttempts < 1 || defaultNotificationService.RetryInterval <= 0 {
+		panic(errors.BadInput.New(`NOTIFICATION_MAX_ATTEMPTS and NOTIFICATION_RETRY_INTERVAL should be positive`))
+	}
+	defaultNotificationService.StartRetryWorker(defaultNotificationService.RetryInterval / 2)
+
+	workerServiceInit()
+	if clusterMode {
+		// cluster mode: pipelines of other workers are recovered when their leases expire, only the ones
+		// left by a previous run of this worker (with a fixed WORKER_ID) can be recovered right away
+		errors.Must(requeuePipelines(
+			"the worker was restarted, the pipeline was put back to the queue",
+			dal.Where("status = ? AND worker_id = ?", models.TASK_RUNNING, workerId),
+		))
+	} else if cfg.GetBool("RESUME_PIPELINES") {
+		// standalone mode: reset pipeline status
 		markInterruptedPipelineAs(models.TASK_RESUME)
 	} else {
 		markInterruptedPipelineAs(models.TASK_FAILED)
@@ -111,6 +135,7 @@ func pipelineServiceInit() {
 	if cfg.GetBool("CONSUME_PIPELINES") {
 		go RunPipelineInQueue(pipelineMaxParallel)
 	}
+	startWorkerHeartbeat()
 }
 
 func markInterruptedPipelineAs(status string) {
@@ -272,6 +297,14 @@ func dequeuePipeline(runningParallelLabels []string) (pipeline *models.Pipeline,
 	if len(top_priorities) > 0 {
 		top_priority = top_priorities[0]
 	}
+	if clusterMode {
+		// parallel labels of the pipelines running in other instances
+		clusterLabels, e := runningParallelLabelsInCluster(tx)
+		if e != nil {
+			panic(e)
+		}
+		runningParallelLabels = append(clusterLabels, runningParallelLabels...)
+	}
 	// 2. pick the earlier runnable pipeline with the highest priority
 	err = tx.First(pipeline,
 		where_status,
@@ -297,14 +330,15 @@ func dequeuePipeline(runningParallelLabels []string) (pipeline *models.Pipeline,
 			globalPipelineLog.Info("resumed pipeline #%d", pipeline.ID)
 		}
 		errors.Must(tx.LockTables(dal.LockTables{{Table: "_devlake_pipelines", Exclusive: true}}))
-		err = tx.UpdateColumns(&models.Pipeline{}, []dal.DalSet{
-			{ColumnName: "status", Value: models.TASK_RUNNING},
-			{ColumnName: "message", Value: ""},
-			{ColumnName: "began_at", Value: pipeline.BeganAt},
-		}, dal.Where("id = ?", pipeline.ID))
+		var claimed bool
+		claimed, err = claimPipeline(tx, pipeline)
 		if err != nil {
 			panic(err)
 		}
+		if !claimed {
+			// another instance picked the same pipeline before us
+			pipeline = nil
+		}
 
 		return
 	}
@@ -354,8 +388,10 @@ func RunPipelineInQueue(pipelineMaxParallel int64) {
 		runningParallelLabels = append(runningParallelLabels, pipelineParallelLabels...)
 		runningParallelLabelLock.Unlock()
 
+		localPipelines.Store(dbPipeline.ID, true)
 		go func(pipelineId uint64, parallelLabels []string) {
 			defer sema.Release(1)
+			defer localPipelines.Delete(pipelineId)
 			defer func() {
 				runningParallelLabelLock.Lock()
 				runningParallelLabels = utils.SliceRemove(runningParallelLabels, parallelLabels...)
@@ -414,6 +450,7 @@ func NotifyExternal(pipelineId uint64) errors.Error {
 	err = notification.PipelineStatusChanged(PipelineNotificationParam{
 		ProjectName: projectName,
 		PipelineID:  pipeline.ID,
+		BlueprintId: pipeline.BlueprintId,
 		CreatedAt:   pipeline.CreatedAt,
 		UpdatedAt:   pipeline.UpdatedAt,
 		BeganAt:     pipeline.BeganAt,
@@ -454,6 +491,23 @@ func CancelPipeline(pipelineId uint64) errors.Error {
 		// the target pipeline is pending, no running, no need to perform the actual cancel operation
 		return nil
 	}
+	if clusterMode && pipeline.Status == models.TASK_RUNNING && pipeline.WorkerId != workerId {
+		// the pipeline is running in another instance, which would cancel it on its next heartbeat
+		err = db.UpdateColumn(
+			&models.Pipeline{},
+			"status", models.TASK_CANCELLED,
+			dal.Where("id = ? AND status = ?", pipelineId, models.TASK_RUNNING),
+		)
+		if err != nil {
+			return errors.Default.Wrap(err, "faile to update pipeline")
+		}
+		return nil
+	}
+	return cancelLocalTasks(pipelineId)
+}
+
+// cancelLocalTasks cancels the pending tasks of a pipeline running in this instance
+func cancelLocalTasks(pipelineId uint64) errors.Error {
 	pendingTasks, count, err := GetTasks(&TaskQuery{PipelineId: pipelineId, Pending: 1, Pagination: Pagination{PageSize: -1}})
 	if err != nil {
 		return errors.Convert(err)
@@ -539,17 +593,20 @@ func RerunPipeline(pipelineId uint64, task *models.Task) (tasks []*models.Task,
 	rerunTasks := []*models.Task{}
 	for _, t := range failedTasks {
 		// mark previous task failed
-		t.Status = models.TASK_FAILED
-		err := tx.UpdateColumn(t, "status", models.TASK_FAILED)
-		if err != nil {
-			return nil, err
+		if t.Status != models.TASK_TIMEOUT {
+			t.Status = models.TASK_FAILED
+			err := tx.UpdateColumn(t, "status", models.TASK_FAILED)
+			if err != nil {
+				return nil, err
+			}
 		}
 		// create new task
 		rerunTask, err := createTask(&models.NewTask{
 			PipelineTask: &models.PipelineTask{
-				Plugin:   t.Plugin,
-				Subtasks: t.Subtasks,
-				Options:  t.Options,
+				Plugin:         t.Plugin,
+				Subtasks:       t.Subtasks,
+				Options:        t.Options,
+				TimeoutSeconds: t.TimeoutSeconds,
 			},
 			PipelineId:  t.PipelineId,
 			PipelineRow: t.PipelineRow,
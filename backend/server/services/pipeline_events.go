@@ -0,0 +1,316 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+const (
+	// PIPELINE_EVENT_PIPELINE carries the pipeline, sent when subscribing and once the pipeline finished
+	PIPELINE_EVENT_PIPELINE = "pipeline"
+	// PIPELINE_EVENT_TASK carries the task whenever its status changes
+	PIPELINE_EVENT_TASK = "task"
+	// PIPELINE_EVENT_SUBTASK carries the status of a subtask whenever it starts or finishes
+	PIPELINE_EVENT_SUBTASK = "subtask"
+	// PIPELINE_EVENT_PROGRESS carries the progress detail of a running task
+	PIPELINE_EVENT_PROGRESS = "progress"
+	// PIPELINE_EVENT_LOG carries a line of the pipeline log
+	PIPELINE_EVENT_LOG = "log"
+)
+
+// pipelineEventBuffer is how many events a subscriber may fall behind before events are dropped for it
+const pipelineEventBuffer = 512
+
+// pipelineEventPollInterval is how often the database is checked for the end of pipelines running elsewhere
+var pipelineEventPollInterval = 5 * time.Second
+
+// PipelineEvent is an event of a running pipeline streamed to the clients
+type PipelineEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// TaskProgressEvent is the data of PIPELINE_EVENT_PROGRESS
+type TaskProgressEvent struct {
+	TaskId uint64 `json:"taskId"`
+	models.TaskProgressDetail
+}
+
+// SubtaskEvent is the data of PIPELINE_EVENT_SUBTASK
+type SubtaskEvent struct {
+	TaskId       uint64 `json:"taskId"`
+	Name         string `json:"name"`
+	Number       int    `json:"number"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+	SpentSeconds int64  `json:"spentSeconds,omitempty"`
+}
+
+// LogEvent is the data of PIPELINE_EVENT_LOG
+type LogEvent struct {
+	TaskId uint64 `json:"taskId,omitempty"`
+	Line   string `json:"line"`
+}
+
+// pipelineEventHub dispatches the events of pipelines running in this instance to their subscribers
+type pipelineEventHub struct {
+	mu          sync.Mutex
+	subscribers map[uint64]map[chan *PipelineEvent]struct{}
+}
+
+var pipelineEvents = &pipelineEventHub{
+	subscribers: make(map[uint64]map[chan *PipelineEvent]struct{}),
+}
+
+func (h *pipelineEventHub) subscribe(pipelineId uint64) chan *PipelineEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan *PipelineEvent, pipelineEventBuffer)
+	if h.subscribers[pipelineId] == nil {
+		h.subscribers[pipelineId] = make(map[chan *PipelineEvent]struct{})
+	}
+	h.subscribers[pipelineId][ch] = struct{}{}
+	return ch
+}
+
+func (h *pipelineEventHub) unsubscribe(pipelineId uint64, ch chan *PipelineEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[pipelineId][ch]; ok {
+		delete(h.subscribers[pipelineId], ch)
+		close(ch)
+	}
+	if len(h.subscribers[pipelineId]) == 0 {
+		delete(h.subscribers, pipelineId)
+	}
+}
+
+func (h *pipelineEventHub) hasSubscribers(pipelineId uint64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[pipelineId]) > 0
+}
+
+func (h *pipelineEventHub) isSubscribed(pipelineId uint64, ch chan *PipelineEvent) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.subscribers[pipelineId][ch]
+	return ok
+}
+
+// publish sends the event to all subscribers of the pipeline without blocking, slow subscribers miss events
+func (h *pipelineEventHub) publish(pipelineId uint64, event *PipelineEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[pipelineId] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// sendTo sends the event to a single subscriber unless it was gone
+func (h *pipelineEventHub) sendTo(pipelineId uint64, ch chan *PipelineEvent, event *PipelineEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[pipelineId][ch]; ok {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// finish sends the last event and closes the streams of the pipeline
+func (h *pipelineEventHub) finish(pipelineId uint64, event *PipelineEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[pipelineId] {
+		select {
+		case ch <- event:
+		default:
+		}
+		close(ch)
+	}
+	delete(h.subscribers, pipelineId)
+}
+
+// SubscribePipelineEvents streams the events of the pipeline, starting with the current state of the pipeline.
+// The channel is closed after the pipeline finished, and the returned function must be called once the caller
+// stops reading. Only pipelines running in this instance produce events, the end of the others is polled from the
+// database.
+func SubscribePipelineEvents(pipelineId uint64) (<-chan *PipelineEvent, func(), errors.Error) {
+	ch := pipelineEvents.subscribe(pipelineId)
+	unsubscribe := func() {
+		pipelineEvents.unsubscribe(pipelineId, ch)
+	}
+	pipeline, err := GetPipeline(pipelineId, true)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, err
+	}
+	snapshot := &PipelineEvent{Type: PIPELINE_EVENT_PIPELINE, Data: pipeline}
+	if isPipelineFinished(pipeline) {
+		pipelineEvents.finish(pipelineId, snapshot)
+		return ch, unsubscribe, nil
+	}
+	pipelineEvents.sendTo(pipelineId, ch, snapshot)
+	go pollPipelineFinished(pipelineId, ch)
+	return ch, unsubscribe, nil
+}
+
+// pollPipelineFinished closes the streams of the pipeline once the database tells it finished, for it may run in
+// another instance of the cluster, or stop running in this one without publishing its end. It returns as soon as
+// the subscriber is gone.
+func pollPipelineFinished(pipelineId uint64, ch chan *PipelineEvent) {
+	ticker := time.NewTicker(pipelineEventPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !pipelineEvents.isSubscribed(pipelineId, ch) {
+			return
+		}
+		if _, running := localPipelines.Load(pipelineId); running {
+			continue
+		}
+		pipeline, err := GetPipeline(pipelineId, true)
+		if err != nil {
+			logger.Error(err, "failed to load pipeline #%d for the pipeline events", pipelineId)
+			continue
+		}
+		if isPipelineFinished(pipeline) {
+			pipelineEvents.finish(pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_PIPELINE, Data: pipeline})
+			return
+		}
+	}
+}
+
+func isPipelineFinished(pipeline *models.Pipeline) bool {
+	for _, status := range models.FinishedTaskStatus {
+		if pipeline.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// publishTaskEvent sends the latest state of the task to the subscribers of its pipeline
+func publishTaskEvent(taskId uint64) {
+	task := &models.Task{}
+	if err := db.First(task, dal.Where("id = ?", taskId)); err != nil {
+		logger.Error(err, "failed to load task #%d for the pipeline events", taskId)
+		return
+	}
+	if !pipelineEvents.hasSubscribers(task.PipelineId) {
+		return
+	}
+	options, err := SanitizePluginOption(task.Plugin, task.Options)
+	if err != nil {
+		logger.Error(err, "failed to sanitize task #%d for the pipeline events", taskId)
+		return
+	}
+	task.Options = options
+	pipelineEvents.publish(task.PipelineId, &PipelineEvent{Type: PIPELINE_EVENT_TASK, Data: task})
+}
+
+// publishTaskProgressEvent sends the progress of the task, along with the status transitions of its subtasks
+func publishTaskProgressEvent(pipelineId uint64, taskId uint64, progressDetail *models.TaskProgressDetail, p *plugin.RunningProgress) {
+	if !pipelineEvents.hasSubscribers(pipelineId) {
+		return
+	}
+	switch p.Type {
+	case plugin.SetCurrentSubTask:
+		pipelineEvents.publish(pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_SUBTASK, Data: &SubtaskEvent{
+			TaskId: taskId,
+			Name:   p.SubTaskName,
+			Number: p.SubTaskNumber,
+			Status: models.TASK_RUNNING,
+		}})
+	case plugin.SubTaskFinished:
+		subtask := &models.Subtask{}
+		err := db.First(subtask, dal.Where("task_id = ? AND name = ?", taskId, p.SubTaskName))
+		if err != nil {
+			logger.Error(err, "failed to load subtask %s of task #%d for the pipeline events", p.SubTaskName, taskId)
+			return
+		}
+		event := &SubtaskEvent{
+			TaskId:       taskId,
+			Name:         subtask.Name,
+			Number:       subtask.Number,
+			Status:       models.TASK_COMPLETED,
+			SpentSeconds: subtask.SpentSeconds,
+		}
+		if subtask.IsFailed {
+			event.Status = models.TASK_FAILED
+			event.Message = subtask.Message
+		}
+		pipelineEvents.publish(pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_SUBTASK, Data: event})
+		return
+	}
+	pipelineEvents.publish(pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_PROGRESS, Data: &TaskProgressEvent{
+		TaskId:             taskId,
+		TaskProgressDetail: *progressDetail,
+	}})
+}
+
+// publishPipelineFinished sends the final state of the pipeline and closes the streams
+func publishPipelineFinished(pipelineId uint64) {
+	if !pipelineEvents.hasSubscribers(pipelineId) {
+		return
+	}
+	pipeline, err := GetPipeline(pipelineId, true)
+	if err != nil {
+		logger.Error(err, "failed to load pipeline #%d for the pipeline events", pipelineId)
+		return
+	}
+	pipelineEvents.finish(pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_PIPELINE, Data: pipeline})
+}
+
+// pipelineLogTap publishes every line written to the log of a pipeline or task
+type pipelineLogTap struct {
+	pipelineId uint64
+	taskId     uint64
+}
+
+func newPipelineLogTap(pipelineId uint64, taskId uint64) io.Writer {
+	return &pipelineLogTap{pipelineId: pipelineId, taskId: taskId}
+}
+
+func (t *pipelineLogTap) Write(p []byte) (int, error) {
+	if !pipelineEvents.hasSubscribers(t.pipelineId) {
+		return len(p), nil
+	}
+	// logrus writes a whole entry per call
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		pipelineEvents.publish(t.pipelineId, &PipelineEvent{Type: PIPELINE_EVENT_LOG, Data: &LogEvent{
+			TaskId: t.taskId,
+			Line:   strings.TrimRight(string(line), "\r"),
+		}})
+	}
+	return len(p), nil
+}
@@ -0,0 +1,118 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPipelineEventHub(t *testing.T) {
+	hub := &pipelineEventHub{subscribers: make(map[uint64]map[chan *PipelineEvent]struct{})}
+	ch1 := hub.subscribe(1)
+	ch2 := hub.subscribe(1)
+	other := hub.subscribe(2)
+	assert.True(t, hub.hasSubscribers(1))
+
+	hub.publish(1, &PipelineEvent{Type: PIPELINE_EVENT_TASK})
+	assert.Equal(t, PIPELINE_EVENT_TASK, (<-ch1).Type)
+	assert.Equal(t, PIPELINE_EVENT_TASK, (<-ch2).Type)
+	assert.Len(t, other, 0)
+
+	hub.unsubscribe(1, ch2)
+	_, ok := <-ch2
+	assert.False(t, ok)
+
+	hub.finish(1, &PipelineEvent{Type: PIPELINE_EVENT_PIPELINE, Data: &models.Pipeline{Status: models.TASK_COMPLETED}})
+	assert.Equal(t, PIPELINE_EVENT_PIPELINE, (<-ch1).Type)
+	_, ok = <-ch1
+	assert.False(t, ok)
+	assert.False(t, hub.hasSubscribers(1))
+	// unsubscribing after the stream was closed is harmless
+	hub.unsubscribe(1, ch1)
+	hub.sendTo(1, ch1, &PipelineEvent{Type: PIPELINE_EVENT_LOG})
+	assert.True(t, hub.hasSubscribers(2))
+}
+
+func TestPipelineEventHubSlowSubscriber(t *testing.T) {
+	hub := &pipelineEventHub{subscribers: make(map[uint64]map[chan *PipelineEvent]struct{})}
+	ch := hub.subscribe(1)
+	for i := 0; i < pipelineEventBuffer+10; i++ {
+		hub.publish(1, &PipelineEvent{Type: PIPELINE_EVENT_LOG})
+	}
+	assert.Len(t, ch, pipelineEventBuffer)
+}
+
+func TestPipelineLogTap(t *testing.T) {
+	ch := pipelineEvents.subscribe(42)
+	defer pipelineEvents.unsubscribe(42, ch)
+	tap := newPipelineLogTap(42, 7)
+	entries := []byte("time=\"now\" level=info msg=\"first\"\ntime=\"now\" level=info msg=\"second\"\n")
+	n, err := tap.Write(entries)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), n)
+	first := (<-ch).Data.(*LogEvent)
+	assert.Equal(t, uint64(7), first.TaskId)
+	assert.Equal(t, `time="now" level=info msg="first"`, first.Line)
+	assert.Equal(t, `time="now" level=info msg="second"`, (<-ch).Data.(*LogEvent).Line)
+}
+
+func TestPollPipelineFinished(t *testing.T) {
+	defer func(d dal.Dal, r context.BasicRes, i time.Duration) {
+		db, basicRes, pipelineEventPollInterval = d, r, i
+	}(db, basicRes, pipelineEventPollInterval)
+	pipelineEventPollInterval = time.Millisecond
+
+	// the pipeline runs in another instance, and finishes at the second poll
+	statuses := []string{models.TASK_RUNNING, models.TASK_COMPLETED}
+	mockRes := unithelper.DummyBasicRes(func(mockDal *mockdal.Dal) {
+		mockDal.On("First", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			pipeline := args.Get(0).(*models.Pipeline)
+			pipeline.ID = 43
+			pipeline.Status = statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+		}).Return(nil)
+		mockDal.On("Pluck", "name", mock.Anything, mock.Anything).Return(nil)
+	})
+	basicRes = mockRes
+	db = mockRes.GetDal()
+
+	ch := pipelineEvents.subscribe(43)
+	defer pipelineEvents.unsubscribe(43, ch)
+	go pollPipelineFinished(43, ch)
+	select {
+	case event := <-ch:
+		assert.Equal(t, PIPELINE_EVENT_PIPELINE, event.Type)
+		assert.Equal(t, models.TASK_COMPLETED, event.Data.(*models.Pipeline).Status)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the stream did not end")
+		return
+	}
+	_, ok := <-ch
+	assert.False(t, ok)
+}
@@ -34,6 +34,9 @@ var createDbPipelineLock sync.Mutex
 func CreateDbPipeline(newPipeline *models.NewPipeline) (pipeline *models.Pipeline, err errors.Error) {
 	createDbPipelineLock.Lock()
 	defer createDbPipelineLock.Unlock()
+	if err = newPipeline.Plan.ValidateDag(); err != nil {
+		return nil, err
+	}
 	pipeline = &models.Pipeline{}
 	txHelper := dbhelper.NewTxHelper(basicRes, &err)
 	defer txHelper.End()
@@ -25,6 +25,7 @@ import (
 type PipelineNotificationParam struct {
 	ProjectName string // can be an empty string, if pipeline is created and triggered by API
 	PipelineID  uint64
+	BlueprintId uint64 // 0 if pipeline is not created by a blueprint
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	BeganAt     *time.Time
@@ -0,0 +1,218 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// PipelineSummary is the human-readable digest of a pipeline posted to chat channels
+type PipelineSummary struct {
+	ProjectName   string
+	BlueprintName string
+	PipelineID    uint64
+	Status        string
+	Duration      time.Duration
+	FailedTasks   []PipelineSummaryFailedTask
+	LogUrl        string
+}
+
+// PipelineSummaryFailedTask describes a failed task of the pipeline
+type PipelineSummaryFailedTask struct {
+	Plugin        string
+	FailedSubtask string
+	ErrorName     string
+}
+
+// buildPipelineSummary collects blueprint and failed tasks of the pipeline, the log link is
+// only available when linkBaseUrl (usually the config-ui address) is set
+func buildPipelineSummary(params PipelineNotificationParam, linkBaseUrl string) (*PipelineSummary, errors.Error) {
+	summary := &PipelineSummary{
+		ProjectName: params.ProjectName,
+		PipelineID:  params.PipelineID,
+		Status:      params.Status,
+	}
+	if params.BeganAt != nil {
+		finishedAt := time.Now()
+		if params.FinishedAt != nil {
+			finishedAt = *params.FinishedAt
+		}
+		summary.Duration = finishedAt.Sub(*params.BeganAt).Round(time.Second)
+	}
+	if params.BlueprintId != 0 {
+		blueprint := &models.Blueprint{}
+		err := db.First(blueprint, dal.Where("id = ?", params.BlueprintId))
+		if err != nil && !db.IsErrorNotFound(err) {
+			return nil, errors.Default.Wrap(err, "error finding blueprint of the pipeline")
+		}
+		summary.BlueprintName = blueprint.Name
+	}
+	tasks := make([]*models.Task, 0)
+	err := db.All(
+		&tasks,
+		dal.Where("pipeline_id = ? AND status IN ?", params.PipelineID, []string{models.TASK_FAILED, models.TASK_TIMEOUT}),
+		dal.Orderby("pipeline_row, pipeline_col"),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error finding failed tasks of the pipeline")
+	}
+	for _, task := range tasks {
+		summary.FailedTasks = append(summary.FailedTasks, PipelineSummaryFailedTask{
+			Plugin:        task.Plugin,
+			FailedSubtask: task.FailedSubTask,
+			ErrorName:     task.ErrorName,
+		})
+	}
+	if linkBaseUrl != "" {
+		summary.LogUrl = fmt.Sprintf("%s/api/pipelines/%d/logging.tar.gz", strings.TrimRight(linkBaseUrl, "/"), params.PipelineID)
+	}
+	return summary, nil
+}
+
+// Title returns a one-line description of the pipeline result
+func (s *PipelineSummary) Title() string {
+	project := s.ProjectName
+	if project == "" {
+		project = "-"
+	}
+	return fmt.Sprintf("DevLake pipeline #%d of project %s: %s", s.PipelineID, project, s.Status)
+}
+
+// Lines returns the details of the summary, one fact per line
+func (s *PipelineSummary) Lines() []string {
+	lines := make([]string, 0, 4+len(s.FailedTasks))
+	if s.ProjectName != "" {
+		lines = append(lines, "Project: "+s.ProjectName)
+	}
+	if s.BlueprintName != "" {
+		lines = append(lines, "Blueprint: "+s.BlueprintName)
+	}
+	lines = append(lines, "Status: "+s.Status)
+	if s.Duration > 0 {
+		lines = append(lines, "Duration: "+s.Duration.String())
+	}
+	if len(s.FailedTasks) > 0 {
+		lines = append(lines, "Failed tasks:")
+		for _, task := range s.FailedTasks {
+			line := "- " + task.Plugin
+			if task.FailedSubtask != "" {
+				line += " / " + task.FailedSubtask
+			}
+			if task.ErrorName != "" {
+				line += ": " + task.ErrorName
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// slackMessage formats the summary as a slack incoming webhook payload
+func slackMessage(summary *PipelineSummary) ([]byte, errors.Error) {
+	text := strings.Join(summary.Lines(), "\n")
+	if summary.LogUrl != "" {
+		text += fmt.Sprintf("\n<%s|Download logs>", summary.LogUrl)
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"text": summary.Title(),
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "header",
+				"text": map[string]interface{}{"type": "plain_text", "text": summary.Title()},
+			},
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+			},
+		},
+	})
+	return payload, errors.Convert(err)
+}
+
+// feishuMessage formats the summary as a feishu custom bot rich text payload
+func feishuMessage(summary *PipelineSummary) ([]byte, errors.Error) {
+	content := make([][]map[string]string, 0)
+	for _, line := range summary.Lines() {
+		content = append(content, []map[string]string{{"tag": "text", "text": line}})
+	}
+	if summary.LogUrl != "" {
+		content = append(content, []map[string]string{{"tag": "a", "text": "Download logs", "href": summary.LogUrl}})
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"msg_type": "post",
+		"content": map[string]interface{}{
+			"post": map[string]interface{}{
+				"en_us": map[string]interface{}{
+					"title":   summary.Title(),
+					"content": content,
+				},
+			},
+		},
+	})
+	return payload, errors.Convert(err)
+}
+
+// channelMessage formats the summary for the given chat channel
+func channelMessage(channel string, summary *PipelineSummary) ([]byte, errors.Error) {
+	switch channel {
+	case models.NOTIFICATION_CHANNEL_SLACK:
+		return slackMessage(summary)
+	case models.NOTIFICATION_CHANNEL_FEISHU:
+		return feishuMessage(summary)
+	}
+	return nil, errors.BadInput.New(fmt.Sprintf("unsupported notification channel %s", channel))
+}
+
+// signFeishuMessage adds the timestamp and signature required by feishu bots with signature verification enabled
+func signFeishuMessage(data, secret string, now time.Time) (string, errors.Error) {
+	message := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		return "", errors.Convert(err)
+	}
+	timestamp := fmt.Sprintf("%d", now.Unix())
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	message["timestamp"] = timestamp
+	message["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	signed, err := json.Marshal(message)
+	return string(signed), errors.Convert(err)
+}
+
+// checkFeishuResponse detects errors reported by feishu with http status 200
+func checkFeishuResponse(body string) errors.Error {
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return nil
+	}
+	if resp.Code != 0 {
+		return errors.Default.New(fmt.Sprintf("feishu responded with code %d: %s", resp.Code, resp.Msg))
+	}
+	return nil
+}
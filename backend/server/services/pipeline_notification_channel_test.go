@@ -0,0 +1,130 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPipelineSummary = &PipelineSummary{
+	ProjectName:   "devlake",
+	BlueprintName: "devlake-blueprint",
+	PipelineID:    42,
+	Status:        models.TASK_FAILED,
+	Duration:      90 * time.Second,
+	FailedTasks: []PipelineSummaryFailedTask{
+		{Plugin: "github", FailedSubtask: "collectApiIssues", ErrorName: "401 Unauthorized"},
+	},
+	LogUrl: "http://localhost:4000/api/pipelines/42/logging.tar.gz",
+}
+
+func TestPipelineSummaryLines(t *testing.T) {
+	assert.Equal(t, "DevLake pipeline #42 of project devlake: TASK_FAILED", testPipelineSummary.Title())
+	assert.Equal(t, []string{
+		"Project: devlake",
+		"Blueprint: devlake-blueprint",
+		"Status: TASK_FAILED",
+		"Duration: 1m30s",
+		"Failed tasks:",
+		"- github / collectApiIssues: 401 Unauthorized",
+	}, testPipelineSummary.Lines())
+}
+
+func TestSlackChannel(t *testing.T) {
+	message, err := slackMessage(testPipelineSummary)
+	assert.Nil(t, err)
+
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Empty(t, r.URL.RawQuery)
+		assert.Nil(t, json.Unmarshal(body, &received))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	code, response, err := postNotification(server.Client(), &models.Notification{
+		Channel:  models.NOTIFICATION_CHANNEL_SLACK,
+		Endpoint: server.URL,
+		Data:     string(message),
+	}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, testPipelineSummary.Title(), received["text"])
+	section := received["blocks"].([]interface{})[1].(map[string]interface{})["text"].(map[string]interface{})
+	assert.Contains(t, section["text"], "- github / collectApiIssues: 401 Unauthorized")
+	assert.Contains(t, section["text"], "<"+testPipelineSummary.LogUrl+"|Download logs>")
+}
+
+func TestFeishuChannel(t *testing.T) {
+	message, err := feishuMessage(testPipelineSummary)
+	assert.Nil(t, err)
+
+	var received map[string]interface{}
+	respondCode := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Nil(t, json.Unmarshal(body, &received))
+		if respondCode == 0 {
+			_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+		} else {
+			_, _ = w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+		}
+	}))
+	defer server.Close()
+
+	notification := &models.Notification{
+		Channel:  models.NOTIFICATION_CHANNEL_FEISHU,
+		Endpoint: server.URL,
+		Data:     string(message),
+	}
+	code, _, err := postNotification(server.Client(), notification, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "post", received["msg_type"])
+	timestamp := received["timestamp"].(string)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\nsecret"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), received["sign"])
+	post := received["content"].(map[string]interface{})["post"].(map[string]interface{})["en_us"].(map[string]interface{})
+	assert.Equal(t, testPipelineSummary.Title(), post["title"])
+
+	respondCode = 19021
+	code, _, err = postNotification(server.Client(), notification, "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, err)
+}
+
+func TestChatChannelMatchesFinishedPipelinesByDefault(t *testing.T) {
+	subscription := &models.NotificationSubscription{Enabled: true, Channel: models.NOTIFICATION_CHANNEL_SLACK}
+	assert.True(t, subscription.Matches("devlake", 0, models.TASK_COMPLETED))
+	assert.False(t, subscription.Matches("devlake", 0, models.TASK_RUNNING))
+	subscription.Statuses = []string{models.TASK_RUNNING}
+	assert.True(t, subscription.Matches("devlake", 0, models.TASK_RUNNING))
+}
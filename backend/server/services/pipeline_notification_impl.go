@@ -22,72 +22,304 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"github.com/apache/incubator-devlake/core/errors"
-	"github.com/apache/incubator-devlake/core/models"
-	"github.com/apache/incubator-devlake/core/utils"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/utils"
 )
 
-// DefaultPipelineNotificationService FIXME ...
+const (
+	defaultNotificationMaxAttempts   = 5
+	defaultNotificationRetryInterval = time.Minute
+	maxNotificationRetryInterval     = 6 * time.Hour
+	maxNotificationResponseLength    = 4096
+)
+
+// DefaultPipelineNotificationService delivers pipeline notifications to the endpoint configured by
+// NOTIFICATION_ENDPOINT and to every matching subscription, failed deliveries are retried with an
+// exponential backoff until NOTIFICATION_MAX_ATTEMPTS is reached
 type DefaultPipelineNotificationService struct {
-	EndPoint string
-	Secret   string
+	EndPoint      string
+	Secret        string
+	LinkBaseUrl   string // used to build links in chat channel messages
+	MaxAttempts   int
+	RetryInterval time.Duration
+	client        *http.Client
+	inflight      sync.Map
 }
 
 // NewDefaultPipelineNotificationService creates a new DefaultPipelineNotificationService
 func NewDefaultPipelineNotificationService(endpoint, secret string) *DefaultPipelineNotificationService {
 	return &DefaultPipelineNotificationService{
-		EndPoint: endpoint,
-		Secret:   secret,
+		EndPoint:      endpoint,
+		Secret:        secret,
+		MaxAttempts:   defaultNotificationMaxAttempts,
+		RetryInterval: defaultNotificationRetryInterval,
+		client:        &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
-// PipelineStatusChanged FIXME ...
+// PipelineStatusChanged records a notification for every interested endpoint and delivers them in background
 func (n *DefaultPipelineNotificationService) PipelineStatusChanged(params PipelineNotificationParam) errors.Error {
-	return n.sendNotification(models.NotificationPipelineStatusChanged, params)
+	notifications, err := n.createNotifications(models.NotificationPipelineStatusChanged, params)
+	if err != nil {
+		return err
+	}
+	for _, notification := range notifications {
+		go n.deliver(notification)
+	}
+	return nil
 }
 
-func (n *DefaultPipelineNotificationService) sendNotification(notificationType models.NotificationType, data interface{}) errors.Error {
-	var dataJson, err = json.Marshal(data)
+func (n *DefaultPipelineNotificationService) createNotifications(notificationType models.NotificationType, params PipelineNotificationParam) ([]*models.Notification, errors.Error) {
+	dataJson, e := json.Marshal(params)
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	subscriptions := make([]*models.NotificationSubscription, 0)
+	err := db.All(&subscriptions, dal.Where("enabled = ?", true))
 	if err != nil {
-		return errors.Convert(err)
+		return nil, errors.Default.Wrap(err, "error finding notification subscriptions")
 	}
+	matched := make([]*models.NotificationSubscription, 0, len(subscriptions)+1)
+	if strings.TrimSpace(n.EndPoint) != "" {
+		matched = append(matched, &models.NotificationSubscription{
+			Channel:  models.NOTIFICATION_CHANNEL_WEBHOOK,
+			Endpoint: n.EndPoint,
+		})
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Matches(params.ProjectName, params.BlueprintId, params.Status) {
+			matched = append(matched, subscription)
+		}
+	}
+	var summary *PipelineSummary
+	notifications := make([]*models.Notification, 0, len(matched))
+	now := time.Now()
+	for _, subscription := range matched {
+		data := string(dataJson)
+		if subscription.Channel != "" && subscription.Channel != models.NOTIFICATION_CHANNEL_WEBHOOK {
+			if summary == nil {
+				summary, err = buildPipelineSummary(params, n.LinkBaseUrl)
+				if err != nil {
+					return nil, err
+				}
+			}
+			message, err := channelMessage(subscription.Channel, summary)
+			if err != nil {
+				return nil, err
+			}
+			data = string(message)
+		}
+		nonce, err := utils.RandLetterBytes(16)
+		if err != nil {
+			return nil, err
+		}
+		// the retry worker takes over if the immediate delivery didn't finish in time, e.g. lake was restarted
+		nextAttemptAt := now.Add(n.RetryInterval)
+		notification := &models.Notification{
+			SubscriptionId: subscription.ID,
+			Channel:        subscription.Channel,
+			Type:           notificationType,
+			Endpoint:       subscription.Endpoint,
+			Nonce:          nonce,
+			Data:           data,
+			Status:         models.NOTIFICATION_PENDING,
+			NextAttemptAt:  &nextAttemptAt,
+		}
+		err = db.Create(notification)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "error saving notification")
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
 
-	var notification models.Notification
-	notification.Data = string(dataJson)
-	notification.Type = notificationType
-	notification.Endpoint = n.EndPoint
-	nonce, err1 := utils.RandLetterBytes(16)
-	if err1 != nil {
-		return err1
+// Redeliver sends the notification once more regardless of its current status
+func (n *DefaultPipelineNotificationService) Redeliver(notification *models.Notification) errors.Error {
+	if !n.acquire(notification.ID) {
+		return errors.Conflict.New(fmt.Sprintf("notification #%d is being delivered", notification.ID))
 	}
-	notification.Nonce = nonce
+	defer n.release(notification.ID)
+	return n.attempt(notification, false)
+}
 
-	err = db.Create(&notification)
+// RetryPendingNotifications delivers all notifications whose next attempt is due
+func (n *DefaultPipelineNotificationService) RetryPendingNotifications() errors.Error {
+	notifications := make([]*models.Notification, 0)
+	err := db.All(
+		&notifications,
+		dal.Where(
+			"status IN ? AND next_attempt_at <= ?",
+			[]string{models.NOTIFICATION_PENDING, models.NOTIFICATION_RETRYING},
+			time.Now(),
+		),
+		dal.Orderby("next_attempt_at"),
+		dal.Limit(100),
+	)
 	if err != nil {
-		return errors.Convert(err)
+		return errors.Default.Wrap(err, "error finding notifications to retry")
+	}
+	for _, notification := range notifications {
+		n.deliver(notification)
 	}
+	return nil
+}
 
-	sign := n.signature(notification.Data, fmt.Sprintf("%d-%s", notification.ID, nonce))
-	url := fmt.Sprintf("%s?nouce=%d-%s&sign=%s", n.EndPoint, notification.ID, nonce, sign)
+// StartRetryWorker polls for notifications to retry until the process exits
+func (n *DefaultPipelineNotificationService) StartRetryWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := n.RetryPendingNotifications(); err != nil {
+				globalPipelineLog.Error(err, "failed to retry notifications")
+			}
+		}
+	}()
+}
 
-	resp, err := http.Post(url, "application/json", strings.NewReader(notification.Data))
-	if err != nil {
-		return errors.Convert(err)
+func (n *DefaultPipelineNotificationService) deliver(notification *models.Notification) {
+	if !n.acquire(notification.ID) {
+		return
+	}
+	defer n.release(notification.ID)
+	if err := n.attempt(notification, true); err != nil {
+		globalPipelineLog.Error(err, "failed to deliver notification #%d", notification.ID)
 	}
+}
+
+func (n *DefaultPipelineNotificationService) acquire(notificationId uint64) bool {
+	_, loaded := n.inflight.LoadOrStore(notificationId, true)
+	return !loaded
+}
 
-	notification.ResponseCode = resp.StatusCode
-	respBody, err := io.ReadAll(resp.Body)
+func (n *DefaultPipelineNotificationService) release(notificationId uint64) {
+	n.inflight.Delete(notificationId)
+}
+
+// attempt sends the notification and records the outcome, schedules next retry if `retry` is set
+func (n *DefaultPipelineNotificationService) attempt(notification *models.Notification, retry bool) errors.Error {
+	secret, err := n.secretOf(notification.SubscriptionId)
 	if err != nil {
-		return errors.Convert(err)
+		if err.GetType() == errors.NotFound {
+			// the subscription was deleted, nothing left to deliver to
+			notification.Status = models.NOTIFICATION_FAILED
+			notification.NextAttemptAt = nil
+			notification.Response = err.Error()
+			if updateErr := db.Update(notification); updateErr != nil {
+				return errors.Default.Wrap(updateErr, "error updating orphaned notification")
+			}
+		}
+		return err
+	}
+	began := time.Now()
+	responseCode, response, sendErr := postNotification(n.client, notification, secret)
+	notification.Attempts++
+	attempt := &models.NotificationAttempt{
+		NotificationId: notification.ID,
+		Attempt:        notification.Attempts,
+		ResponseCode:   responseCode,
+		Response:       response,
+		DurationMs:     time.Since(began).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	err = db.Create(attempt)
+	if err != nil {
+		return errors.Default.Wrap(err, "error saving notification attempt")
+	}
+
+	notification.ResponseCode = responseCode
+	notification.Response = response
+	now := time.Now()
+	switch {
+	case sendErr == nil && responseCode >= 200 && responseCode < 300:
+		notification.Status = models.NOTIFICATION_DELIVERED
+		notification.DeliveredAt = &now
+		notification.NextAttemptAt = nil
+	case retry && notification.Attempts < n.MaxAttempts:
+		next := now.Add(notificationBackoff(n.RetryInterval, notification.Attempts))
+		notification.Status = models.NOTIFICATION_RETRYING
+		notification.NextAttemptAt = &next
+	default:
+		notification.Status = models.NOTIFICATION_FAILED
+		notification.NextAttemptAt = nil
 	}
-	notification.Response = string(respBody)
 	return db.Update(notification)
 }
 
-func (n *DefaultPipelineNotificationService) signature(input, nouce string) string {
-	sum := sha256.Sum256([]byte(input + n.Secret + nouce))
+func (n *DefaultPipelineNotificationService) secretOf(subscriptionId uint64) (string, errors.Error) {
+	if subscriptionId == 0 {
+		return n.Secret, nil
+	}
+	subscription := &models.NotificationSubscription{}
+	err := db.First(subscription, dal.Where("id = ?", subscriptionId))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return "", errors.NotFound.New(fmt.Sprintf("notification subscription #%d not found", subscriptionId))
+		}
+		return "", errors.Default.Wrap(err, "error finding notification subscription")
+	}
+	return subscription.Secret, nil
+}
+
+// notificationBackoff returns the delay before the next attempt, doubling for every failed attempt
+func notificationBackoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxNotificationRetryInterval {
+			return maxNotificationRetryInterval
+		}
+	}
+	return delay
+}
+
+// postNotification sends the notification in the way its channel expects
+func postNotification(client *http.Client, notification *models.Notification, secret string) (int, string, errors.Error) {
+	url := notification.Endpoint
+	data := notification.Data
+	switch notification.Channel {
+	case models.NOTIFICATION_CHANNEL_SLACK:
+	case models.NOTIFICATION_CHANNEL_FEISHU:
+		if secret != "" {
+			signed, err := signFeishuMessage(data, secret, time.Now())
+			if err != nil {
+				return 0, "", err
+			}
+			data = signed
+		}
+	default:
+		nonce := fmt.Sprintf("%d-%s", notification.ID, notification.Nonce)
+		sign := notificationSignature(data, secret, nonce)
+		url = fmt.Sprintf("%s?nouce=%s&sign=%s", url, nonce, sign)
+	}
+
+	resp, err := client.Post(url, "application/json", strings.NewReader(data))
+	if err != nil {
+		return 0, "", errors.Convert(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxNotificationResponseLength))
+	if err != nil {
+		return resp.StatusCode, "", errors.Convert(err)
+	}
+	if notification.Channel == models.NOTIFICATION_CHANNEL_FEISHU {
+		return resp.StatusCode, string(respBody), checkFeishuResponse(string(respBody))
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+func notificationSignature(input, secret, nouce string) string {
+	sum := sha256.Sum256([]byte(input + secret + nouce))
 	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotificationBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, notificationBackoff(time.Minute, 1))
+	assert.Equal(t, 2*time.Minute, notificationBackoff(time.Minute, 2))
+	assert.Equal(t, 8*time.Minute, notificationBackoff(time.Minute, 4))
+	assert.Equal(t, maxNotificationRetryInterval, notificationBackoff(time.Minute, 20))
+}
+
+func TestPostNotification(t *testing.T) {
+	notification := &models.Notification{
+		Endpoint: "",
+		Nonce:    "abc",
+		Data:     `{"Status":"TASK_COMPLETED"}`,
+	}
+	notification.ID = 7
+	var gotBody, gotNonce, gotSign string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotNonce = r.URL.Query().Get("nouce")
+		gotSign = r.URL.Query().Get("sign")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("try later"))
+	}))
+	defer server.Close()
+	notification.Endpoint = server.URL
+
+	code, response, err := postNotification(server.Client(), notification, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "try later", response)
+	assert.Equal(t, notification.Data, gotBody)
+	assert.Equal(t, "7-abc", gotNonce)
+	assert.Equal(t, notificationSignature(notification.Data, "secret", "7-abc"), gotSign)
+}
+
+func TestNotificationSubscriptionMatches(t *testing.T) {
+	subscription := &models.NotificationSubscription{Enabled: true}
+	assert.True(t, subscription.Matches("p1", 1, models.TASK_FAILED))
+
+	subscription.ProjectName = "p1"
+	subscription.Statuses = []string{models.TASK_FAILED}
+	assert.True(t, subscription.Matches("p1", 1, models.TASK_FAILED))
+	assert.False(t, subscription.Matches("p2", 1, models.TASK_FAILED))
+	assert.False(t, subscription.Matches("p1", 1, models.TASK_COMPLETED))
+
+	subscription.BlueprintId = 2
+	assert.False(t, subscription.Matches("p1", 1, models.TASK_FAILED))
+
+	subscription.BlueprintId = 0
+	subscription.Enabled = false
+	assert.False(t, subscription.Matches("p1", 1, models.TASK_FAILED))
+}
+
+func TestAttemptFailsNotificationOfDeletedSubscription(t *testing.T) {
+	defer func(d dal.Dal) { db = d }(db)
+	notFound := errors.Default.New("record not found")
+	mockDal := new(mockdal.Dal)
+	mockDal.On("First", mock.Anything, mock.Anything).Return(notFound)
+	mockDal.On("IsErrorNotFound", notFound).Return(true)
+	var saved *models.Notification
+	mockDal.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.Notification)
+	}).Return(nil)
+	db = mockDal
+
+	next := time.Now()
+	notification := &models.Notification{SubscriptionId: 3, Status: models.NOTIFICATION_RETRYING, NextAttemptAt: &next}
+	service := NewDefaultPipelineNotificationService("", "")
+	err := service.attempt(notification, true)
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.NotFound, err.GetType())
+	assert.NotNil(t, saved)
+	assert.Equal(t, models.NOTIFICATION_FAILED, saved.Status)
+	assert.Nil(t, saved.NextAttemptAt)
+	mockDal.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -25,8 +25,12 @@ import (
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/core/tracing"
 	"github.com/apache/incubator-devlake/impls/logruslog"
+	"io"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type pipelineRunner struct {
@@ -34,12 +38,12 @@ type pipelineRunner struct {
 	pipeline *models.Pipeline
 }
 
-func (p *pipelineRunner) runPipelineStandalone() errors.Error {
+func (p *pipelineRunner) runPipelineStandalone(ctx context.Context) errors.Error {
 	return runner.RunPipeline(
 		basicRes.ReplaceLogger(p.logger),
 		p.pipeline.ID,
 		func(taskIds []uint64) errors.Error {
-			return RunTasksStandalone(p.logger, taskIds)
+			return RunTasksStandalone(ctx, p.logger, taskIds)
 		},
 	)
 }
@@ -56,7 +60,7 @@ func GetPipelineLogger(pipeline *models.Pipeline) log.Logger {
 	} else {
 		pipelineLogger.SetStream(&log.LoggerStreamConfig{
 			Path:   loggingPath,
-			Writer: stream,
+			Writer: io.MultiWriter(stream, newPipelineLogTap(pipeline.ID, 0)),
 		})
 	}
 	return pipelineLogger
@@ -64,6 +68,8 @@ func GetPipelineLogger(pipeline *models.Pipeline) log.Logger {
 
 // runPipeline start a pipeline actually
 func runPipeline(pipelineId uint64) errors.Error {
+	// the streams end with the pipeline whatever the outcome
+	defer publishPipelineFinished(pipelineId)
 	ppl, err := GetPipeline(pipelineId, false)
 	if err != nil {
 		return err
@@ -73,7 +79,14 @@ func runPipeline(pipelineId uint64) errors.Error {
 		pipeline: ppl,
 	}
 	// run
-	err = pipelineRun.runPipelineStandalone()
+	ctx, span := tracing.Start(
+		context.Background(),
+		"pipeline",
+		tracing.PipelineIdKey.Int64(int64(ppl.ID)),
+		attribute.Int64("devlake.blueprint_id", int64(ppl.BlueprintId)),
+	)
+	err = pipelineRun.runPipelineStandalone(ctx)
+	tracing.End(span, err)
 	isCancelled := errors.Is(err, context.Canceled)
 	if err != nil {
 		err = errors.Default.Wrap(err, fmt.Sprintf("Error running pipeline %d.", pipelineId))
@@ -85,6 +98,7 @@ func runPipeline(pipelineId uint64) errors.Error {
 	// finished, update database
 	finishedAt := time.Now()
 	dbPipeline.FinishedAt = &finishedAt
+	dbPipeline.LeaseExpiresAt = nil
 	if dbPipeline.BeganAt != nil {
 		dbPipeline.SpentSeconds = int(finishedAt.Unix() - dbPipeline.BeganAt.Unix())
 	}
@@ -97,7 +111,23 @@ func runPipeline(pipelineId uint64) errors.Error {
 		globalPipelineLog.Error(err, "compute pipeline status failed")
 		return err
 	}
-	err = db.Update(dbPipeline)
+	if !ownsPipeline(dbPipeline) {
+		globalPipelineLog.Warn(nil, "pipeline #%d was taken over by worker %s, its result is discarded", pipelineId, dbPipeline.WorkerId)
+		return nil
+	}
+	where := dal.Where("id = ?", pipelineId)
+	if clusterMode {
+		// the lease might be lost right now, the worker holding it owns the result then
+		where = dal.Where("id = ? AND worker_id = ?", pipelineId, workerId)
+	}
+	err = db.UpdateColumns(&models.Pipeline{}, []dal.DalSet{
+		{ColumnName: "finished_at", Value: dbPipeline.FinishedAt},
+		{ColumnName: "lease_expires_at", Value: nil},
+		{ColumnName: "spent_seconds", Value: dbPipeline.SpentSeconds},
+		{ColumnName: "message", Value: dbPipeline.Message},
+		{ColumnName: "error_name", Value: dbPipeline.ErrorName},
+		{ColumnName: "status", Value: dbPipeline.Status},
+	}, where)
 	if err != nil {
 		globalPipelineLog.Error(err, "update pipeline state failed")
 		return err
@@ -121,7 +151,7 @@ func ComputePipelineStatus(pipeline *models.Pipeline, isCancelled bool) (string,
 	for _, task := range tasks {
 		if task.Status == models.TASK_COMPLETED {
 			succeeded += 1
-		} else if task.Status == models.TASK_FAILED || task.Status == models.TASK_CANCELLED {
+		} else if task.Status == models.TASK_FAILED || task.Status == models.TASK_TIMEOUT || task.Status == models.TASK_CANCELLED {
 			failed += 1
 		} else if task.Status == models.TASK_RUNNING {
 			running += 1
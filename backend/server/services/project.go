@@ -118,22 +118,44 @@ func CreateProject(projectInput *models.ApiInputProject) (*models.ApiOutputProje
 		}
 	}()
 
+	project, blueprint, err := createProject(tx, projectInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// all good, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	// reload schedule
+	err = reloadBlueprint(blueprint)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeProjectOutput(project, false)
+}
+
+// createProject creates the project along with its metrics and blueprint within the transaction
+func createProject(tx dal.Transaction, projectInput *models.ApiInputProject) (*models.Project, *models.Blueprint, errors.Error) {
 	// create project first
 	project := &models.Project{}
 	project.BaseProject = projectInput.BaseProject
-	err = db.Create(project)
+	err := tx.Create(project)
 	if err != nil {
-		if db.IsDuplicationError(err) {
-			return nil, errors.BadInput.New(fmt.Sprintf("A project with name [%s] already exists", project.Name))
+		if tx.IsDuplicationError(err) {
+			return nil, nil, errors.BadInput.New(fmt.Sprintf("A project with name [%s] already exists", project.Name))
 		}
-		return nil, errors.Default.Wrap(err, "error creating DB project")
+		return nil, nil, errors.Default.Wrap(err, "error creating DB project")
 	}
 
 	// check if we need flush the Metrics
 	if len(projectInput.Metrics) > 0 {
 		err = refreshProjectMetrics(tx, projectInput)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -159,22 +181,9 @@ func CreateProject(projectInput *models.ApiInputProject) (*models.ApiOutputProje
 	}
 	err = tx.Create(blueprint)
 	if err != nil {
-		return nil, errors.Default.Wrap(err, "error creating DB blueprint")
-	}
-
-	// all good, commit transaction
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
+		return nil, nil, errors.Default.Wrap(err, "error creating DB blueprint")
 	}
-
-	// reload schedule
-	err = reloadBlueprint(blueprint)
-	if err != nil {
-		return nil, err
-	}
-
-	return makeProjectOutput(project, false)
+	return project, blueprint, nil
 }
 
 // GetProject returns a Project
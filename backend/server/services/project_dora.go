@@ -0,0 +1,91 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+)
+
+// ProjectDoraQuery used to query the DORA metrics of a project
+type ProjectDoraQuery struct {
+	PeriodType string     `form:"periodType"`
+	StartDate  *time.Time `form:"startDate" time_format:"2006-01-02"`
+	EndDate    *time.Time `form:"endDate" time_format:"2006-01-02"`
+}
+
+// ProjectDora is the DORA metrics of a project along with the benchmarks they were graded against
+type ProjectDora struct {
+	ProjectName string                           `json:"projectName"`
+	PeriodType  string                           `json:"periodType"`
+	DoraReport  string                           `json:"doraReport"`
+	Benchmarks  []*crossdomain.DoraBenchmark     `json:"benchmarks"`
+	Metrics     []*crossdomain.ProjectDoraMetric `json:"metrics"`
+}
+
+// GetProjectDora returns the DORA metrics calculated by the dora plugin for the project
+func GetProjectDora(projectName string, query *ProjectDoraQuery) (*ProjectDora, errors.Error) {
+	if projectName == "" {
+		return nil, errors.BadInput.New("project name is missing")
+	}
+	periodType := strings.ToUpper(query.PeriodType)
+	if periodType == "" {
+		periodType = crossdomain.DORA_PERIOD_MONTH
+	}
+	if periodType != crossdomain.DORA_PERIOD_MONTH && periodType != crossdomain.DORA_PERIOD_WEEK {
+		return nil, errors.BadInput.New("periodType must be either WEEK or MONTH")
+	}
+	if _, err := getProjectByName(db, projectName); err != nil {
+		return nil, err
+	}
+
+	clauses := []dal.Clause{
+		dal.From(&crossdomain.ProjectDoraMetric{}),
+		dal.Where("project_name = ? AND period_type = ?", projectName, periodType),
+	}
+	if query.StartDate != nil {
+		clauses = append(clauses, dal.Where("period_end > ?", *query.StartDate))
+	}
+	if query.EndDate != nil {
+		clauses = append(clauses, dal.Where("period_start <= ?", *query.EndDate))
+	}
+	clauses = append(clauses, dal.Orderby("period_start"))
+	output := &ProjectDora{
+		ProjectName: projectName,
+		PeriodType:  periodType,
+		Benchmarks:  make([]*crossdomain.DoraBenchmark, 0),
+		Metrics:     make([]*crossdomain.ProjectDoraMetric, 0),
+	}
+	err := db.All(&output.Metrics, clauses...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error getting DORA metrics of the project")
+	}
+	if len(output.Metrics) == 0 {
+		return output, nil
+	}
+	output.DoraReport = output.Metrics[0].DoraReport
+	err = db.All(&output.Benchmarks, dal.Where("dora_report = ?", output.DoraReport), dal.Orderby("id"))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error getting DORA benchmarks")
+	}
+	return output, nil
+}
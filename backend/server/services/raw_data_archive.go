@@ -0,0 +1,201 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// rawDataRecord is a line of the ndjson files in raw data archives and bundles, holding a row of a raw table
+type rawDataRecord struct {
+	Id        uint64          `json:"id"`
+	Params    string          `json:"params"`
+	Url       string          `json:"url"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// Data is the decompressed payload if it is valid json
+	Data json.RawMessage `json:"data,omitempty"`
+	// Text is the decompressed payload otherwise
+	Text string `json:"text,omitempty"`
+}
+
+func newRawDataRecord(row *helper.RawData) (*rawDataRecord, errors.Error) {
+	data, err := helper.DecodeRawData(row.Data)
+	if err != nil {
+		return nil, err
+	}
+	record := &rawDataRecord{
+		Id:        row.ID,
+		Params:    row.Params,
+		Url:       row.Url,
+		CreatedAt: row.CreatedAt,
+	}
+	if json.Valid(data) {
+		record.Data = data
+	} else {
+		record.Text = string(data)
+	}
+	if json.Valid(row.Input) {
+		record.Input = row.Input
+	}
+	return record, nil
+}
+
+// toRawData converts the record back to a row, the payload gets compressed as configured by RAW_DATA_COMPRESSION
+func (r *rawDataRecord) toRawData(compression string) (*helper.RawData, errors.Error) {
+	data := []byte(r.Data)
+	if r.Data == nil {
+		data = []byte(r.Text)
+	}
+	data, err := helper.EncodeRawData(data, compression)
+	if err != nil {
+		return nil, err
+	}
+	return &helper.RawData{
+		Params:    r.Params,
+		Data:      data,
+		Url:       r.Url,
+		Input:     r.Input,
+		CreatedAt: r.CreatedAt,
+	}, nil
+}
+
+// rawDataArchive writes rows into a tar.gz file holding a <table>.ndjson file for each table
+type rawDataArchive struct {
+	path    string
+	file    *os.File
+	gzip    *gzip.Writer
+	tar     *tar.Writer
+	table   string
+	ndjson  *os.File
+	entries int
+}
+
+func newRawDataArchive(archivePath string) (*rawDataArchive, errors.Error) {
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to create the directory of the raw data archive %s", archivePath))
+	}
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to create the raw data archive %s", archivePath))
+	}
+	gzipWriter := gzip.NewWriter(file)
+	return &rawDataArchive{
+		path: archivePath,
+		file: file,
+		gzip: gzipWriter,
+		tar:  tar.NewWriter(gzipWriter),
+	}, nil
+}
+
+// AddFile writes a file other than the ndjson files of the tables into the archive
+func (a *rawDataArchive) AddFile(name string, data []byte) errors.Error {
+	if err := a.flush(); err != nil {
+		return err
+	}
+	a.table = ""
+	return a.writeEntry(name, int64(len(data)), bytes.NewReader(data))
+}
+
+// Add appends the rows of the table, the rows of a table have to be added before moving to the next table
+func (a *rawDataArchive) Add(table string, rows []*helper.RawData) errors.Error {
+	if table != a.table {
+		if err := a.flush(); err != nil {
+			return err
+		}
+		ndjson, err := os.CreateTemp("", "devlake-raw-data-*.ndjson")
+		if err != nil {
+			return errors.Default.Wrap(err, "failed to create a temporary file")
+		}
+		a.table = table
+		a.ndjson = ndjson
+	}
+	encoder := json.NewEncoder(a.ndjson)
+	for _, row := range rows {
+		record, err := newRawDataRecord(row)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(record); err != nil {
+			return errors.Default.Wrap(err, "failed to write the raw data archive")
+		}
+	}
+	return nil
+}
+
+// flush moves the ndjson file of the current table into the archive
+func (a *rawDataArchive) flush() errors.Error {
+	if a.ndjson == nil {
+		return nil
+	}
+	defer func() {
+		a.ndjson.Close()
+		os.Remove(a.ndjson.Name())
+		a.ndjson = nil
+	}()
+	info, err := a.ndjson.Stat()
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to write the raw data archive")
+	}
+	if _, err := a.ndjson.Seek(0, io.SeekStart); err != nil {
+		return errors.Default.Wrap(err, "failed to write the raw data archive")
+	}
+	return a.writeEntry(a.table+".ndjson", info.Size(), a.ndjson)
+}
+
+func (a *rawDataArchive) writeEntry(name string, size int64, content io.Reader) errors.Error {
+	err := a.tar.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to write the raw data archive")
+	}
+	if _, err := io.Copy(a.tar, content); err != nil {
+		return errors.Default.Wrap(err, "failed to write the raw data archive")
+	}
+	a.entries++
+	return nil
+}
+
+// Close completes the archive, which gets removed if nothing was added
+func (a *rawDataArchive) Close() errors.Error {
+	err := a.flush()
+	for _, closer := range []io.Closer{a.tar, a.gzip, a.file} {
+		if closeErr := closer.Close(); err == nil && closeErr != nil {
+			err = errors.Default.Wrap(closeErr, "failed to close the raw data archive")
+		}
+	}
+	if a.entries == 0 {
+		os.Remove(a.path)
+	}
+	return err
+}
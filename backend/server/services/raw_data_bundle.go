@@ -0,0 +1,312 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/google/uuid"
+)
+
+const RAW_DATA_BUNDLE_VERSION = 1
+
+// the manifest is the first file of a bundle, followed by a <table>.ndjson file for each raw table
+const rawDataBundleManifestName = "manifest.json"
+
+var rawDataTableNamePattern = regexp.MustCompile(`^_raw_[A-Za-z0-9_]+$`)
+
+// RawDataBundleManifest describes the raw data of a scope held by a bundle
+type RawDataBundleManifest struct {
+	Version      int       `json:"version"`
+	Plugin       string    `json:"plugin"`
+	ConnectionId uint64    `json:"connectionId"`
+	ScopeId      string    `json:"scopeId"`
+	Params       string    `json:"params"`
+	ExportedAt   time.Time `json:"exportedAt"`
+	// Tables holds the number of rows of each raw table
+	Tables map[string]int `json:"tables"`
+}
+
+// ExportRawDataBundle writes the rows of the raw tables collected for the scope into a tar.gz bundle, and returns
+// the path of the bundle which is to be removed by the caller
+func ExportRawDataBundle(pluginName string, connectionId uint64, scopeId string) (bundlePath string, err errors.Error) {
+	params, err := getScopeRawDataParams(pluginName, connectionId, scopeId)
+	if err != nil {
+		return "", err
+	}
+	manifest := &RawDataBundleManifest{
+		Version:      RAW_DATA_BUNDLE_VERSION,
+		Plugin:       pluginName,
+		ConnectionId: connectionId,
+		ScopeId:      scopeId,
+		Params:       params,
+		ExportedAt:   time.Now(),
+		Tables:       make(map[string]int),
+	}
+	allTables, err := db.AllTables()
+	if err != nil {
+		return "", err
+	}
+	tables := make([]string, 0)
+	for _, table := range allTables {
+		if !strings.HasPrefix(table, fmt.Sprintf("_raw_%s_", pluginName)) {
+			continue
+		}
+		count, err := db.Count(dal.From(table), dal.Where("params = ?", params))
+		if err != nil {
+			return "", err
+		}
+		if count > 0 {
+			tables = append(tables, table)
+			manifest.Tables[table] = int(count)
+		}
+	}
+	if len(tables) == 0 {
+		return "", errors.NotFound.New(fmt.Sprintf("no raw data was collected for the scope %s of %s connection %d", scopeId, pluginName, connectionId))
+	}
+
+	bundlePath = fmt.Sprintf("%s/raw-data-%s.tar.gz", os.TempDir(), uuid.New())
+	archive, err := newRawDataArchive(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := archive.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(bundlePath)
+			bundlePath = ""
+		}
+	}()
+	manifestData, e := json.Marshal(manifest)
+	if e != nil {
+		return "", errors.Default.Wrap(e, "failed to marshal the manifest of the raw data bundle")
+	}
+	if err = archive.AddFile(rawDataBundleManifestName, manifestData); err != nil {
+		return "", err
+	}
+	for _, table := range tables {
+		var lastId uint64
+		for {
+			rows := make([]*helper.RawData, 0, rawDataMaintenancePageSize)
+			err = db.All(
+				&rows,
+				dal.From(table),
+				dal.Where("params = ? AND id > ?", params, lastId),
+				dal.Orderby("id"),
+				dal.Limit(rawDataMaintenancePageSize),
+			)
+			if err != nil {
+				return "", err
+			}
+			if len(rows) > 0 {
+				if err = archive.Add(table, rows); err != nil {
+					return "", err
+				}
+				lastId = rows[len(rows)-1].ID
+			}
+			if len(rows) < rawDataMaintenancePageSize {
+				break
+			}
+		}
+	}
+	return bundlePath, nil
+}
+
+// ImportRawDataBundle replaces the rows of the raw tables for the scope of the bundle with the ones it holds, so
+// the scope can be processed with `skipCollectors`. The rows are imported for the connection with the given id if
+// it is not 0, the connection and the scope must exist in this instance to run a blueprint on them.
+func ImportRawDataBundle(r io.Reader, connectionId uint64) (manifest *RawDataBundleManifest, err errors.Error) {
+	gzipReader, e := gzip.NewReader(r)
+	if e != nil {
+		return nil, errors.BadInput.Wrap(e, "the raw data bundle is not a tar.gz file")
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+
+	header, e := tarReader.Next()
+	if e != nil || header.Name != rawDataBundleManifestName {
+		return nil, errors.BadInput.New(fmt.Sprintf("the raw data bundle must start with %s", rawDataBundleManifestName))
+	}
+	manifest = &RawDataBundleManifest{}
+	if e := json.NewDecoder(tarReader).Decode(manifest); e != nil {
+		return nil, errors.BadInput.Wrap(e, "invalid manifest of the raw data bundle")
+	}
+	if err = validateRawDataBundleManifest(manifest); err != nil {
+		return nil, err
+	}
+	params := manifest.Params
+	if connectionId != 0 && connectionId != manifest.ConnectionId {
+		params, err = remapRawDataParams(manifest.Params, manifest.ConnectionId, connectionId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	compression := cfg.GetString("RAW_DATA_COMPRESSION")
+	if err = helper.ValidateRawDataCompression(compression); err != nil {
+		return nil, err
+	}
+	// tables are created ahead of the transaction since DDL statements commit it implicitly on mysql
+	for table := range manifest.Tables {
+		if err = db.AutoMigrate(&helper.RawData{}, dal.From(table)); err != nil {
+			return nil, err
+		}
+	}
+
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	tx := txHelper.Begin()
+	defer txHelper.End()
+	for table := range manifest.Tables {
+		if err = tx.Delete(&helper.RawData{}, dal.From(table), dal.Where("params = ?", params)); err != nil {
+			return nil, err
+		}
+	}
+	imported := make(map[string]int)
+	for {
+		header, e := tarReader.Next()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return nil, errors.BadInput.Wrap(e, "failed to read the raw data bundle")
+		}
+		table := strings.TrimSuffix(header.Name, ".ndjson")
+		if _, ok := manifest.Tables[table]; !ok || table == header.Name {
+			return nil, errors.BadInput.New(fmt.Sprintf("unexpected file %s in the raw data bundle", header.Name))
+		}
+		if imported[table], err = importRawDataRecords(tx, table, tarReader, manifest.Params, params, compression); err != nil {
+			return nil, err
+		}
+	}
+	for table, count := range manifest.Tables {
+		if imported[table] != count {
+			return nil, errors.BadInput.New(fmt.Sprintf("the raw data bundle holds %d rows of %s instead of %d", imported[table], table, count))
+		}
+	}
+	if connectionId != 0 {
+		manifest.ConnectionId = connectionId
+	}
+	manifest.Params = params
+	return manifest, nil
+}
+
+// importRawDataRecords inserts the rows of an ndjson file of a bundle into the table, and returns how many of them
+// were inserted
+func importRawDataRecords(tx dal.Transaction, table string, ndjson io.Reader, bundleParams, params, compression string) (int, errors.Error) {
+	decoder := json.NewDecoder(ndjson)
+	count := 0
+	rows := make([]*helper.RawData, 0, rawDataMaintenancePageSize)
+	flush := func() errors.Error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows, dal.From(table)); err != nil {
+			return err
+		}
+		count += len(rows)
+		rows = rows[:0]
+		return nil
+	}
+	for {
+		record := &rawDataRecord{}
+		if e := decoder.Decode(record); e == io.EOF {
+			break
+		} else if e != nil {
+			return count, errors.BadInput.Wrap(e, fmt.Sprintf("invalid rows of %s in the raw data bundle", table))
+		}
+		if record.Params != bundleParams {
+			return count, errors.BadInput.New(fmt.Sprintf("the raw data bundle holds rows of %s for other scopes", table))
+		}
+		row, err := record.toRawData(compression)
+		if err != nil {
+			return count, err
+		}
+		row.Params = params
+		rows = append(rows, row)
+		if len(rows) == rawDataMaintenancePageSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, flush()
+}
+
+func validateRawDataBundleManifest(manifest *RawDataBundleManifest) errors.Error {
+	if manifest.Version != RAW_DATA_BUNDLE_VERSION {
+		return errors.BadInput.New(fmt.Sprintf("unsupported version %d of the raw data bundle", manifest.Version))
+	}
+	if manifest.Plugin == "" || manifest.Params == "" {
+		return errors.BadInput.New("the manifest of the raw data bundle misses the plugin or the params")
+	}
+	if _, err := plugin.GetPlugin(manifest.Plugin); err != nil {
+		return errors.BadInput.Wrap(err, fmt.Sprintf("the plugin %s of the raw data bundle is not available", manifest.Plugin))
+	}
+	for table := range manifest.Tables {
+		if !rawDataTableNamePattern.MatchString(table) || !strings.HasPrefix(table, fmt.Sprintf("_raw_%s_", manifest.Plugin)) {
+			return errors.BadInput.New(fmt.Sprintf("invalid raw table %s of the plugin %s", table, manifest.Plugin))
+		}
+	}
+	return nil
+}
+
+// remapRawDataParams replaces the connection id of the params, keeping the order of the fields which the raw rows
+// are matched by
+func remapRawDataParams(params string, from, to uint64) (string, errors.Error) {
+	fromField := fmt.Sprintf(`"ConnectionId":%d`, from)
+	if strings.Count(params, fromField) != 1 {
+		return "", errors.BadInput.New(fmt.Sprintf("the params %s of the raw data bundle can not be mapped to connection %d", params, to))
+	}
+	return strings.Replace(params, fromField, fmt.Sprintf(`"ConnectionId":%d`, to), 1), nil
+}
+
+// getScopeRawDataParams returns the params of the raw rows collected for the scope
+func getScopeRawDataParams(pluginName string, connectionId uint64, scopeId string) (string, errors.Error) {
+	src, err := configPluginSource(pluginName)
+	if err != nil {
+		return "", err
+	}
+	if configModelIsNil(src.Scope()) {
+		return "", errors.BadInput.New(fmt.Sprintf("plugin %s has no scopes", pluginName))
+	}
+	scopes, err := loadConfigModels(db, src.Scope(), dal.Where("connection_id = ?", connectionId))
+	if err != nil {
+		return "", err
+	}
+	for _, scope := range scopes {
+		s := scope.(plugin.ToolLayerScope)
+		if s.ScopeId() == scopeId {
+			return plugin.MarshalScopeParams(s.ScopeParams()), nil
+		}
+	}
+	return "", errors.NotFound.New(fmt.Sprintf("scope %s of %s connection %d not found", scopeId, pluginName, connectionId))
+}
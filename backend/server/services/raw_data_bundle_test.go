@@ -0,0 +1,104 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemapRawDataParams(t *testing.T) {
+	params, err := remapRawDataParams(`{"ConnectionId":1,"Name":"apache/incubator-devlake"}`, 1, 12)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ConnectionId":12,"Name":"apache/incubator-devlake"}`, params)
+
+	_, err = remapRawDataParams(`{"ConnectionId":2,"Name":"apache/incubator-devlake"}`, 1, 12)
+	assert.NotNil(t, err)
+}
+
+func TestRawDataRecord(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":1,"title":"` + string(bytes.Repeat([]byte("a"), 1024)) + `"}`)
+	compressed, err := helper.EncodeRawData(payload, helper.RAW_DATA_COMPRESSION_ZSTD)
+	assert.Nil(t, err)
+	for _, data := range [][]byte{payload, compressed, []byte("plain text")} {
+		row := &helper.RawData{ID: 1, Params: `{"ConnectionId":1}`, Data: data, Url: "https://example.com", Input: json.RawMessage(`{"id":1}`), CreatedAt: createdAt}
+		record, err := newRawDataRecord(row)
+		assert.Nil(t, err)
+		imported, err := record.toRawData(helper.RAW_DATA_COMPRESSION_NONE)
+		assert.Nil(t, err)
+		decoded, _ := helper.DecodeRawData(data)
+		assert.Equal(t, decoded, imported.Data)
+		assert.Equal(t, row.Params, imported.Params)
+		assert.Equal(t, row.Url, imported.Url)
+		assert.Equal(t, row.Input, imported.Input)
+		assert.Equal(t, row.CreatedAt, imported.CreatedAt)
+	}
+}
+
+func TestRawDataArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive", "raw-data.tar.gz")
+	archive, err := newRawDataArchive(archivePath)
+	assert.Nil(t, err)
+	assert.Nil(t, archive.AddFile(rawDataBundleManifestName, []byte(`{"version":1}`)))
+	assert.Nil(t, archive.Add("_raw_github_api_issues", []*helper.RawData{{ID: 1, Data: []byte(`{"id":1}`)}}))
+	assert.Nil(t, archive.Add("_raw_github_api_issues", []*helper.RawData{{ID: 2, Data: []byte(`{"id":2}`)}}))
+	assert.Nil(t, archive.Add("_raw_github_api_pulls", []*helper.RawData{{ID: 1, Data: []byte(`{"id":3}`)}}))
+	assert.Nil(t, archive.Close())
+
+	file, e := os.Open(archivePath)
+	assert.Nil(t, e)
+	defer file.Close()
+	gzipReader, e := gzip.NewReader(file)
+	assert.Nil(t, e)
+	tarReader := tar.NewReader(gzipReader)
+	lines := make(map[string]int)
+	names := make([]string, 0)
+	for {
+		header, e := tarReader.Next()
+		if e == io.EOF {
+			break
+		}
+		assert.Nil(t, e)
+		names = append(names, header.Name)
+		content, e := io.ReadAll(tarReader)
+		assert.Nil(t, e)
+		lines[header.Name] = bytes.Count(content, []byte("\n"))
+	}
+	assert.Equal(t, []string{rawDataBundleManifestName, "_raw_github_api_issues.ndjson", "_raw_github_api_pulls.ndjson"}, names)
+	assert.Equal(t, 2, lines["_raw_github_api_issues.ndjson"])
+	assert.Equal(t, 1, lines["_raw_github_api_pulls.ndjson"])
+
+	// archives without anything added are removed
+	emptyPath := filepath.Join(t.TempDir(), "empty.tar.gz")
+	archive, err = newRawDataArchive(emptyPath)
+	assert.Nil(t, err)
+	assert.Nil(t, archive.Close())
+	_, e = os.Stat(emptyPath)
+	assert.True(t, os.IsNotExist(e))
+}
@@ -0,0 +1,458 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/server/services/remote"
+	"github.com/robfig/cron/v3"
+)
+
+// rows of raw tables are processed by pages to avoid holding long-running cursors
+const rawDataMaintenancePageSize = 1000
+
+// compressed payloads are written by batches of at most rawDataCompressionBatchSize rows and
+// rawDataCompressionBatchBytes bytes, to keep the statements below the packet size limit of the database
+const (
+	rawDataCompressionBatchSize  = 100
+	rawDataCompressionBatchBytes = 4 * 1024 * 1024
+)
+
+const (
+	RAW_DATA_RETENTION_LATEST = "latest"
+)
+
+// rawDataRetentionRule limits how long the payloads of matching raw tables are kept
+type rawDataRetentionRule struct {
+	// Pattern matches the names of the raw tables, e.g. _raw_github_* for the plugin github and the table *
+	Pattern string
+	// KeepLatest keeps only the latest payload of each entity
+	KeepLatest bool
+	// MaxAge removes the payloads collected longer ago than it
+	MaxAge time.Duration
+}
+
+var (
+	rawDataMaintenanceCron *cron.Cron
+	rawDataMaintenanceLock sync.Mutex
+)
+
+// parseRawDataRetentionRules parses RAW_DATA_RETENTION, a comma separated list of plugin:table:policy, where table
+// might be a glob pattern and policy is either `latest` or a max age like `90d` or `720h`
+func parseRawDataRetentionRules(s string) ([]*rawDataRetentionRule, errors.Error) {
+	rules := make([]*rawDataRetentionRule, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid raw data retention rule %s, expected plugin:table:policy", item))
+		}
+		rule := &rawDataRetentionRule{Pattern: fmt.Sprintf("_raw_%s_%s", parts[0], parts[1])}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid table pattern of raw data retention rule %s", item))
+		}
+		policy := parts[2]
+		switch {
+		case policy == RAW_DATA_RETENTION_LATEST:
+			rule.KeepLatest = true
+		case strings.HasSuffix(policy, "d"):
+			days, err := strconv.Atoi(strings.TrimSuffix(policy, "d"))
+			if err != nil || days <= 0 {
+				return nil, errors.BadInput.New(fmt.Sprintf("invalid policy of raw data retention rule %s", item))
+			}
+			rule.MaxAge = time.Duration(days) * 24 * time.Hour
+		default:
+			maxAge, err := time.ParseDuration(policy)
+			if err != nil || maxAge <= 0 {
+				return nil, errors.BadInput.New(fmt.Sprintf("invalid policy of raw data retention rule %s", item))
+			}
+			rule.MaxAge = maxAge
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matchRawDataRetentionRule returns the first rule matching the table
+func matchRawDataRetentionRule(rules []*rawDataRetentionRule, table string) *rawDataRetentionRule {
+	for _, rule := range rules {
+		if matched, _ := path.Match(rule.Pattern, table); matched {
+			return rule
+		}
+	}
+	return nil
+}
+
+// rawDataMaintenanceInit schedules the maintenance of raw tables when retention rules or compression are configured
+func rawDataMaintenanceInit() {
+	if cfg.GetString("RAW_DATA_RETENTION") == "" && cfg.GetString("RAW_DATA_COMPRESSION") == "" {
+		return
+	}
+	if _, err := parseRawDataRetentionRules(cfg.GetString("RAW_DATA_RETENTION")); err != nil {
+		logger.Error(err, "raw data maintenance is disabled")
+		return
+	}
+	if err := helper.ValidateRawDataCompression(cfg.GetString("RAW_DATA_COMPRESSION")); err != nil {
+		logger.Error(err, "raw data maintenance is disabled")
+		return
+	}
+	rawDataMaintenanceCron = cron.New(cron.WithLocation(time.UTC))
+	schedule := cfg.GetString("RAW_DATA_MAINTENANCE_CRON")
+	_, err := rawDataMaintenanceCron.AddFunc(schedule, func() {
+		if clusterMode {
+			// only the first instance to lease the tick maintains the tables
+			acquired, err := acquireLease(rawDataMaintenanceLeaseName(time.Now()), time.Hour)
+			if err != nil {
+				logger.Error(err, "failed to lease raw data maintenance")
+				return
+			}
+			if !acquired {
+				return
+			}
+		}
+		if err := RunRawDataMaintenance(); err != nil {
+			logger.Error(err, "raw data maintenance failed")
+		}
+	})
+	if err != nil {
+		logger.Error(err, "invalid RAW_DATA_MAINTENANCE_CRON %s, raw data maintenance is disabled", schedule)
+		return
+	}
+	rawDataMaintenanceCron.Start()
+	logger.Info("raw data maintenance was scheduled: %s", schedule)
+}
+
+// rawDataMaintenanceLeaseName names the lease of a cronjob tick of the maintenance, so only one instance runs it
+func rawDataMaintenanceLeaseName(tick time.Time) string {
+	return fmt.Sprintf("raw-data-maintenance:%d", tick.Truncate(time.Minute).Unix())
+}
+
+// RunRawDataMaintenance applies the retention rules to the raw tables, archiving the removed payloads if
+// RAW_DATA_ARCHIVE_PATH is set, then compresses the remaining payloads if RAW_DATA_COMPRESSION is set
+func RunRawDataMaintenance() (err errors.Error) {
+	if !rawDataMaintenanceLock.TryLock() {
+		return errors.Conflict.New("raw data maintenance is running")
+	}
+	defer rawDataMaintenanceLock.Unlock()
+
+	rules, err := parseRawDataRetentionRules(cfg.GetString("RAW_DATA_RETENTION"))
+	if err != nil {
+		return err
+	}
+	compression := cfg.GetString("RAW_DATA_COMPRESSION")
+	if err = helper.ValidateRawDataCompression(compression); err != nil {
+		return err
+	}
+	var archive *rawDataArchive
+	if archiveDir := cfg.GetString("RAW_DATA_ARCHIVE_PATH"); archiveDir != "" {
+		archivePath := filepath.Join(archiveDir, fmt.Sprintf("raw-data-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z")))
+		archive, err = newRawDataArchive(archivePath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := archive.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil && archive.entries > 0 {
+				logger.Info("raw data were archived into %s", archivePath)
+			}
+		}()
+	}
+
+	tables, err := db.AllTables()
+	if err != nil {
+		return err
+	}
+	goPlugins := make([]string, 0)
+	for name := range plugin.AllPlugins() {
+		if !remote.IsRemotePlugin(name) {
+			goPlugins = append(goPlugins, name)
+		}
+	}
+	for _, table := range tables {
+		// raw tables of pydevlake plugins are read as they are stored, and tables of unknown plugins are left alone
+		if rawDataTablePlugin(table, goPlugins) == "" {
+			continue
+		}
+		if rule := matchRawDataRetentionRule(rules, table); rule != nil {
+			removed, err := applyRawDataRetention(table, rule, archive)
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("failed to apply the retention to %s", table))
+			}
+			logger.Info("removed %d rows from %s", removed, table)
+		}
+		if compression != helper.RAW_DATA_COMPRESSION_NONE {
+			compressed, err := compressRawData(table, compression)
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("failed to compress %s", table))
+			}
+			logger.Info("compressed %d rows of %s", compressed, table)
+		}
+	}
+	return nil
+}
+
+// rawDataTablePlugin returns the plugin of the raw table among the given ones, or an empty string if none matches.
+// The longest name wins since names of plugins might be prefixes of each other, e.g. azuredevops and azuredevops_go
+func rawDataTablePlugin(table string, plugins []string) string {
+	owner := ""
+	for _, name := range plugins {
+		if strings.HasPrefix(table, fmt.Sprintf("_raw_%s_", name)) && len(name) > len(owner) {
+			owner = name
+		}
+	}
+	return owner
+}
+
+// applyRawDataRetention removes the expired rows of the table, and returns how many of them were removed
+func applyRawDataRetention(table string, rule *rawDataRetentionRule, archive *rawDataArchive) (int, errors.Error) {
+	if rule.KeepLatest {
+		removed, err := removeSupersededRawResponses(table, archive)
+		if err != nil {
+			return removed, err
+		}
+		removedEntities, err := removeSupersededRawEntities(table, archive)
+		return removed + removedEntities, err
+	}
+	removed := 0
+	expiredBefore := time.Now().Add(-rule.MaxAge)
+	for {
+		var ids []uint64
+		err := db.Pluck("id", &ids, dal.From(table), dal.Where("created_at < ?", expiredBefore), dal.Limit(rawDataMaintenancePageSize))
+		if err != nil || len(ids) == 0 {
+			return removed, err
+		}
+		if err = removeRawData(table, ids, archive); err != nil {
+			return removed, err
+		}
+		removed += len(ids)
+	}
+}
+
+// removeSupersededRawResponses removes the payloads of the responses superseded by later responses to the same
+// request, and returns how many of them were removed
+func removeSupersededRawResponses(table string, archive *rawDataArchive) (int, errors.Error) {
+	removed := 0
+	for {
+		var ids []uint64
+		err := db.Pluck("t.id", &ids,
+			dal.From(fmt.Sprintf("%s t", table)),
+			dal.Join(fmt.Sprintf(
+				"JOIN (SELECT params, url, MAX(created_at) AS latest FROM %s GROUP BY params, url) l ON l.params = t.params AND l.url = t.url",
+				table,
+			)),
+			dal.Where("t.created_at < l.latest"),
+			dal.Limit(rawDataMaintenancePageSize),
+		)
+		if err != nil || len(ids) == 0 {
+			return removed, err
+		}
+		if err = removeRawData(table, ids, archive); err != nil {
+			return removed, err
+		}
+		removed += len(ids)
+	}
+}
+
+// removeSupersededRawEntities removes the payloads superseded by later payloads of the same entities, identified by
+// the `id` field of the payloads, and returns how many of them were removed. Scopes are processed one by one, so only
+// the entities of a single scope are held in memory
+func removeSupersededRawEntities(table string, archive *rawDataArchive) (int, errors.Error) {
+	var allParams []string
+	err := db.Pluck("DISTINCT params", &allParams, dal.From(table))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, params := range allParams {
+		latestEntities := make(map[string]bool)
+		superseded := make([]uint64, 0, rawDataMaintenancePageSize)
+		var lastId uint64
+		for {
+			clauses := []dal.Clause{
+				dal.Select("id, data"),
+				dal.From(table),
+				dal.Where("params = ?", params),
+				dal.Orderby("id DESC"),
+				dal.Limit(rawDataMaintenancePageSize),
+			}
+			if lastId > 0 {
+				clauses = append(clauses, dal.Where("id < ?", lastId))
+			}
+			rows := make([]*helper.RawData, 0, rawDataMaintenancePageSize)
+			if err := db.All(&rows, clauses...); err != nil {
+				return removed, err
+			}
+			for _, row := range rows {
+				if rawDataSuperseded(row, latestEntities) {
+					superseded = append(superseded, row.ID)
+				}
+				lastId = row.ID
+			}
+			// the rows visited already are removed, the pages to come are not affected
+			if len(superseded) >= rawDataMaintenancePageSize || (len(rows) < rawDataMaintenancePageSize && len(superseded) > 0) {
+				if err := removeRawData(table, superseded, archive); err != nil {
+					return removed, err
+				}
+				removed += len(superseded)
+				superseded = superseded[:0]
+			}
+			if len(rows) < rawDataMaintenancePageSize {
+				break
+			}
+		}
+	}
+	return removed, nil
+}
+
+// removeRawData deletes the rows from the table, after adding them to the archive if there is one
+func removeRawData(table string, ids []uint64, archive *rawDataArchive) errors.Error {
+	if archive != nil {
+		rows := make([]*helper.RawData, 0, len(ids))
+		if err := db.All(&rows, dal.From(table), dal.Where("id IN ?", ids)); err != nil {
+			return err
+		}
+		if err := archive.Add(table, rows); err != nil {
+			return err
+		}
+	}
+	return db.Delete(&helper.RawData{}, dal.From(table), dal.Where("id IN ?", ids))
+}
+
+// rawDataSuperseded tells whether the row is a payload of an entity seen before, the rows of a scope have to be
+// visited from the latest to the earliest. Payloads without `id` are never superseded by entities
+func rawDataSuperseded(row *helper.RawData, latestEntities map[string]bool) bool {
+	data, err := helper.DecodeRawData(row.Data)
+	if err != nil {
+		return false
+	}
+	fields := make(map[string]json.RawMessage)
+	if json.Unmarshal(data, &fields) != nil {
+		return false
+	}
+	id, ok := fields["id"]
+	if !ok || string(id) == "null" {
+		return false
+	}
+	if latestEntities[string(id)] {
+		return true
+	}
+	latestEntities[string(id)] = true
+	return false
+}
+
+// compressRawData compresses the payloads of the table which are stored as they are, and returns how many of them
+// were compressed
+func compressRawData(table string, compression string) (int, errors.Error) {
+	compressed := 0
+	var lastId uint64
+	batch := make([]*helper.RawData, 0, rawDataCompressionBatchSize)
+	batchBytes := 0
+	flush := func() errors.Error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := updateRawData(table, batch); err != nil {
+			return err
+		}
+		compressed += len(batch)
+		batch = batch[:0]
+		batchBytes = 0
+		return nil
+	}
+	for {
+		rows := make([]*helper.RawData, 0, rawDataMaintenancePageSize)
+		err := db.All(
+			&rows,
+			dal.Select("id, data"),
+			dal.From(table),
+			dal.Where("id > ?", lastId),
+			dal.Orderby("id"),
+			dal.Limit(rawDataMaintenancePageSize),
+		)
+		if err != nil {
+			return compressed, err
+		}
+		for _, row := range rows {
+			lastId = row.ID
+			if helper.IsRawDataCompressed(row.Data) {
+				continue
+			}
+			data, err := helper.EncodeRawData(row.Data, compression)
+			if err != nil {
+				return compressed, err
+			}
+			if len(data) == len(row.Data) {
+				// too small to be compressed
+				continue
+			}
+			batch = append(batch, &helper.RawData{ID: row.ID, Data: data})
+			batchBytes += len(data)
+			if len(batch) >= rawDataCompressionBatchSize || batchBytes >= rawDataCompressionBatchBytes {
+				if err := flush(); err != nil {
+					return compressed, err
+				}
+			}
+		}
+		if len(rows) < rawDataMaintenancePageSize {
+			return compressed, flush()
+		}
+	}
+}
+
+// updateRawData replaces the payloads of the rows with one statement
+func updateRawData(table string, rows []*helper.RawData) errors.Error {
+	query, params := updateRawDataStatement(table, rows, db.Dialect())
+	return db.Exec(query, params...)
+}
+
+func updateRawDataStatement(table string, rows []*helper.RawData, dialect string) (string, []interface{}) {
+	then := "?"
+	if dialect == "postgres" {
+		// the type of the parameters would be inferred as text otherwise
+		then = "CAST(? AS BYTEA)"
+	}
+	var query strings.Builder
+	params := make([]interface{}, 0, 2*len(rows)+1)
+	ids := make([]uint64, 0, len(rows))
+	fmt.Fprintf(&query, "UPDATE %s SET data = CASE id", table)
+	for _, row := range rows {
+		fmt.Fprintf(&query, " WHEN ? THEN %s", then)
+		params = append(params, row.ID, row.Data)
+		ids = append(ids, row.ID)
+	}
+	query.WriteString(" END WHERE id IN ?")
+	params = append(params, ids)
+	return query.String(), params
+}
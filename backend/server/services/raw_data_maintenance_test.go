@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRawDataRetentionRules(t *testing.T) {
+	rules, err := parseRawDataRetentionRules("github:*:latest, jira:api_issues:90d,gitlab:api_jobs:720h,")
+	assert.Nil(t, err)
+	assert.Equal(t, []*rawDataRetentionRule{
+		{Pattern: "_raw_github_*", KeepLatest: true},
+		{Pattern: "_raw_jira_api_issues", MaxAge: 90 * 24 * time.Hour},
+		{Pattern: "_raw_gitlab_api_jobs", MaxAge: 720 * time.Hour},
+	}, rules)
+
+	rules, err = parseRawDataRetentionRules("")
+	assert.Nil(t, err)
+	assert.Empty(t, rules)
+
+	for _, invalid := range []string{"github:latest", "github:*:forever", "github:*:0d", "github:[:latest", ":*:latest"} {
+		_, err = parseRawDataRetentionRules(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestMatchRawDataRetentionRule(t *testing.T) {
+	rules, err := parseRawDataRetentionRules("jira:api_issues:90d,jira:*:latest")
+	assert.Nil(t, err)
+	assert.Equal(t, rules[0], matchRawDataRetentionRule(rules, "_raw_jira_api_issues"))
+	assert.Equal(t, rules[1], matchRawDataRetentionRule(rules, "_raw_jira_api_sprints"))
+	assert.Nil(t, matchRawDataRetentionRule(rules, "_raw_github_api_issues"))
+}
+
+func TestRawDataSuperseded(t *testing.T) {
+	latestEntities := make(map[string]bool)
+	// rows of a scope are visited from the latest to the earliest
+	rows := []struct {
+		row        *helper.RawData
+		superseded bool
+	}{
+		{&helper.RawData{Data: []byte(`{"id":1,"v":2}`)}, false},
+		{&helper.RawData{Data: []byte(`{"id":2}`)}, false},
+		{&helper.RawData{Data: []byte(`{"id":1,"v":1}`)}, true},
+		// payloads without id are left to the superseded responses
+		{&helper.RawData{Url: "u", Data: []byte(`["x"]`)}, false},
+		{&helper.RawData{Url: "u", Data: []byte(`["x"]`)}, false},
+		{&helper.RawData{Data: []byte(`{"id":null}`)}, false},
+	}
+	for i, r := range rows {
+		assert.Equal(t, r.superseded, rawDataSuperseded(r.row, latestEntities), i)
+	}
+}
+
+func TestRawDataTablePlugin(t *testing.T) {
+	plugins := []string{"github", "github_graphql", "azuredevops_go", "jira"}
+	assert.Equal(t, "github", rawDataTablePlugin("_raw_github_api_issues", plugins))
+	assert.Equal(t, "github_graphql", rawDataTablePlugin("_raw_github_graphql_issues", plugins))
+	assert.Equal(t, "azuredevops_go", rawDataTablePlugin("_raw_azuredevops_go_api_builds", plugins))
+	// tables of pydevlake plugins, which are not listed, are not maintained
+	assert.Equal(t, "", rawDataTablePlugin("_raw_azuredevops_builds", plugins))
+	assert.Equal(t, "", rawDataTablePlugin("_raw_jiraa_issues", plugins))
+	assert.Equal(t, "", rawDataTablePlugin("_tool_jira_issues", plugins))
+}
+
+func TestUpdateRawDataStatement(t *testing.T) {
+	rows := []*helper.RawData{{ID: 1, Data: []byte("a")}, {ID: 2, Data: []byte("b")}}
+	query, params := updateRawDataStatement("_raw_jira_api_issues", rows, "mysql")
+	assert.Equal(t, "UPDATE _raw_jira_api_issues SET data = CASE id WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN ?", query)
+	assert.Equal(t, []interface{}{uint64(1), []byte("a"), uint64(2), []byte("b"), []uint64{1, 2}}, params)
+	query, _ = updateRawDataStatement("_raw_jira_api_issues", rows[:1], "postgres")
+	assert.Equal(t, "UPDATE _raw_jira_api_issues SET data = CASE id WHEN ? THEN CAST(? AS BYTEA) END WHERE id IN ?", query)
+}
+
+func TestRawDataMaintenanceLeaseName(t *testing.T) {
+	tick := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, rawDataMaintenanceLeaseName(tick), rawDataMaintenanceLeaseName(tick.Add(2*time.Second)))
+	assert.NotEqual(t, rawDataMaintenanceLeaseName(tick), rawDataMaintenanceLeaseName(tick.Add(time.Minute)))
+}
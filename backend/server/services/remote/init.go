@@ -50,3 +50,9 @@ func NewRemotePlugin(info *models.PluginInfo) (models.RemotePlugin, errors.Error
 	remotePlugins[info.Name] = plugin
 	return plugin, nil
 }
+
+// IsRemotePlugin tells whether the plugin was loaded from REMOTE_PLUGIN_DIR, i.e. it is implemented with pydevlake
+func IsRemotePlugin(name string) bool {
+	_, ok := remotePlugins[name]
+	return ok
+}
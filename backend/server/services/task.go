@@ -51,6 +51,8 @@ func createTask(newTask *models.NewTask, tx dal.Transaction) (*models.Task, erro
 		PipelineId:  newTask.PipelineId,
 		PipelineRow: newTask.PipelineRow,
 		PipelineCol: newTask.PipelineCol,
+		// TimeoutSeconds is kept by rerun tasks
+		TimeoutSeconds: newTask.TimeoutSeconds,
 	}
 	if newTask.IsRerun {
 		task.Status = models.TASK_RERUN
@@ -175,7 +177,7 @@ func CancelTask(taskId uint64) errors.Error {
 }
 
 // RunTasksStandalone run tasks in parallel
-func RunTasksStandalone(parentLogger log.Logger, taskIds []uint64) errors.Error {
+func RunTasksStandalone(ctx context.Context, parentLogger log.Logger, taskIds []uint64) errors.Error {
 	if len(taskIds) == 0 {
 		return nil
 	}
@@ -184,7 +186,7 @@ func RunTasksStandalone(parentLogger log.Logger, taskIds []uint64) errors.Error
 		go func(id uint64) {
 			taskLog.Info("run task #%d in background ", id)
 			var err errors.Error
-			taskErr := runTaskStandalone(parentLogger, id)
+			taskErr := runTaskStandalone(ctx, parentLogger, id)
 			if taskErr != nil {
 				err = errors.Default.Wrap(taskErr, fmt.Sprintf("Error running task %d.", id))
 			}
@@ -366,7 +368,7 @@ func getTaskStatus(statuses []string) string {
 	failedCount := 0
 	completedCount := 0
 	for _, s := range statuses {
-		if s == models.TASK_FAILED {
+		if s == models.TASK_FAILED || s == models.TASK_TIMEOUT {
 			failedCount++
 		} else if s == models.TASK_COMPLETED {
 			completedCount++
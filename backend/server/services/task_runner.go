@@ -96,13 +96,13 @@ func init() {
 	runningTasks.tasks = make(map[uint64]*RunningTaskData)
 }
 
-func runTaskStandalone(parentLog log.Logger, taskId uint64) errors.Error {
+func runTaskStandalone(parentCtx context.Context, parentLog log.Logger, taskId uint64) errors.Error {
 	// deferring cleaning up
 	defer func() {
 		_, _ = runningTasks.Remove(taskId)
 	}()
 	// for task cancelling
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parentCtx)
 	err := runningTasks.Add(taskId, cancel)
 	if err != nil {
 		return err
@@ -120,6 +120,7 @@ func runTaskStandalone(parentLog log.Logger, taskId uint64) errors.Error {
 	close(progress)
 	// wait all progresses are handled
 	<-doneSignal
+	publishTaskEvent(taskId)
 	return err
 }
 
@@ -136,11 +137,22 @@ func updateTaskProgress(done chan struct{}, taskId uint64, progress chan plugin.
 		return
 	}
 	progressDetail := data.ProgressDetail
+	var pipelineId uint64
+	if task, err := GetTask(taskId); err == nil {
+		pipelineId = task.PipelineId
+	}
+	started := false
 	for {
 		p, hasMore := <-progress
 		if hasMore {
+			if !started {
+				// the task was marked as running before reporting any progress
+				publishTaskEvent(taskId)
+				started = true
+			}
 			runningTasks.mu.Lock()
 			runner.UpdateProgressDetail(basicRes, taskId, progressDetail, &p)
+			publishTaskProgressEvent(pipelineId, taskId, progressDetail, &p)
 			runningTasks.mu.Unlock()
 		} else {
 			done <- struct{}{}
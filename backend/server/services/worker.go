@@ -0,0 +1,317 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/google/uuid"
+)
+
+// In cluster mode multiple devlake instances share one database: every instance serves the REST api, the ones with
+// CONSUME_PIPELINES=true also lease pipelines from the queue. A worker renews the leases of its pipelines on every
+// heartbeat, pipelines whose lease expired (e.g. the worker crashed) are put back to the queue by the other workers.
+var clusterMode bool
+var workerId string
+var workerLeaseDuration time.Duration
+var workerHeartbeatInterval time.Duration
+
+var workerLog = globalPipelineLog.Nested("worker")
+
+// localPipelines holds the ids of the pipelines running in this instance
+var localPipelines sync.Map
+
+// blueprintsVersion tells whether blueprints were changed by other instances since the cronjobs were loaded
+var blueprintsVersion string
+
+func workerServiceInit() {
+	clusterMode = cfg.GetBool("CLUSTER_MODE")
+	workerId = cfg.GetString("WORKER_ID")
+	if workerId == "" {
+		hostName, _ := os.Hostname()
+		workerId = fmt.Sprintf("%s-%d-%s", hostName, os.Getpid(), uuid.NewString()[:8])
+	}
+	if !clusterMode {
+		return
+	}
+	workerLeaseDuration = cfg.GetDuration("WORKER_LEASE_DURATION")
+	workerHeartbeatInterval = cfg.GetDuration("WORKER_HEARTBEAT_INTERVAL")
+	if workerHeartbeatInterval <= 0 || workerLeaseDuration <= workerHeartbeatInterval {
+		panic(errors.BadInput.New(`WORKER_HEARTBEAT_INTERVAL should be positive and shorter than WORKER_LEASE_DURATION`))
+	}
+	workerLog.Info("cluster mode enabled, worker id: %s", workerId)
+}
+
+// startWorkerHeartbeat keeps leases renewed and recovers the pipelines of dead workers
+func startWorkerHeartbeat() {
+	if !clusterMode {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(workerHeartbeatInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			workerHeartbeat()
+		}
+	}()
+}
+
+func workerHeartbeat() {
+	err := renewPipelineLeases()
+	if err != nil {
+		workerLog.Error(err, "failed to renew pipeline leases")
+	}
+	err = requeuePipelines(
+		fmt.Sprintf("the worker stopped renewing its lease, the pipeline was put back to the queue at %s", time.Now().Format(time.RFC3339)),
+		dal.Where("status = ? AND (lease_expires_at IS NULL OR lease_expires_at < NOW())", models.TASK_RUNNING),
+	)
+	if err != nil {
+		workerLog.Error(err, "failed to requeue expired pipelines")
+	}
+	cancelRemotelyCancelledPipelines()
+	err = db.Delete(&models.Lease{}, dal.Where("expires_at < NOW()"))
+	if err != nil {
+		workerLog.Error(err, "failed to delete expired leases")
+	}
+	err = reloadChangedBlueprints()
+	if err != nil {
+		workerLog.Error(err, "failed to reload blueprints")
+	}
+}
+
+// dbTimeAfter computes an expiry with the clock of the database, so that the clocks of the instances may drift apart
+func dbTimeAfter(d time.Duration) dal.DalClause {
+	if db.Dialect() == "postgres" {
+		return dal.Expr("NOW() + ? * INTERVAL '1 microsecond'", d.Microseconds())
+	}
+	return dal.Expr("DATE_ADD(NOW(3), INTERVAL ? MICROSECOND)", d.Microseconds())
+}
+
+// renewPipelineLeases extends the leases of the pipelines running in this instance. The pipelines which are no longer
+// leased by this worker were requeued by another instance, most likely because the renewals did not make it in time,
+// their local tasks are cancelled so the pipeline doesn't run twice
+func renewPipelineLeases() errors.Error {
+	err := db.UpdateColumn(
+		&models.Pipeline{},
+		"lease_expires_at", dbTimeAfter(workerLeaseDuration),
+		dal.Where("worker_id = ? AND status = ?", workerId, models.TASK_RUNNING),
+	)
+	if err != nil {
+		return err
+	}
+	var localIds []uint64
+	localPipelines.Range(func(key, _ any) bool {
+		localIds = append(localIds, key.(uint64))
+		return true
+	})
+	if len(localIds) == 0 {
+		return nil
+	}
+	var ownedIds []uint64
+	err = db.Pluck("id", &ownedIds,
+		dal.From(&models.Pipeline{}),
+		dal.Where("id IN ? AND worker_id = ?", localIds, workerId),
+	)
+	if err != nil {
+		return err
+	}
+	for _, pipelineId := range lostPipelines(localIds, ownedIds) {
+		workerLog.Warn(nil, "pipeline #%d lost its lease to another worker, cancelling its local tasks", pipelineId)
+		err = cancelLocalTasks(pipelineId)
+		if err != nil {
+			workerLog.Error(err, "failed to cancel pipeline #%d", pipelineId)
+		}
+	}
+	return nil
+}
+
+// lostPipelines returns the local pipelines which are not owned by this worker anymore
+func lostPipelines(localIds []uint64, ownedIds []uint64) []uint64 {
+	owned := make(map[uint64]bool, len(ownedIds))
+	for _, id := range ownedIds {
+		owned[id] = true
+	}
+	var lost []uint64
+	for _, id := range localIds {
+		if !owned[id] {
+			lost = append(lost, id)
+		}
+	}
+	return lost
+}
+
+// ownsPipeline tells whether this worker may save the result of the pipeline
+func ownsPipeline(pipeline *models.Pipeline) bool {
+	return !clusterMode || pipeline.WorkerId == workerId
+}
+
+// requeuePipelines puts the running pipelines matching the clauses back to the queue, or marks them as failed
+// when RESUME_PIPELINES is disabled
+func requeuePipelines(message string, clauses ...dal.Clause) errors.Error {
+	status := models.TASK_FAILED
+	if cfg.GetBool("RESUME_PIPELINES") {
+		status = models.TASK_RESUME
+	}
+	var pipelineIds []uint64
+	err := db.Pluck("id", &pipelineIds, append([]dal.Clause{dal.From(&models.Pipeline{})}, clauses...)...)
+	if err != nil || len(pipelineIds) == 0 {
+		return err
+	}
+	for _, pipelineId := range pipelineIds {
+		// the condition makes sure a pipeline is requeued once even if several workers spot it at the same time
+		err = db.UpdateColumns(
+			&models.Pipeline{},
+			[]dal.DalSet{
+				{ColumnName: "status", Value: status},
+				{ColumnName: "message", Value: message},
+				{ColumnName: "worker_id", Value: ""},
+				{ColumnName: "lease_expires_at", Value: nil},
+			},
+			append([]dal.Clause{dal.Where("id = ?", pipelineId)}, clauses...)...,
+		)
+		if err != nil {
+			return err
+		}
+		err = db.UpdateColumn(
+			&models.Task{},
+			"status", status,
+			dal.Where("pipeline_id = ? AND status = ?", pipelineId, models.TASK_RUNNING),
+		)
+		if err != nil {
+			return err
+		}
+		workerLog.Warn(nil, "pipeline #%d was requeued as %s: %s", pipelineId, status, message)
+	}
+	return nil
+}
+
+// claimPipeline marks the pipeline running by this worker, it returns false if another worker claimed it first
+func claimPipeline(tx dal.Transaction, pipeline *models.Pipeline) (bool, errors.Error) {
+	var leaseExpiresAt interface{}
+	if clusterMode {
+		leaseExpiresAt = dbTimeAfter(workerLeaseDuration)
+	}
+	err := tx.UpdateColumns(&models.Pipeline{}, []dal.DalSet{
+		{ColumnName: "status", Value: models.TASK_RUNNING},
+		{ColumnName: "message", Value: ""},
+		{ColumnName: "began_at", Value: pipeline.BeganAt},
+		{ColumnName: "worker_id", Value: workerId},
+		{ColumnName: "lease_expires_at", Value: leaseExpiresAt},
+	}, dal.Where("id = ? AND status IN ?", pipeline.ID, []string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME}))
+	if err != nil {
+		return false, err
+	}
+	claimed := &models.Pipeline{}
+	err = tx.First(claimed, dal.Select("worker_id, status"), dal.Where("id = ?", pipeline.ID))
+	if err != nil {
+		return false, err
+	}
+	return claimed.WorkerId == workerId && claimed.Status == models.TASK_RUNNING, nil
+}
+
+// runningParallelLabelsInCluster returns the parallel labels of the pipelines running in all instances
+func runningParallelLabelsInCluster(tx dal.Transaction) ([]string, errors.Error) {
+	var labels []string
+	err := tx.Pluck("_devlake_pipeline_labels.name", &labels,
+		dal.From(&models.DbPipelineLabel{}),
+		dal.Join("JOIN _devlake_pipelines ON _devlake_pipelines.id = _devlake_pipeline_labels.pipeline_id"),
+		dal.Where("_devlake_pipelines.status = ? AND _devlake_pipeline_labels.name LIKE 'parallel/%'", models.TASK_RUNNING),
+	)
+	return labels, err
+}
+
+// cancelRemotelyCancelledPipelines cancels the local pipelines which were cancelled through other instances
+func cancelRemotelyCancelledPipelines() {
+	localPipelines.Range(func(key, _ any) bool {
+		pipelineId := key.(uint64)
+		pipeline := &models.Pipeline{}
+		err := db.First(pipeline, dal.Select("status"), dal.Where("id = ?", pipelineId))
+		if err != nil {
+			workerLog.Error(err, "failed to load pipeline #%d", pipelineId)
+			return true
+		}
+		if pipeline.Status == models.TASK_CANCELLED {
+			workerLog.Info("pipeline #%d was cancelled by another instance", pipelineId)
+			err = cancelLocalTasks(pipelineId)
+			if err != nil {
+				workerLog.Error(err, "failed to cancel pipeline #%d", pipelineId)
+			}
+		}
+		return true
+	})
+}
+
+// acquireLease obtains the named lease for ttl, it returns false if the lease is held by another instance
+func acquireLease(name string, ttl time.Duration) (bool, errors.Error) {
+	// create the lease if it doesn't exist yet, the expiry is set with the clock of the database right after
+	_ = db.Create(&models.Lease{Name: name, Holder: workerId, ExpiresAt: time.Now().Add(ttl)})
+	// take the lease over if it expired, or extend it if we hold it already
+	err := db.UpdateColumns(&models.Lease{}, []dal.DalSet{
+		{ColumnName: "holder", Value: workerId},
+		{ColumnName: "expires_at", Value: dbTimeAfter(ttl)},
+	}, dal.Where("name = ? AND (expires_at < NOW() OR holder = ?)", name, workerId))
+	if err != nil {
+		return false, err
+	}
+	lease := &models.Lease{}
+	err = db.First(lease, dal.Where("name = ?", name))
+	if err != nil {
+		return false, err
+	}
+	return lease.Holder == workerId, nil
+}
+
+// blueprintTriggerLeaseName names the lease of a cronjob tick of a blueprint, so only one instance triggers it
+func blueprintTriggerLeaseName(blueprintId uint64, tick time.Time) string {
+	return fmt.Sprintf("blueprint:%d:%d", blueprintId, tick.Truncate(time.Minute).Unix())
+}
+
+// reloadChangedBlueprints reloads the cronjobs when blueprints were created, updated or deleted by other instances
+func reloadChangedBlueprints() errors.Error {
+	version, err := getBlueprintsVersion()
+	if err != nil {
+		return err
+	}
+	if version == blueprintsVersion {
+		return nil
+	}
+	workerLog.Info("blueprints changed, reloading cronjobs")
+	return ReloadBlueprints()
+}
+
+func getBlueprintsVersion() (string, errors.Error) {
+	total, err := db.Count(dal.From(&models.Blueprint{}))
+	if err != nil {
+		return "", err
+	}
+	var lastUpdated []time.Time
+	err = db.Pluck("updated_at", &lastUpdated, dal.From(&models.Blueprint{}), dal.Orderby("updated_at DESC"), dal.Limit(1))
+	if err != nil {
+		return "", err
+	}
+	if len(lastUpdated) == 0 {
+		return fmt.Sprintf("%d", total), nil
+	}
+	return fmt.Sprintf("%d:%d", total, lastUpdated[0].UnixNano()), nil
+}
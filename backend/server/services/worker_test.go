@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBlueprintTriggerLeaseName(t *testing.T) {
+	tick := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	// instances firing the same cron tick a few seconds apart compete for the same lease
+	assert.Equal(t, blueprintTriggerLeaseName(1, tick), blueprintTriggerLeaseName(1, tick.Add(2*time.Second)))
+	assert.Equal(t, "blueprint:1:1792224000", blueprintTriggerLeaseName(1, tick))
+	assert.NotEqual(t, blueprintTriggerLeaseName(1, tick), blueprintTriggerLeaseName(2, tick))
+	assert.NotEqual(t, blueprintTriggerLeaseName(1, tick), blueprintTriggerLeaseName(1, tick.Add(time.Minute)))
+}
+
+func TestLostPipelines(t *testing.T) {
+	assert.Equal(t, []uint64{2, 3}, lostPipelines([]uint64{1, 2, 3}, []uint64{1}))
+	assert.Empty(t, lostPipelines([]uint64{1, 2}, []uint64{2, 1}))
+	assert.Empty(t, lostPipelines(nil, []uint64{1}))
+}
+
+func TestRequeuePipelines(t *testing.T) {
+	defer func(d dal.Dal, c config.ConfigReader) { db, cfg = d, c }(db, cfg)
+	v := config.GetConfig()
+	defer v.Set("RESUME_PIPELINES", v.GetBool("RESUME_PIPELINES"))
+	v.Set("RESUME_PIPELINES", true)
+	cfg = v
+
+	mockDal := new(mockdal.Dal)
+	mockDal.On("Pluck", "id", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*[]uint64) = []uint64{7}
+	}).Return(nil).Once()
+	mockDal.On("UpdateColumns", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		set := make(map[string]interface{})
+		for _, s := range args.Get(1).([]dal.DalSet) {
+			set[s.ColumnName] = s.Value
+		}
+		// the lease is released so that any worker may pick the pipeline up again
+		assert.Equal(t, models.TASK_RESUME, set["status"])
+		assert.Equal(t, "", set["worker_id"])
+		assert.Nil(t, set["lease_expires_at"])
+	}).Return(nil).Once()
+	mockDal.On("UpdateColumn", mock.Anything, "status", models.TASK_RESUME, mock.Anything).Return(nil).Once()
+	db = mockDal
+
+	err := requeuePipelines("expired", dal.Where("status = ?", models.TASK_RUNNING))
+	assert.Nil(t, err)
+	mockDal.AssertExpectations(t)
+}
+
+func TestRenewPipelineLeasesCancelsLostPipelines(t *testing.T) {
+	defer func(d dal.Dal, id string) { db, workerId = d, id }(db, workerId)
+	if vld == nil {
+		vld = validator.New()
+	}
+	workerId = "worker-a"
+	localPipelines.Store(uint64(1), true)
+	localPipelines.Store(uint64(2), true)
+	defer localPipelines.Delete(uint64(1))
+	defer localPipelines.Delete(uint64(2))
+
+	mockDal := new(mockdal.Dal)
+	mockDal.On("Dialect").Return("mysql")
+	mockDal.On("UpdateColumn", mock.Anything, "lease_expires_at", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		// the expiry is computed by the database
+		assert.IsType(t, dal.DalClause{}, args.Get(2))
+	}).Return(nil).Once()
+	// pipeline #2 was requeued and taken by another worker meanwhile
+	mockDal.On("Pluck", "id", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*[]uint64) = []uint64{1}
+	}).Return(nil).Once()
+	var cancelled []interface{}
+	mockDal.On("Count", mock.Anything).Run(func(args mock.Arguments) {
+		for _, c := range args.Get(0).([]dal.Clause) {
+			if where, ok := c.Data.(dal.DalClause); ok && c.Type == dal.WhereClause && where.Expr == "pipeline_id = ?" {
+				cancelled = append(cancelled, where.Params...)
+			}
+		}
+	}).Return(int64(0), nil)
+	mockDal.On("All", mock.Anything, mock.Anything).Return(nil)
+	db = mockDal
+
+	err := renewPipelineLeases()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{uint64(2)}, cancelled)
+	mockDal.AssertExpectations(t)
+}
+
+func TestOwnsPipeline(t *testing.T) {
+	defer func(c bool, id string) { clusterMode, workerId = c, id }(clusterMode, workerId)
+	workerId = "worker-a"
+	clusterMode = false
+	assert.True(t, ownsPipeline(&models.Pipeline{WorkerId: "worker-b"}))
+	clusterMode = true
+	assert.True(t, ownsPipeline(&models.Pipeline{WorkerId: "worker-a"}))
+	// requeued, or taken over by another worker
+	assert.False(t, ownsPipeline(&models.Pipeline{WorkerId: ""}))
+	assert.False(t, ownsPipeline(&models.Pipeline{WorkerId: "worker-b"}))
+}
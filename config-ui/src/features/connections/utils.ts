@@ -51,6 +51,7 @@ export const transformWebhook = (connection: IWebhookAPI): IWebhook => {
     closeIssuesEndpoint: connection.closeIssuesEndpoint,
     postPipelineDeployTaskEndpoint: connection.postPipelineDeployTaskEndpoint,
     postPullRequestsEndpoint: connection.postPullRequestsEndpoint,
+    postCoverageEndpoint: connection.postCoverageEndpoint,
     apiKeyId: connection.apiKey?.id,
   };
 };
@@ -18,7 +18,7 @@
 
 import { useMemo, useState, useEffect } from 'react';
 import { CaretRightOutlined } from '@ant-design/icons';
-import { theme, Collapse, Form, Input } from 'antd';
+import { theme, Collapse, Tag, Form, Input, Checkbox } from 'antd';
 
 import { ExternalLink, HelpTooltip } from '@/components';
 import { DOC_URL } from '@/release';
@@ -160,6 +160,61 @@ const renderCollapseItems = ({
         </>
       ),
     },
+    {
+      key: 'CICD',
+      label: 'CI/CD',
+      style: panelStyle,
+      children: (
+        <>
+          <h3 style={{ marginBottom: 16 }}>
+            <span>Deployment</span>
+            <Tag style={{ marginLeft: 4 }} color="blue">
+              DORA
+            </Tag>
+          </h3>
+          <p style={{ marginBottom: 16 }}>
+            Use Regular Expression to define Deployments in DevLake in order to measure DORA metrics.{' '}
+            <ExternalLink link={DOC_URL.PLUGIN.BITBUCKET_SERVER.BASIS}>Learn more</ExternalLink>
+          </p>
+          <Checkbox checked={useCustom} onChange={onChangeUseCustom}>
+            Convert a Bitbucket Server build status to a DevLake Deployment when its name
+          </Checkbox>
+          <div style={{ margin: '8px 0', paddingLeft: 28 }}>
+            <span>matches</span>
+            <Input
+              style={{ width: 200, margin: '0 8px' }}
+              placeholder="(deploy|push-image)"
+              value={transformation.deploymentPattern ?? ''}
+              onChange={(e) =>
+                onChangeTransformation({
+                  ...transformation,
+                  deploymentPattern: e.target.value,
+                  productionPattern: !e.target.value ? '' : transformation.productionPattern,
+                })
+              }
+            />
+            <span>.</span>
+            <HelpTooltip content="Build statuses are posted to commits by Bamboo, Jenkins or other CI servers through the Bitbucket Server build status API." />
+          </div>
+          <div style={{ margin: '8px 0', paddingLeft: 28 }}>
+            <span>If the name also matches</span>
+            <Input
+              style={{ width: 200, margin: '0 8px' }}
+              placeholder="prod(.*)"
+              value={transformation.productionPattern ?? ''}
+              onChange={(e) =>
+                onChangeTransformation({
+                  ...transformation,
+                  productionPattern: e.target.value,
+                })
+              }
+            />
+            <span>, this Deployment is a ‘Production Deployment’</span>
+            <HelpTooltip content="If you leave this field empty, all Deployments will be tagged as in the Production environment. " />
+          </div>
+        </>
+      ),
+    },
     {
       key: 'ADDITIONAL',
       label: 'Additional Settings',
@@ -45,6 +45,7 @@ export const CreateDialog = ({ open, onCancel, onSubmitAfter }: Props) => {
     closeIssuesEndpoint: '',
     postDeploymentsCurl: '',
     postPullRequestsEndpoint: '',
+    postCoverageCurl: '',
     apiKey: '',
   });
 
@@ -62,6 +63,7 @@ export const CreateDialog = ({ open, onCancel, onSubmitAfter }: Props) => {
             closeIssuesEndpoint,
             postPipelineDeployTaskEndpoint,
             postPullRequestsEndpoint,
+            postCoverageEndpoint,
           },
           apiKey,
         } = await dispatch(addWebhook({ name })).unwrap();
@@ -73,6 +75,7 @@ export const CreateDialog = ({ open, onCancel, onSubmitAfter }: Props) => {
           closeIssuesEndpoint,
           postPipelineDeployTaskEndpoint,
           postPullRequestsEndpoint,
+          postCoverageEndpoint,
         };
       },
       {
@@ -170,6 +173,10 @@ export const CreateDialog = ({ open, onCancel, onSubmitAfter }: Props) => {
               .
             </p>
           </Block>
+          <Block title="Coverage">
+            <h5>Post a Cobertura, LCOV or Go coverprofile report of a commit</h5>
+            <CopyText content={record.postCoverageCurl} />
+          </Block>
         </S.Wrapper>
       )}
     </Modal>
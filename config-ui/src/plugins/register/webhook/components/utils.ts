@@ -74,5 +74,8 @@ export const transformURI = (prefix: string, webhook: IWebhook, apiKey: string)
       "headCommitSha": "b22f772f1197edfafd4cc5fe679a2d299ec12837",
       "isDraft": false
     }`,
+    postCoverageCurl: `curl ${prefix}${webhook.postCoverageEndpoint} -X 'POST' -H 'Authorization: Bearer ${
+      apiKey ?? '{API_KEY}'
+    }' -F 'repoUrl=your-git-url' -F 'commitSha=e.g. 015e3d3b480e417aede5a1293bd61de9b0fd051d' -F 'file=@coverage.xml'`,
   };
 };
@@ -105,6 +105,10 @@ export const ViewDialog = ({ initialId, onCancel }: Props) => {
             .
           </p>
         </Block>
+        <Block title="Coverage">
+          <h5>Post a Cobertura, LCOV or Go coverprofile report of a commit</h5>
+          <CopyText content={URI.postCoverageCurl} />
+        </Block>
         <Block
           title="API Key"
           description="If you have forgotten your API key, you can revoke the previous key and generate a new one as a replacement."
@@ -32,9 +32,13 @@ export const PipelineDuration = ({ status, beganAt, finishedAt }: Props) => {
   }
 
   if (
-    ![IPipelineStatus.CANCELLED, IPipelineStatus.COMPLETED, IPipelineStatus.PARTIAL, IPipelineStatus.FAILED].includes(
-      status,
-    )
+    ![
+      IPipelineStatus.CANCELLED,
+      IPipelineStatus.COMPLETED,
+      IPipelineStatus.PARTIAL,
+      IPipelineStatus.FAILED,
+      IPipelineStatus.TIMEOUT,
+    ].includes(status)
   ) {
     return <span>{dayjs(beganAt).toNow(true)}</span>;
   }
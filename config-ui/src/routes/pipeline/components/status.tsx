@@ -33,7 +33,7 @@ export const PipelineStatus = ({ status }: Props) => {
     ready: [IPipelineStatus.CREATED, IPipelineStatus.PENDING].includes(status),
     loading: [IPipelineStatus.ACTIVE, IPipelineStatus.RUNNING, IPipelineStatus.RERUN].includes(status),
     success: [IPipelineStatus.COMPLETED, IPipelineStatus.PARTIAL].includes(status),
-    error: status === IPipelineStatus.FAILED,
+    error: [IPipelineStatus.FAILED, IPipelineStatus.TIMEOUT].includes(status),
     cancel: status === IPipelineStatus.CANCELLED,
   });
 
@@ -136,6 +136,12 @@ export const PipelineTask = ({ task }: Props) => {
           </TextTooltip>
         )}
 
+        {status === IPipelineStatus.TIMEOUT && (
+          <TextTooltip content={message}>
+            <p className="error">Task timed out: hover to view the reason</p>
+          </TextTooltip>
+        )}
+
         {status === IPipelineStatus.CANCELLED && <p>Subtasks canceled</p>}
       </div>
       <div className="duration">
@@ -144,6 +150,7 @@ export const PipelineTask = ({ task }: Props) => {
           IPipelineStatus.COMPLETED,
           IPipelineStatus.PARTIAL,
           IPipelineStatus.FAILED,
+          IPipelineStatus.TIMEOUT,
           IPipelineStatus.CANCELLED,
         ].includes(status) && <Button loading={operating} icon={<RedoOutlined />} onClick={handleRerun} />}
       </div>
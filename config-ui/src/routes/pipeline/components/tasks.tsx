@@ -51,7 +51,12 @@ export const PipelineTasks = ({ id, style }: Props) => {
         return !!(
           data &&
           data.every((task) =>
-            [IPipelineStatus.COMPLETED, IPipelineStatus.FAILED, IPipelineStatus.CANCELLED].includes(task.status),
+            [
+              IPipelineStatus.COMPLETED,
+              IPipelineStatus.FAILED,
+              IPipelineStatus.TIMEOUT,
+              IPipelineStatus.CANCELLED,
+            ].includes(task.status),
           )
         );
       },
@@ -78,7 +83,9 @@ export const PipelineTasks = ({ id, style }: Props) => {
               case stages[key].every((task) => task.status === IPipelineStatus.COMPLETED):
                 status = 'success';
                 break;
-              case !!stages[key].find((task) => task.status === IPipelineStatus.FAILED):
+              case !!stages[key].find((task) =>
+                [IPipelineStatus.FAILED, IPipelineStatus.TIMEOUT].includes(task.status),
+              ):
                 status = 'error';
                 break;
               case !!stages[key].find((task) => task.status === IPipelineStatus.CANCELLED):
@@ -35,6 +35,7 @@ export const PipeLineStatusIcon = {
   [IPipelineStatus.COMPLETED]: <CheckCircleOutlined />,
   [IPipelineStatus.PARTIAL]: <CheckCircleOutlined />,
   [IPipelineStatus.FAILED]: <CloseCircleOutlined />,
+  [IPipelineStatus.TIMEOUT]: <CloseCircleOutlined />,
   [IPipelineStatus.CANCELLED]: <UndoOutlined />,
 };
 
@@ -47,5 +48,6 @@ export const PipeLineStatusLabel = {
   [IPipelineStatus.COMPLETED]: 'Succeeded',
   [IPipelineStatus.PARTIAL]: 'Partial Success',
   [IPipelineStatus.FAILED]: 'Failed',
+  [IPipelineStatus.TIMEOUT]: 'Timed Out',
   [IPipelineStatus.CANCELLED]: 'Cancelled',
 };
@@ -25,6 +25,7 @@ export enum IPipelineStatus {
   COMPLETED = 'TASK_COMPLETED',
   PARTIAL = 'TASK_PARTIAL',
   FAILED = 'TASK_FAILED',
+  TIMEOUT = 'TASK_TIMEOUT',
   CANCELLED = 'TASK_CANCELLED',
 }
 
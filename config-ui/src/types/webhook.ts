@@ -23,6 +23,7 @@ export interface IWebhookAPI {
   closeIssuesEndpoint: string;
   postPipelineDeployTaskEndpoint: string;
   postPullRequestsEndpoint: string;
+  postCoverageEndpoint: string;
   apiKey: {
     id: number;
     apiKey: string;
@@ -36,5 +37,6 @@ export interface IWebhook {
   closeIssuesEndpoint: string;
   postPipelineDeployTaskEndpoint: string;
   postPullRequestsEndpoint: string;
+  postCoverageEndpoint: string;
   apiKeyId: number;
 }